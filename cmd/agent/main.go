@@ -1,7 +1,7 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -9,36 +9,56 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/ofkm/arcane-agent/internal/agent"
 	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/logging"
 )
 
 func main() {
 	// Load .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		log.Printf("No .env file found: %v", err)
-	}
+	envErr := godotenv.Load()
 
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	logging.Setup(cfg.LogLevel, cfg.LogFormat)
+	if envErr != nil {
+		slog.Debug("No .env file found", "error", envErr)
 	}
 
 	// Create and start agent
 	agent := agent.New(cfg)
 
-	// Handle shutdown signals
+	// Handle shutdown signals; SIGHUP reloads MAINTENANCE_MODE
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go func() {
-		<-sigChan
-		log.Printf("Received shutdown signal")
-		agent.Stop()
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP {
+				if err := godotenv.Overload(); err != nil {
+					slog.Debug("No .env file found", "error", err)
+				}
+				enabled, err := config.LoadMaintenanceMode()
+				if err != nil {
+					slog.Warn("Ignoring invalid MAINTENANCE_MODE on reload", "error", err)
+					continue
+				}
+				agent.SetMaintenanceMode(enabled)
+				continue
+			}
+			slog.Info("Received shutdown signal")
+			agent.Stop()
+			return
+		}
 	}()
 
 	// Start agent (blocks until shutdown)
 	if err := agent.Start(); err != nil {
-		log.Fatalf("Agent failed: %v", err)
+		slog.Error("Agent failed", "error", err)
+		os.Exit(1)
 	}
 
-	log.Printf("Agent stopped")
+	slog.Info("Agent stopped")
 }
@@ -2,7 +2,7 @@ package agent
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -15,6 +15,7 @@ type Agent struct {
 	config       *config.Config
 	httpClient   *HTTPClient
 	dockerClient *docker.Client
+	dockerStatus *dockerMonitor
 	taskManager  *tasks.Manager
 
 	ctx    context.Context
@@ -28,14 +29,23 @@ type Agent struct {
 func New(cfg *config.Config) *Agent {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	dockerClient := docker.NewClient()
+	dockerClient := docker.NewClientWithOptions(docker.ClientOptions{
+		Binary:  cfg.DockerBin,
+		Host:    cfg.DockerHost,
+		Context: cfg.DockerContext,
+		Scanner: cfg.ImageScanner,
+	})
 	taskManager := tasks.NewManager(dockerClient, cfg)
 	httpClient := NewHTTPClient(cfg, taskManager)
+	dockerStatus := newDockerMonitor(dockerClient, cfg.ReconnectDelay)
+	httpClient.dockerStatus = dockerStatus
+	httpClient.capabilities = newCapabilityDetectors(dockerClient)
 
 	return &Agent{
 		config:       cfg,
 		httpClient:   httpClient,
 		dockerClient: dockerClient,
+		dockerStatus: dockerStatus,
 		taskManager:  taskManager,
 		ctx:          ctx,
 		cancel:       cancel,
@@ -45,27 +55,65 @@ func New(cfg *config.Config) *Agent {
 }
 
 func (a *Agent) Start() error {
-	log.Printf("Starting Arcane Agent %s", a.config.AgentID)
+	slog.Info("Starting Arcane Agent", "agent_id", a.config.AgentID)
+
+	if err := a.dockerClient.ValidateContext(a.ctx); err != nil {
+		return err
+	}
+
+	// Keep probing Docker so the agent recovers when the daemon starts late
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.dockerStatus.Run(a.ctx)
+	}()
 
 	// Start HTTP client (handles registration, heartbeat, and task polling)
 	a.wg.Add(1)
 	go func() {
 		defer a.wg.Done()
 		if err := a.httpClient.Start(a.ctx); err != nil {
-			log.Printf("HTTP client error: %v", err)
+			slog.Error("HTTP client error", "error", err)
 		}
 	}()
 
+	// Keep cached stack statuses fresh for stack_list
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.taskManager.RunStatusRefresher(a.ctx)
+	}()
+
+	// Collect heartbeat metrics on their own, slower schedule
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.taskManager.RunMetricsCollector(a.ctx)
+	}()
+
+	// Pull and redeploy stacks that opted into auto-update
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.taskManager.RunAutoUpdater(a.ctx)
+	}()
+
 	// Wait for shutdown signal
 	<-a.shutdown
 
-	log.Printf("Shutting down agent...")
+	slog.Info("Shutting down agent...")
 	a.cancel()
 	a.wg.Wait()
 
 	return nil
 }
 
+// SetMaintenanceMode turns maintenance mode on or off, e.g. after a config
+// reload
+func (a *Agent) SetMaintenanceMode(enabled bool) {
+	a.taskManager.SetMaintenanceMode(enabled)
+}
+
 func (a *Agent) Stop() {
 	select {
 	case <-a.shutdown:
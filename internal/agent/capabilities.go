@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+// baseCapabilities are advertised by every agent
+var baseCapabilities = []string{"docker", "compose"}
+
+// capabilityDetectors probe the host for the optional features advertised at
+// registration, so the control plane can gate features per agent
+type capabilityDetectors struct {
+	composeV2 func(ctx context.Context) bool
+	swarm     func(ctx context.Context) bool
+	buildx    func(ctx context.Context) bool
+	scanner   func(ctx context.Context) bool
+}
+
+func newCapabilityDetectors(client *docker.Client) *capabilityDetectors {
+	return &capabilityDetectors{
+		composeV2: client.HasComposeV2,
+		swarm:     client.SwarmActive,
+		buildx:    client.HasBuildx,
+		scanner:   client.HasScanner,
+	}
+}
+
+// detect returns the base capabilities followed by each detected feature
+func (d *capabilityDetectors) detect(ctx context.Context) []string {
+	capabilities := append([]string{}, baseCapabilities...)
+	if d == nil {
+		return capabilities
+	}
+
+	for _, feature := range []struct {
+		name   string
+		detect func(ctx context.Context) bool
+	}{
+		{"compose_v2", d.composeV2},
+		{"swarm", d.swarm},
+		{"buildx", d.buildx},
+		{"image_scan", d.scanner},
+	} {
+		if feature.detect != nil && feature.detect(ctx) {
+			capabilities = append(capabilities, feature.name)
+		}
+	}
+	return capabilities
+}
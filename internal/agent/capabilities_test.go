@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/tasks"
+)
+
+func stubDetector(result bool) func(ctx context.Context) bool {
+	return func(ctx context.Context) bool { return result }
+}
+
+func TestCapabilityDetectorsDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		detectors *capabilityDetectors
+		want      []string
+	}{
+		{
+			name:      "no detectors",
+			detectors: nil,
+			want:      []string{"docker", "compose"},
+		},
+		{
+			name: "nothing detected",
+			detectors: &capabilityDetectors{
+				composeV2: stubDetector(false),
+				swarm:     stubDetector(false),
+				buildx:    stubDetector(false),
+				scanner:   stubDetector(false),
+			},
+			want: []string{"docker", "compose"},
+		},
+		{
+			name: "everything detected",
+			detectors: &capabilityDetectors{
+				composeV2: stubDetector(true),
+				swarm:     stubDetector(true),
+				buildx:    stubDetector(true),
+				scanner:   stubDetector(true),
+			},
+			want: []string{"docker", "compose", "compose_v2", "swarm", "buildx", "image_scan"},
+		},
+		{
+			name: "some detected",
+			detectors: &capabilityDetectors{
+				composeV2: stubDetector(true),
+				swarm:     stubDetector(false),
+				buildx:    stubDetector(true),
+			},
+			want: []string{"docker", "compose", "compose_v2", "buildx"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.detectors.detect(context.Background())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterAgentAdvertisesDetectedCapabilities(t *testing.T) {
+	var capabilities []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Capabilities []string `json:"capabilities"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode registration: %v", err)
+		}
+		capabilities = body.Capabilities
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "registered"})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{AgentID: "test-agent"}
+	httpClient := NewHTTPClient(cfg, tasks.NewManager(docker.NewClient(), cfg))
+	httpClient.baseURL = server.URL
+	httpClient.capabilities = &capabilityDetectors{
+		swarm:   stubDetector(true),
+		scanner: stubDetector(true),
+	}
+
+	if err := httpClient.registerAgent(); err != nil {
+		t.Fatalf("registerAgent() error = %v", err)
+	}
+
+	want := []string{"docker", "compose", "swarm", "image_scan"}
+	if !reflect.DeepEqual(capabilities, want) {
+		t.Errorf("Registered capabilities = %v, want %v", capabilities, want)
+	}
+}
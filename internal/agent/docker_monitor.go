@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+// dockerMonitor tracks whether the Docker daemon is reachable. The agent
+// starts even when Docker is down; the monitor keeps probing so the agent
+// recovers on its own once the daemon comes up, and the heartbeat reports the
+// current state in the meantime.
+type dockerMonitor struct {
+	client    *docker.Client
+	interval  time.Duration
+	available atomic.Bool
+	checked   atomic.Bool
+}
+
+// defaultDockerRetryInterval is used when the config leaves RECONNECT_DELAY unset
+const defaultDockerRetryInterval = 5 * time.Second
+
+func newDockerMonitor(client *docker.Client, interval time.Duration) *dockerMonitor {
+	if interval <= 0 {
+		interval = defaultDockerRetryInterval
+	}
+	return &dockerMonitor{client: client, interval: interval}
+}
+
+// Available reports the result of the most recent probe
+func (d *dockerMonitor) Available() bool {
+	return d.available.Load()
+}
+
+// Run probes Docker immediately and then every interval until ctx is cancelled
+func (d *dockerMonitor) Run(ctx context.Context) {
+	d.check()
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.check()
+		}
+	}
+}
+
+// check probes Docker and logs when availability changes
+func (d *dockerMonitor) check() bool {
+	available := d.client.IsDockerAvailable()
+	previous := d.available.Swap(available)
+	first := !d.checked.Swap(true)
+
+	switch {
+	case first && !available:
+		slog.Warn("Docker is unavailable; tasks will fail until it comes up", "retry_interval", d.interval)
+	case !first && previous && !available:
+		slog.Warn("Docker became unavailable", "retry_interval", d.interval)
+	case !first && !previous && available:
+		slog.Info("Docker is available again")
+	}
+	return available
+}
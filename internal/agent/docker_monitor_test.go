@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/tasks"
+)
+
+func TestDockerMonitorRecovers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker is a shell script")
+	}
+
+	// The fake daemon "starts" once the marker file exists
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "started")
+	bin := filepath.Join(dir, "docker")
+	script := "#!/bin/sh\n[ -f " + marker + " ] || { echo 'Cannot connect to the Docker daemon' >&2; exit 1; }\necho '{}'\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker: %v", err)
+	}
+
+	client := docker.NewClientWithOptions(docker.ClientOptions{Binary: bin})
+	monitor := newDockerMonitor(client, 10*time.Millisecond)
+
+	var heartbeat map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/agents/heartbeat" {
+			json.NewDecoder(r.Body).Decode(&heartbeat)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArcaneHost: "localhost", ArcanePort: 3000, AgentID: "test-agent", ComposeBasePath: t.TempDir()}
+	httpClient := NewHTTPClient(cfg, tasks.NewManager(client, cfg))
+	httpClient.baseURL = server.URL
+	httpClient.dockerStatus = monitor
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		monitor.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if monitor.Available() {
+		t.Fatal("Expected Docker to be unavailable before the daemon starts")
+	}
+	if err := httpClient.sendHeartbeat(); err != nil {
+		t.Fatalf("sendHeartbeat() error = %v", err)
+	}
+	if heartbeat["status"] != "degraded" || heartbeat["docker_available"] != false {
+		t.Errorf("Expected degraded heartbeat, got status %v docker_available %v", heartbeat["status"], heartbeat["docker_available"])
+	}
+
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		t.Fatalf("failed to write marker: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !monitor.Available() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected monitor to notice Docker becoming available")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := httpClient.sendHeartbeat(); err != nil {
+		t.Fatalf("sendHeartbeat() error = %v", err)
+	}
+	if heartbeat["status"] != "online" || heartbeat["docker_available"] != true {
+		t.Errorf("Expected online heartbeat, got status %v docker_available %v", heartbeat["status"], heartbeat["docker_available"])
+	}
+}
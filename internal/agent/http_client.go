@@ -5,26 +5,42 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math/rand"
 	"net/http"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+	"github.com/ofkm/arcane-agent/internal/logging"
 	"github.com/ofkm/arcane-agent/internal/tasks"
 	"github.com/ofkm/arcane-agent/internal/version"
 	"github.com/ofkm/arcane-agent/pkg/types"
 )
 
+// defaultTaskPollInterval is used when the config leaves the poll interval unset
+const defaultTaskPollInterval = 5 * time.Second
+
 type HTTPClient struct {
 	config      *config.Config
 	httpClient  *http.Client
 	baseURL     string
 	taskManager *tasks.Manager
+
+	// dockerStatus, when set, reports Docker availability in heartbeats
+	dockerStatus *dockerMonitor
+
+	// capabilities, when set, detects the optional features advertised at
+	// registration
+	capabilities *capabilityDetectors
 }
 
 func NewHTTPClient(cfg *config.Config, taskManager *tasks.Manager) *HTTPClient {
@@ -49,30 +65,52 @@ func (h *HTTPClient) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to register: %v", err)
 	}
 
-	log.Printf("Agent registered successfully")
+	slog.Info("Agent registered successfully")
+
+	interval := h.config.TaskPollInterval
+	if interval <= 0 {
+		interval = defaultTaskPollInterval
+	}
+
+	// Spread out the first poll so a fleet of agents restarting together
+	// does not hit the server at the same instant
+	select {
+	case <-ctx.Done():
+		slog.Info("HTTP client shutting down")
+		return nil
+	case <-time.After(startupJitter(interval)):
+	}
 
 	// Start polling loop
-	ticker := time.NewTicker(5 * time.Second) // Poll every 5 seconds
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("HTTP client shutting down")
+			slog.Info("HTTP client shutting down")
 			return nil
 		case <-ticker.C:
 			// Send heartbeat and check for tasks
 			if err := h.sendHeartbeat(); err != nil {
-				log.Printf("Heartbeat failed: %v", err)
+				slog.Warn("Heartbeat failed", "error", err)
 			}
 
 			if err := h.pollForTasks(); err != nil {
-				log.Printf("Task polling failed: %v", err)
+				slog.Warn("Task polling failed", "error", err)
 			}
 		}
 	}
 }
 
+// startupJitter returns a random delay in [0, interval)
+func startupJitter(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(interval)))
+}
+
 func (h *HTTPClient) registerAgent() error {
 	hostname := getHostname()
 
@@ -82,16 +120,25 @@ func (h *HTTPClient) registerAgent() error {
 		"platform":     runtime.GOOS,
 		"arch":         runtime.GOARCH,
 		"version":      version.GetVersion(),
-		"capabilities": []string{"docker", "compose"},
+		"capabilities": h.capabilities.detect(context.Background()),
+
+		"protocol_version":     ProtocolVersion,
+		"min_protocol_version": MinProtocolVersion,
+		"max_protocol_version": MaxProtocolVersion,
 	}
 
-	return h.makeRequest("POST", "/api/agents/register", regData, nil)
+	body, err := h.doRequest(context.Background(), "POST", "/api/agents/register", regData)
+	if err != nil {
+		return err
+	}
+	return negotiateProtocol(body)
 }
 
 func (h *HTTPClient) sendHeartbeat() error {
 	// Get current metrics
-	metrics, err := h.taskManager.ExecuteTask("metrics", map[string]interface{}{})
-	if err != nil {
+	snapshot := h.taskManager.Heartbeat(context.Background())
+	metrics := snapshot.Metrics
+	if metrics == nil {
 		metrics = map[string]interface{}{
 			"containerCount": 0,
 			"imageCount":     0,
@@ -101,11 +148,32 @@ func (h *HTTPClient) sendHeartbeat() error {
 		}
 	}
 
+	status := "online"
+	if h.dockerStatus != nil && !h.dockerStatus.Available() {
+		status = "degraded"
+	}
+
 	heartbeatData := map[string]interface{}{
 		"agent_id":  h.config.AgentID,
-		"status":    "online",
+		"status":    status,
 		"timestamp": time.Now().Unix(),
 		"metrics":   metrics,
+		"host":      snapshot.Host,
+	}
+
+	if h.dockerStatus != nil {
+		heartbeatData["docker_available"] = h.dockerStatus.Available()
+	}
+	if h.taskManager.MaintenanceMode() {
+		heartbeatData["maintenance_mode"] = true
+	}
+
+	if snapshot.DockerInfo != nil {
+		heartbeatData["docker_info"] = snapshot.DockerInfo
+	}
+
+	if updates := h.taskManager.DrainAutoUpdateEvents(); len(updates) > 0 {
+		heartbeatData["auto_updates"] = updates
 	}
 
 	return h.makeRequest("POST", "/api/agents/heartbeat", heartbeatData, nil)
@@ -120,7 +188,7 @@ func (h *HTTPClient) pollForTasks() error {
 	if err != nil {
 		// Check if it's a JSON parsing error (likely empty response or HTML)
 		if strings.Contains(err.Error(), "invalid character") {
-			log.Printf("No JSON response from tasks endpoint (likely no tasks available)")
+			slog.Debug("No JSON response from tasks endpoint (likely no tasks available)")
 			return nil // Don't treat this as an error
 		}
 		return err
@@ -135,10 +203,14 @@ func (h *HTTPClient) pollForTasks() error {
 }
 
 func (h *HTTPClient) executeTask(task types.TaskRequest) {
-	log.Printf("Executing task %s of type %s", task.ID, task.Type)
+	// The task ID doubles as the correlation ID for everything the task logs
+	// and for the request reporting its result
+	ctx := logging.WithRequestID(context.Background(), task.ID)
+
+	slog.InfoContext(ctx, "Executing task", "task_id", task.ID, "type", task.Type)
 
 	// Execute the task using task manager
-	result, err := h.taskManager.ExecuteTask(task.Type, task.Payload)
+	result, err := h.taskManager.ExecuteTrackedTask(ctx, task.ID, task.Type, task.Payload)
 
 	// Send result back
 	taskResult := types.TaskResult{
@@ -150,58 +222,90 @@ func (h *HTTPClient) executeTask(task types.TaskRequest) {
 	if err != nil {
 		taskResult.Status = "failed"
 		taskResult.Error = err.Error()
-		log.Printf("Task %s failed: %v", task.ID, err)
+		taskResult.ErrorCode = errdefs.Code(err)
+		var composeErr *docker.ComposeError
+		if errors.As(err, &composeErr) {
+			taskResult.ErrorDetails = composeErr
+		}
+		slog.ErrorContext(ctx, "Task failed", "task_id", task.ID, "error", err)
 	} else {
-		log.Printf("Task %s completed successfully", task.ID)
+		slog.InfoContext(ctx, "Task completed successfully", "task_id", task.ID)
 	}
 
 	url := fmt.Sprintf("/api/agents/%s/tasks/%s/result", h.config.AgentID, task.ID)
-	if err := h.makeRequest("POST", url, taskResult, nil); err != nil {
-		log.Printf("Failed to send task result: %v", err)
+	if err := h.makeRequestContext(ctx, "POST", url, taskResult, nil); err != nil {
+		slog.ErrorContext(ctx, "Failed to send task result", "task_id", task.ID, "error", err)
 	}
 }
 
 func (h *HTTPClient) makeRequest(method, path string, body interface{}, response interface{}) error {
+	return h.makeRequestContext(context.Background(), method, path, body, response)
+}
+
+// makeRequestContext sends the request with the correlation ID from ctx,
+// generating a fresh one when ctx has none
+func (h *HTTPClient) makeRequestContext(ctx context.Context, method, path string, body interface{}, response interface{}) error {
+	bodyBytes, err := h.doRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	if response != nil {
+		// Parse the body we already read
+		return json.Unmarshal(bodyBytes, response)
+	}
+
+	return nil
+}
+
+// doRequest sends a JSON request and returns the raw response body, failing on
+// non-2xx statuses
+func (h *HTTPClient) doRequest(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
 	var reqBody io.Reader
 
 	if body != nil {
 		jsonData, err := json.Marshal(body)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		reqBody = bytes.NewBuffer(jsonData)
 	}
 
-	req, err := http.NewRequest(method, h.baseURL+path, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, h.baseURL+path, reqBody)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "arcane-agent/1.1.1")
+	requestID := logging.RequestID(ctx)
+	if requestID == "" {
+		requestID = logging.NewRequestID()
+	}
+	req.Header.Set(logging.RequestIDHeader, requestID)
+	req.Header.Set(protocolVersionHeader, strconv.Itoa(ProtocolVersion))
+	req.Header.Set(supportedProtocolsHeader, supportedProtocolVersions())
+	if h.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.config.Token)
+	}
 
 	resp, err := h.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	// Read the response body first
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("HTTP %d: %s - %s", resp.StatusCode, resp.Status, string(bodyBytes))
+		return nil, fmt.Errorf("HTTP %d: %s - %s", resp.StatusCode, resp.Status, string(bodyBytes))
 	}
 
-	if response != nil {
-		// Parse the body we already read
-		return json.Unmarshal(bodyBytes, response)
-	}
-
-	return nil
+	return bodyBytes, nil
 }
 
 // Helper function to get hostname
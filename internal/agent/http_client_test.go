@@ -1,15 +1,22 @@
 package agent
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ofkm/arcane-agent/internal/config"
 	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/logging"
 	"github.com/ofkm/arcane-agent/internal/tasks"
 	"github.com/ofkm/arcane-agent/pkg/types"
 )
@@ -125,6 +132,32 @@ func TestHTTPClientMakeRequest(t *testing.T) {
 	})
 }
 
+func TestHTTPClientMakeRequestToken(t *testing.T) {
+	var authHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		ArcaneHost: "localhost",
+		ArcanePort: 3000,
+		AgentID:    "test-agent",
+		Token:      "secret-token",
+	}
+
+	httpClient := NewHTTPClient(cfg, tasks.NewManager(docker.NewClient(), cfg))
+	httpClient.baseURL = server.URL
+
+	if err := httpClient.makeRequest("GET", "/api/test", nil, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if authHeader != "Bearer secret-token" {
+		t.Errorf("Expected bearer token header, got '%s'", authHeader)
+	}
+}
+
 func TestHTTPClientStart(t *testing.T) {
 	// Create test server
 	var registrationCalled, heartbeatCalled, tasksCalled bool
@@ -267,6 +300,132 @@ func TestExecuteTask(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 }
 
+func TestExecuteTaskCorrelationID(t *testing.T) {
+	var requestID string
+	var result types.TaskResult
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = r.Header.Get(logging.RequestIDHeader)
+		json.NewDecoder(r.Body).Decode(&result)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		ArcaneHost: "localhost",
+		ArcanePort: 3000,
+		AgentID:    "test-agent",
+	}
+
+	httpClient := NewHTTPClient(cfg, tasks.NewManager(docker.NewClient(), cfg))
+	httpClient.baseURL = server.URL
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.NewLogger(&logs, "info", "json"))
+	defer slog.SetDefault(previous)
+
+	httpClient.executeTask(types.TaskRequest{ID: "task-456", Type: "unknown_task"})
+
+	if requestID != "task-456" {
+		t.Errorf("Expected task ID as %s header, got %q", logging.RequestIDHeader, requestID)
+	}
+	if result.ErrorCode != "invalid_input" {
+		t.Errorf("Expected error_code invalid_input, got %q", result.ErrorCode)
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("Expected task log lines, got %q", logs.String())
+	}
+	for _, line := range lines {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected JSON log record, got %q: %v", line, err)
+		}
+		if record[logging.RequestIDKey] != "task-456" {
+			t.Errorf("Expected request_id task-456 on %q", line)
+		}
+	}
+}
+
+func TestMakeRequestGeneratesRequestID(t *testing.T) {
+	var requestIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestIDs = append(requestIDs, r.Header.Get(logging.RequestIDHeader))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArcaneHost: "localhost", ArcanePort: 3000, AgentID: "test-agent"}
+	httpClient := NewHTTPClient(cfg, tasks.NewManager(docker.NewClient(), cfg))
+	httpClient.baseURL = server.URL
+
+	for i := 0; i < 2; i++ {
+		if err := httpClient.makeRequest("GET", "/api/test", nil, nil); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if requestIDs[0] == "" || requestIDs[0] == requestIDs[1] {
+		t.Errorf("Expected a fresh request ID per request, got %q", requestIDs)
+	}
+}
+
+func TestRegisterProtocolNegotiation(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{"matching version", `{"status":"registered","protocol_version":1}`, false},
+		{"unversioned server", `{"status":"registered"}`, false},
+		{"non-JSON body", `OK`, false},
+		{"newer server", `{"status":"registered","protocol_version":99}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var registration map[string]interface{}
+			var header, supported string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header.Get(protocolVersionHeader)
+				supported = r.Header.Get(supportedProtocolsHeader)
+				json.NewDecoder(r.Body).Decode(&registration)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{ArcaneHost: "localhost", ArcanePort: 3000, AgentID: "test-agent"}
+			httpClient := NewHTTPClient(cfg, tasks.NewManager(docker.NewClient(), cfg))
+			httpClient.baseURL = server.URL
+
+			err := httpClient.registerAgent()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("registerAgent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if header != "1" || supported != "1" {
+				t.Errorf("protocol headers = %q, %q, want 1, 1", header, supported)
+			}
+			if registration["protocol_version"] != float64(ProtocolVersion) {
+				t.Errorf("registration protocol_version = %v, want %d", registration["protocol_version"], ProtocolVersion)
+			}
+		})
+	}
+}
+
+func TestStartupJitter(t *testing.T) {
+	if got := startupJitter(0); got != 0 {
+		t.Errorf("startupJitter(0) = %v, want 0", got)
+	}
+
+	interval := 5 * time.Second
+	for i := 0; i < 100; i++ {
+		if got := startupJitter(interval); got < 0 || got >= interval {
+			t.Fatalf("startupJitter(%v) = %v, want within [0, %v)", interval, got, interval)
+		}
+	}
+}
+
 func TestGetHostname(t *testing.T) {
 	hostname := getHostname()
 
@@ -278,3 +437,49 @@ func TestGetHostname(t *testing.T) {
 		t.Log("Hostname returned 'unknown' (this might be expected in some environments)")
 	}
 }
+
+func TestExecuteTaskComposeErrorDetails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker-compose is a shell script")
+	}
+
+	bin := t.TempDir()
+	script := `#!/bin/sh
+echo " Container web-web-1  Starting"
+echo "Error response from daemon: driver failed programming external connectivity on endpoint web-web-1 (abc): Bind for 0.0.0.0:80 failed: port is already allocated"
+exit 1
+`
+	if err := os.WriteFile(filepath.Join(bin, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker-compose: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var result struct {
+		Error        string                 `json:"error"`
+		ErrorCode    string                 `json:"error_code"`
+		ErrorDetails map[string]interface{} `json:"error_details"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&result)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ArcaneHost: "localhost", ArcanePort: 3000, AgentID: "test-agent", ComposeBasePath: t.TempDir()}
+	httpClient := NewHTTPClient(cfg, tasks.NewManager(docker.NewClient(), cfg))
+	httpClient.baseURL = server.URL
+
+	httpClient.executeTask(types.TaskRequest{ID: "task-789", Type: "compose_up", Payload: map[string]interface{}{"project_name": "web"}})
+
+	if result.ErrorCode != "conflict" {
+		t.Errorf("Expected error_code conflict, got %q", result.ErrorCode)
+	}
+	if result.ErrorDetails["service"] != "web" || result.ErrorDetails["reason"] != "port 0.0.0.0:80 is already allocated" {
+		t.Errorf("Unexpected error_details %v", result.ErrorDetails)
+	}
+	if raw, _ := result.ErrorDetails["raw_output"].(string); !strings.Contains(raw, "driver failed programming") {
+		t.Errorf("Expected raw_output in error_details, got %q", raw)
+	}
+	if strings.Contains(result.Error, "driver failed programming") {
+		t.Errorf("Expected a concise error message, got %q", result.Error)
+	}
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+// Protocol versions of the agent/server message format. The agent speaks
+// ProtocolVersion and accepts servers anywhere in [MinProtocolVersion,
+// MaxProtocolVersion].
+const (
+	ProtocolVersion    = 1
+	MinProtocolVersion = 1
+	MaxProtocolVersion = 1
+)
+
+// Headers advertising the agent's protocol on every request
+const (
+	protocolVersionHeader    = "X-Arcane-Protocol-Version"
+	supportedProtocolsHeader = "X-Arcane-Protocol-Versions"
+)
+
+// supportedProtocolVersions formats the accepted range, e.g. "1" or "1-2"
+func supportedProtocolVersions() string {
+	if MinProtocolVersion == MaxProtocolVersion {
+		return strconv.Itoa(MinProtocolVersion)
+	}
+	return fmt.Sprintf("%d-%d", MinProtocolVersion, MaxProtocolVersion)
+}
+
+// negotiateProtocol checks the protocol_version the server returned on
+// registration. Servers that predate versioning send none and are accepted
+// with a warning; a version outside the supported range is rejected.
+func negotiateProtocol(body []byte) error {
+	var response struct {
+		ProtocolVersion int `json:"protocol_version"`
+	}
+	// Registration responses are not required to be JSON
+	_ = json.Unmarshal(body, &response)
+
+	version := response.ProtocolVersion
+	if version == 0 {
+		slog.Warn("Server did not report a protocol version; assuming compatibility", "agent_protocol", ProtocolVersion)
+		return nil
+	}
+	if version < MinProtocolVersion || version > MaxProtocolVersion {
+		return fmt.Errorf("server protocol version %d is not supported (agent supports %s)", version, supportedProtocolVersions())
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package compose
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// maxArchiveSize caps the total extracted size of an imported stack archive
+const maxArchiveSize = 100 << 20
+
+// composeFileNames are the root-level file names recognized as a stack's compose file
+var composeFileNames = []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"}
+
+// ImportProjectArchive creates a project from a .tar.gz holding exactly one
+// compose file at its root plus any supporting files. The archive is
+// extracted to a staging directory and renamed into place, so a failed import
+// leaves nothing behind.
+func (m *Manager) ImportProjectArchive(projectName string, r io.Reader) error {
+	if err := ValidateProjectName(projectName); err != nil {
+		return err
+	}
+
+	projectPath := m.GetProjectPath(projectName)
+	if _, err := os.Stat(projectPath); err == nil {
+		return fmt.Errorf("project %s already exists", projectName)
+	}
+
+	if err := m.EnsureBaseDirectory(); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(m.basePath, ".import-"+projectName+"-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if err := extractArchive(r, staging); err != nil {
+		return err
+	}
+
+	composeFile, err := findRootComposeFile(staging)
+	if err != nil {
+		return err
+	}
+
+	meta, err := rebuildArchivedMetadata(staging, composeFile)
+	if err != nil {
+		return err
+	}
+	metaPath := filepath.Join(staging, MetadataFile)
+	if err := os.Remove(metaPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to drop archived metadata: %w", err)
+	}
+	if len(meta.ComposeFiles) > 0 || len(meta.Profiles) > 0 {
+		data, err := jsonMetadata(meta)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(metaPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to write metadata: %w", err)
+		}
+	}
+
+	if err := os.Rename(staging, projectPath); err != nil {
+		return fmt.Errorf("failed to move imported project into place: %w", err)
+	}
+	return nil
+}
+
+// rebuildArchivedMetadata derives an imported stack's metadata. Only the
+// compose file layering and profiles are taken from an archived metadata
+// file: its git source, compose project name and auto-update setting could
+// point the new stack at another stack's containers or outside its directory.
+func rebuildArchivedMetadata(staging, composeFile string) (*ProjectMetadata, error) {
+	meta := &ProjectMetadata{}
+	if data, err := os.ReadFile(filepath.Join(staging, MetadataFile)); err == nil {
+		archived := &ProjectMetadata{}
+		if err := json.Unmarshal(data, archived); err != nil {
+			return nil, fmt.Errorf("%w: invalid archived metadata: %w", errdefs.ErrInvalidInput, err)
+		}
+		for _, file := range archived.ComposeFiles {
+			if !filepath.IsLocal(file) {
+				return nil, fmt.Errorf("%w: archived compose file %q is outside the project", errdefs.ErrInvalidInput, file)
+			}
+			if _, err := os.Stat(filepath.Join(staging, file)); err != nil {
+				return nil, fmt.Errorf("%w: archived compose file %q is missing", errdefs.ErrInvalidInput, file)
+			}
+		}
+		meta.ComposeFiles = archived.ComposeFiles
+		meta.Profiles = archived.Profiles
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read archived metadata: %w", err)
+	}
+
+	if len(meta.ComposeFiles) == 0 && composeFile != "docker-compose.yml" {
+		meta.ComposeFiles = []string{composeFile}
+	}
+	return meta, nil
+}
+
+// extractArchive unpacks a gzipped tarball into dest, rejecting entries that
+// would escape it and anything other than regular files and directories
+func extractArchive(r io.Reader, dest string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("invalid archive: %w", err)
+	}
+	defer gz.Close()
+
+	var total int64
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid archive: %w", err)
+		}
+
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if name == "." {
+			continue
+		}
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %q escapes the project directory", header.Name)
+		}
+		target := filepath.Join(dest, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			total += header.Size
+			if total > maxArchiveSize {
+				return fmt.Errorf("archive exceeds %d bytes", maxArchiveSize)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := writeArchiveFile(target, tr, header); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("archive entry %q has unsupported type", header.Name)
+		}
+	}
+}
+
+func writeArchiveFile(target string, r io.Reader, header *tar.Header) error {
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm()|0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.CopyN(file, r, header.Size); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", header.Name, err)
+	}
+	return nil
+}
+
+func findRootComposeFile(dir string) (string, error) {
+	var found []string
+	for _, name := range composeFileNames {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			found = append(found, name)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("archive has no compose file at its root")
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("archive has multiple compose files at its root: %s", strings.Join(found, ", "))
+	}
+}
+
+// ProjectArchiveName is the file name used when exporting a project
+func ProjectArchiveName(projectName string) string {
+	return projectName + ".tar.gz"
+}
+
+// ExportProjectArchive writes a project's directory as a .tar.gz to w. The
+// .git directory is always skipped, and .env files only included when
+// includeEnv is set, so exports can be shared without leaking secrets.
+func (m *Manager) ExportProjectArchive(projectName string, w io.Writer, includeEnv bool) error {
+	if !m.ProjectExists(projectName) {
+		return fmt.Errorf("%w: project %s does not exist", errdefs.ErrNotFound, projectName)
+	}
+	projectPath := m.GetProjectPath(projectName)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(projectPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == projectPath {
+			return nil
+		}
+
+		rel, err := filepath.Rel(projectPath, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !includeEnv && d.Name() == ".env" {
+			return nil
+		}
+		// Only plain files and directories round-trip through import
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive project %s: %w", projectName, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
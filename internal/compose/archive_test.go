@@ -0,0 +1,256 @@
+package compose
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type archiveEntry struct {
+	name     string
+	body     string
+	typeflag byte
+}
+
+func buildArchive(t *testing.T, entries []archiveEntry) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		typeflag := entry.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		header := &tar.Header{Name: entry.name, Mode: 0644, Size: int64(len(entry.body)), Typeflag: typeflag}
+		if typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		if typeflag == tar.TypeSymlink {
+			header.Linkname = "/etc/passwd"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(entry.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestImportProjectArchive(t *testing.T) {
+	tempDir := t.TempDir()
+	manager := NewManager(tempDir)
+
+	archive := buildArchive(t, []archiveEntry{
+		{name: "compose.yaml", body: "services:\n  web:\n    image: nginx\n"},
+		{name: "config/", typeflag: tar.TypeDir},
+		{name: "config/nginx.conf", body: "server {}\n"},
+		{name: ".env", body: "PORT=80\n"},
+	})
+
+	if err := manager.ImportProjectArchive("imported", archive); err != nil {
+		t.Fatalf("ImportProjectArchive() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "imported", "config", "nginx.conf"))
+	if err != nil || string(data) != "server {}\n" {
+		t.Errorf("supporting file not extracted: %q, %v", data, err)
+	}
+
+	files := manager.GetComposeFiles("imported", "")
+	if len(files) != 1 || filepath.Base(files[0]) != "compose.yaml" {
+		t.Errorf("expected compose.yaml to be recorded, got %v", files)
+	}
+
+	projects, err := manager.ListProjects()
+	if err != nil || len(projects) != 1 {
+		t.Errorf("expected imported project to be listed, got %v, %v", projects, err)
+	}
+
+	if err := manager.ImportProjectArchive("imported", buildArchive(t, []archiveEntry{{name: "compose.yaml", body: "services: {}"}})); err == nil {
+		t.Error("expected error importing over an existing project")
+	}
+}
+
+func TestImportProjectArchiveDropsMetadata(t *testing.T) {
+	manager := NewManager(t.TempDir())
+
+	archive := buildArchive(t, []archiveEntry{
+		{name: "docker-compose.yml", body: "services:\n  web:\n    image: nginx\n"},
+		{name: MetadataFile, body: `{"compose_project_name":"other","git":{"repo_url":"x","sub_path":"sub"}}`},
+	})
+	if err := manager.ImportProjectArchive("imported", archive); err != nil {
+		t.Fatalf("ImportProjectArchive() error = %v", err)
+	}
+
+	meta, err := manager.LoadMetadata("imported")
+	if err != nil {
+		t.Fatalf("LoadMetadata() error = %v", err)
+	}
+	if meta.ComposeProjectName != "" || meta.Git != nil {
+		t.Errorf("archived metadata was kept: %+v", meta)
+	}
+}
+
+func TestImportProjectArchiveRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []archiveEntry
+	}{
+		{"path traversal", []archiveEntry{
+			{name: "compose.yaml", body: "services: {}"},
+			{name: "../escape.txt", body: "pwned"},
+		}},
+		{"nested traversal", []archiveEntry{
+			{name: "compose.yaml", body: "services: {}"},
+			{name: "config/../../escape.txt", body: "pwned"},
+		}},
+		{"absolute path", []archiveEntry{
+			{name: "/tmp/escape.txt", body: "pwned"},
+		}},
+		{"symlink", []archiveEntry{
+			{name: "compose.yaml", body: "services: {}"},
+			{name: "passwd", typeflag: tar.TypeSymlink},
+		}},
+		{"missing compose file", []archiveEntry{
+			{name: "README.md", body: "hello"},
+			{name: "sub/compose.yaml", body: "services: {}"},
+		}},
+		{"metadata compose file outside project", []archiveEntry{
+			{name: "docker-compose.yml", body: "services: {}"},
+			{name: MetadataFile, body: `{"compose_files":["../x/docker-compose.yml"]}`},
+		}},
+		{"multiple compose files", []archiveEntry{
+			{name: "compose.yaml", body: "services: {}"},
+			{name: "docker-compose.yml", body: "services: {}"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			manager := NewManager(filepath.Join(tempDir, "stacks"))
+
+			if err := manager.ImportProjectArchive("stack", buildArchive(t, tt.entries)); err == nil {
+				t.Fatal("expected import to fail")
+			}
+
+			if manager.ProjectExists("stack") {
+				t.Error("failed import must not leave a project behind")
+			}
+			if _, err := os.Stat(filepath.Join(tempDir, "escape.txt")); err == nil {
+				t.Error("traversal entry was written outside the project")
+			}
+			entries, _ := os.ReadDir(filepath.Join(tempDir, "stacks"))
+			if len(entries) != 0 {
+				t.Errorf("expected staging directory to be cleaned up, found %d entries", len(entries))
+			}
+		})
+	}
+
+	if err := NewManager(t.TempDir()).ImportProjectArchive("stack", bytes.NewBufferString("not gzip")); err == nil {
+		t.Error("expected error for non-gzip input")
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	manager := NewManager(tempDir)
+
+	err := manager.CreateProject(ProjectConfig{
+		Name:            "source",
+		Content:         "services:\n  web:\n    image: nginx\n",
+		EnvVars:         map[string]string{"PORT": "80"},
+		OverrideContent: "services:\n  web:\n    ports:\n      - 8080:80\n",
+		Files:           map[string]string{"secrets/api_key.txt": "s3cr3t"},
+	})
+	if err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+	sourcePath := manager.GetProjectPath("source")
+	os.MkdirAll(filepath.Join(sourcePath, "config"), 0755)
+	os.WriteFile(filepath.Join(sourcePath, "config", "app.ini"), []byte("[app]\n"), 0644)
+	os.MkdirAll(filepath.Join(sourcePath, ".git"), 0755)
+	os.WriteFile(filepath.Join(sourcePath, ".git", "HEAD"), []byte("ref: main\n"), 0644)
+
+	var archive bytes.Buffer
+	if err := manager.ExportProjectArchive("source", &archive, true); err != nil {
+		t.Fatalf("ExportProjectArchive() error = %v", err)
+	}
+	if err := manager.ImportProjectArchive("copy", &archive); err != nil {
+		t.Fatalf("ImportProjectArchive() error = %v", err)
+	}
+
+	copyPath := manager.GetProjectPath("copy")
+	for _, rel := range []string{"docker-compose.yml", OverrideFile, ".env", MetadataFile, filepath.Join("config", "app.ini"), filepath.Join("secrets", "api_key.txt")} {
+		want, err := os.ReadFile(filepath.Join(sourcePath, rel))
+		if err != nil {
+			t.Fatalf("failed to read source %s: %v", rel, err)
+		}
+		got, err := os.ReadFile(filepath.Join(copyPath, rel))
+		if err != nil {
+			t.Errorf("%s missing after round trip: %v", rel, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs after round trip", rel)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(copyPath, ".git")); err == nil {
+		t.Error("expected .git to be excluded from export")
+	}
+	if got, want := manager.GetComposeFiles("copy", ""), 2; len(got) != want {
+		t.Errorf("expected layered compose files to survive the round trip, got %v", got)
+	}
+}
+
+func TestExportProjectArchiveExcludesEnv(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	err := manager.CreateProject(ProjectConfig{
+		Name:    "secret",
+		Content: "services: {}\n",
+		EnvVars: map[string]string{"TOKEN": "hunter2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	if err := manager.ExportProjectArchive("secret", &archive, false); err != nil {
+		t.Fatalf("ExportProjectArchive() error = %v", err)
+	}
+
+	gz, err := gzip.NewReader(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		if filepath.Base(header.Name) == ".env" {
+			t.Error("expected .env to be excluded")
+		}
+	}
+
+	if err := manager.ExportProjectArchive("missing", &archive, true); err == nil {
+		t.Error("expected error for missing project")
+	}
+}
@@ -0,0 +1,200 @@
+package compose
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProjectSpec is the subset of the normalized `docker compose config --format json`
+// output the agent inspects
+type ProjectSpec struct {
+	Name     string                 `json:"name"`
+	Services map[string]ServiceSpec `json:"services"`
+}
+
+// ServiceSpec describes a single service in a normalized compose config
+type ServiceSpec struct {
+	Image   string        `json:"image,omitempty"`
+	Restart string        `json:"restart,omitempty"`
+	Volumes []VolumeMount `json:"volumes,omitempty"`
+
+	// DependsOn is keyed by the name of the service depended on
+	DependsOn map[string]json.RawMessage `json:"depends_on,omitempty"`
+}
+
+// VolumeMount is a service volume in compose long syntax
+type VolumeMount struct {
+	Type     string `json:"type"`
+	Source   string `json:"source,omitempty"`
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"read_only,omitempty"`
+}
+
+// ParseProjectSpec parses the JSON emitted by `docker compose config --format json`
+func ParseProjectSpec(data []byte) (*ProjectSpec, error) {
+	var spec ProjectSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse compose config: %w", err)
+	}
+	return &spec, nil
+}
+
+// ConfigHash returns a stable SHA-256 fingerprint of a normalized compose
+// config. The JSON is re-encoded first, so key order and whitespace do not
+// change the hash.
+func ConfigHash(data []byte) (string, error) {
+	var config interface{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", fmt.Errorf("failed to parse compose config: %w", err)
+	}
+
+	canonical, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ServiceNames returns the declared service names in sorted order
+func (p *ProjectSpec) ServiceNames() []string {
+	names := make([]string, 0, len(p.Services))
+	for name := range p.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ImageRefs returns the distinct image references used by the project's services
+func (p *ProjectSpec) ImageRefs() []string {
+	seen := map[string]bool{}
+	images := []string{}
+	for _, name := range p.ServiceNames() {
+		image := p.Services[name].Image
+		if image == "" || seen[image] {
+			continue
+		}
+		seen[image] = true
+		images = append(images, image)
+	}
+	return images
+}
+
+// ValidateBindMounts ensures every bind mount source lives under one of the
+// allowed host paths. An empty allowlist permits all bind mounts.
+func ValidateBindMounts(spec *ProjectSpec, allowed []string) error {
+	if len(allowed) == 0 || spec == nil {
+		return nil
+	}
+
+	for serviceName, service := range spec.Services {
+		for _, mount := range service.Volumes {
+			if mount.Type != "bind" {
+				continue
+			}
+			if !IsPathAllowed(mount.Source, allowed) {
+				return fmt.Errorf("service %s: bind mount source %s is not in the allowed host paths", serviceName, mount.Source)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateDependencies ensures the depends_on graph has no cycles, which
+// would leave compose unable to order service startup. The error names the
+// services in the cycle, e.g. "a -> b -> c -> a".
+func ValidateDependencies(spec *ProjectSpec) error {
+	if spec == nil {
+		return nil
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(spec.Services))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for i, service := range path {
+				if service == name {
+					start = i
+					break
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), name)
+			return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range sortedKeys(spec.Services[name].DependsOn) {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range spec.ServiceNames() {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsPathAllowed reports whether path equals or is nested under one of the allowed paths
+func IsPathAllowed(path string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	if !filepath.IsAbs(path) {
+		return false
+	}
+
+	cleaned := resolveSymlinks(filepath.Clean(path))
+	for _, prefix := range allowed {
+		prefix = resolveSymlinks(filepath.Clean(prefix))
+		if cleaned == prefix || strings.HasPrefix(cleaned, prefix+string(filepath.Separator)) || prefix == string(filepath.Separator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveSymlinks resolves symlinks in the longest existing prefix of path, so
+// a link inside an allowed directory cannot point outside of it. Components
+// that do not exist yet, e.g. a bind source docker will create, are kept as-is.
+func resolveSymlinks(path string) string {
+	rest := ""
+	for dir := path; ; {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
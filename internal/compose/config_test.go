@@ -0,0 +1,237 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const sampleProjectConfig = `{
+  "name": "web-app",
+  "services": {
+    "web": {
+      "image": "nginx:latest",
+      "volumes": [
+        {"type": "bind", "source": "/srv/web/html", "target": "/usr/share/nginx/html", "read_only": true},
+        {"type": "volume", "source": "cache", "target": "/var/cache/nginx"}
+      ]
+    },
+    "db": {
+      "image": "postgres:16",
+      "volumes": [
+        {"type": "bind", "source": "/etc/passwd", "target": "/etc/passwd"}
+      ]
+    }
+  }
+}`
+
+func TestParseProjectSpec(t *testing.T) {
+	spec, err := ParseProjectSpec([]byte(sampleProjectConfig))
+	if err != nil {
+		t.Fatalf("ParseProjectSpec failed: %v", err)
+	}
+
+	if spec.Name != "web-app" {
+		t.Errorf("Expected name 'web-app', got '%s'", spec.Name)
+	}
+
+	web, ok := spec.Services["web"]
+	if !ok {
+		t.Fatal("Expected 'web' service")
+	}
+
+	if len(web.Volumes) != 2 {
+		t.Fatalf("Expected 2 volumes, got %d", len(web.Volumes))
+	}
+
+	if web.Volumes[0].Type != "bind" || !web.Volumes[0].ReadOnly {
+		t.Errorf("Expected read-only bind mount, got %+v", web.Volumes[0])
+	}
+
+	if _, err := ParseProjectSpec([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestValidateBindMounts(t *testing.T) {
+	spec, err := ParseProjectSpec([]byte(sampleProjectConfig))
+	if err != nil {
+		t.Fatalf("ParseProjectSpec failed: %v", err)
+	}
+
+	if err := ValidateBindMounts(spec, nil); err != nil {
+		t.Errorf("Expected empty allowlist to permit all mounts, got %v", err)
+	}
+
+	if err := ValidateBindMounts(spec, []string{"/srv"}); err == nil {
+		t.Error("Expected /etc/passwd bind mount to be rejected")
+	}
+
+	if err := ValidateBindMounts(spec, []string{"/srv", "/etc/passwd"}); err != nil {
+		t.Errorf("Expected all mounts to be allowed, got %v", err)
+	}
+}
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantCycle string
+	}{
+		{
+			name: "dag",
+			config: `{"services": {
+				"web": {"depends_on": {"api": {"condition": "service_started"}, "cache": {"condition": "service_started"}}},
+				"api": {"depends_on": {"db": {"condition": "service_healthy"}, "cache": {"condition": "service_started"}}},
+				"cache": {},
+				"db": {}
+			}}`,
+		},
+		{
+			name: "three service cycle",
+			config: `{"services": {
+				"a": {"depends_on": {"b": {"condition": "service_started"}}},
+				"b": {"depends_on": {"c": {"condition": "service_started"}}},
+				"c": {"depends_on": {"a": {"condition": "service_started"}}},
+				"d": {"depends_on": {"a": {"condition": "service_started"}}}
+			}}`,
+			wantCycle: "a -> b -> c -> a",
+		},
+		{
+			name:      "self dependency",
+			config:    `{"services": {"a": {"depends_on": {"a": {"condition": "service_started"}}}}}`,
+			wantCycle: "a -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseProjectSpec([]byte(tt.config))
+			if err != nil {
+				t.Fatalf("ParseProjectSpec failed: %v", err)
+			}
+
+			err = ValidateDependencies(spec)
+			if tt.wantCycle == "" {
+				if err != nil {
+					t.Errorf("Expected no cycle, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantCycle) {
+				t.Errorf("Expected cycle %q, got %v", tt.wantCycle, err)
+			}
+		})
+	}
+}
+
+func TestIsPathAllowed(t *testing.T) {
+	allowed := []string{"/srv/data", "/opt/apps/"}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"/srv/data", true},
+		{"/srv/data/web", true},
+		{"/opt/apps/site/config", true},
+		{"/srv/database", false},
+		{"/srv/data/../../etc", false},
+		{"relative/path", false},
+		{"/etc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsPathAllowed(tt.path, allowed); got != tt.expected {
+				t.Errorf("IsPathAllowed(%q) = %v, expected %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsPathAllowedResolvesSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on windows")
+	}
+
+	allowed := filepath.Join(t.TempDir(), "data")
+	outside := t.TempDir()
+	if err := os.MkdirAll(allowed, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(allowed, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{filepath.Join(allowed, "new", "dir"), true},
+		{filepath.Join(allowed, "escape"), false},
+		{filepath.Join(allowed, "escape", "etc"), false},
+	}
+	for _, tt := range tests {
+		if got := IsPathAllowed(tt.path, []string{allowed}); got != tt.expected {
+			t.Errorf("IsPathAllowed(%q) = %v, expected %v", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	a := []byte(`{"name":"web","services":{"web":{"image":"nginx"},"db":{"image":"postgres"}}}`)
+	b := []byte(`{
+  "services": {
+    "db": {"image": "postgres"},
+    "web": {"image": "nginx"}
+  },
+  "name": "web"
+}`)
+	c := []byte(`{"name":"web","services":{"web":{"image":"nginx:1.27"},"db":{"image":"postgres"}}}`)
+
+	hashA, err := ConfigHash(a)
+	if err != nil {
+		t.Fatalf("ConfigHash failed: %v", err)
+	}
+	hashB, _ := ConfigHash(b)
+	hashC, _ := ConfigHash(c)
+
+	if hashA != hashB {
+		t.Error("Expected hash to ignore key order and whitespace")
+	}
+	if hashA == hashC {
+		t.Error("Expected hash to change when the config changes")
+	}
+	if len(hashA) != 64 {
+		t.Errorf("Expected 64 character hex digest, got %d", len(hashA))
+	}
+
+	if _, err := ConfigHash([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestProjectSpecServicesAndImages(t *testing.T) {
+	spec, err := ParseProjectSpec([]byte(`{"services":{
+		"web":{"image":"nginx:latest"},
+		"worker":{"image":"nginx:latest"},
+		"db":{"image":"postgres:16"},
+		"built":{}
+	}}`))
+	if err != nil {
+		t.Fatalf("ParseProjectSpec failed: %v", err)
+	}
+
+	names := spec.ServiceNames()
+	if strings.Join(names, ",") != "built,db,web,worker" {
+		t.Errorf("Expected sorted service names, got %v", names)
+	}
+
+	images := spec.ImageRefs()
+	if strings.Join(images, ",") != "postgres:16,nginx:latest" {
+		t.Errorf("Expected distinct images in service order, got %v", images)
+	}
+}
@@ -0,0 +1,172 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// CreateProjectFromGit shallow-clones a repository into a new project directory
+// and records its origin in the project metadata. The token is only used for
+// the clone and is not written to disk. Cancelling ctx kills the clone.
+func (m *Manager) CreateProjectFromGit(ctx context.Context, projectName, repoURL, ref, subPath, token string) error {
+	if err := ValidateProjectName(projectName); err != nil {
+		return err
+	}
+	if repoURL == "" {
+		return fmt.Errorf("repository URL is required")
+	}
+	if err := validateGitSource(&GitSource{RepoURL: repoURL, Ref: ref, SubPath: subPath}); err != nil {
+		return err
+	}
+
+	projectPath := m.GetProjectPath(projectName)
+	if _, err := os.Stat(projectPath); err == nil {
+		return fmt.Errorf("project %s already exists", projectName)
+	}
+
+	cloneURL, err := authenticatedURL(repoURL, token)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"clone", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "--", cloneURL, projectPath)
+
+	if output, err := runGit(ctx, "", args...); err != nil {
+		os.RemoveAll(projectPath)
+		return fmt.Errorf("git clone failed: %s", redact(output, token))
+	}
+
+	// Make sure the remote stored in .git/config carries no credentials
+	if output, err := runGit(ctx, projectPath, "remote", "set-url", "--", "origin", repoURL); err != nil {
+		os.RemoveAll(projectPath)
+		return fmt.Errorf("failed to reset git remote: %s", redact(output, token))
+	}
+
+	if _, err := os.Stat(filepath.Join(projectPath, subPath)); err != nil {
+		os.RemoveAll(projectPath)
+		return fmt.Errorf("sub path %s not found in repository", subPath)
+	}
+
+	meta := &ProjectMetadata{
+		Git: &GitSource{
+			RepoURL: repoURL,
+			Ref:     ref,
+			SubPath: subPath,
+		},
+	}
+	if err := m.SaveMetadata(projectName, meta); err != nil {
+		os.RemoveAll(projectPath)
+		return err
+	}
+
+	return nil
+}
+
+// PullProjectFromGit fetches the configured ref of a git-backed project and
+// resets the working tree to it. Cancelling ctx kills the fetch.
+func (m *Manager) PullProjectFromGit(ctx context.Context, projectName, token string) error {
+	if err := ValidateProjectName(projectName); err != nil {
+		return err
+	}
+
+	meta, err := m.LoadMetadata(projectName)
+	if err != nil {
+		return err
+	}
+	if meta.Git == nil {
+		return fmt.Errorf("project %s is not git-backed", projectName)
+	}
+
+	fetchURL, err := authenticatedURL(meta.Git.RepoURL, token)
+	if err != nil {
+		return err
+	}
+
+	ref := meta.Git.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	projectPath := m.GetProjectPath(projectName)
+	if output, err := runGit(ctx, projectPath, "fetch", "--depth", "1", "--", fetchURL, ref); err != nil {
+		return fmt.Errorf("git fetch failed: %s", redact(output, token))
+	}
+
+	if output, err := runGit(ctx, projectPath, "reset", "--hard", "FETCH_HEAD"); err != nil {
+		return fmt.Errorf("git reset failed: %s", output)
+	}
+
+	return nil
+}
+
+// authenticatedURL embeds a token into an HTTP(S) repository URL
+func authenticatedURL(repoURL, token string) (string, error) {
+	if token == "" {
+		return repoURL, nil
+	}
+
+	parsed, err := url.Parse(repoURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid repository URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("tokens are only supported for http(s) repository URLs")
+	}
+
+	parsed.User = url.UserPassword("x-access-token", token)
+	return parsed.String(), nil
+}
+
+// validateGitSource rejects a repository URL or ref git would parse as an
+// option, and a sub path escaping the repository
+func validateGitSource(source *GitSource) error {
+	if strings.HasPrefix(source.RepoURL, "-") {
+		return fmt.Errorf("%w: invalid repository URL %q", errdefs.ErrInvalidInput, source.RepoURL)
+	}
+	if strings.HasPrefix(source.Ref, "-") {
+		return fmt.Errorf("%w: invalid ref %q", errdefs.ErrInvalidInput, source.Ref)
+	}
+	if err := validateSubPath(source.SubPath); err != nil {
+		return fmt.Errorf("%w: %w", errdefs.ErrInvalidInput, err)
+	}
+	return nil
+}
+
+// validateSubPath rejects absolute paths and paths escaping the repository
+func validateSubPath(subPath string) error {
+	if subPath == "" {
+		return nil
+	}
+	cleaned := filepath.Clean(subPath)
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("invalid sub path %s", subPath)
+	}
+	return nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Never block waiting for interactive credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
+
+func redact(output, token string) string {
+	if token == "" {
+		return output
+	}
+	return strings.ReplaceAll(output, token, "***")
+}
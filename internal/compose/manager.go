@@ -4,7 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
 )
 
 type Manager struct {
@@ -17,8 +23,23 @@ type ProjectConfig struct {
 	Content     string            `json:"content"`                // Docker compose YAML content
 	EnvVars     map[string]string `json:"env_vars,omitempty"`     // Environment variables for .env file
 	Override    bool              `json:"override,omitempty"`     // Whether to override existing files
+	AutoUpdate  *bool             `json:"auto_update,omitempty"`  // Whether the agent should keep images up to date; nil keeps the current setting
+
+	// OverrideContent is written to OverrideFile and layered on top of the main compose file
+	OverrideContent string `json:"override_content,omitempty"`
+	// ComposeFiles is an explicit ordered list of compose files, relative to the project directory
+	ComposeFiles []string `json:"compose_files,omitempty"`
+	// Files holds extra project files such as file-backed secrets, keyed by
+	// path relative to the project directory
+	Files map[string]string `json:"files,omitempty"`
+	// ComposeProjectName is the compose project name when it differs from Name.
+	// Left empty on update, the previously recorded name is kept.
+	ComposeProjectName string `json:"compose_project_name,omitempty"`
 }
 
+// OverrideFile is the file name used for ProjectConfig.OverrideContent
+const OverrideFile = "compose.override.yaml"
+
 func NewManager(basePath string) *Manager {
 	return &Manager{
 		basePath: basePath,
@@ -35,18 +56,49 @@ func (m *Manager) EnsureBaseDirectory() error {
 
 // CreateProject creates a new compose project directory with files
 func (m *Manager) CreateProject(config ProjectConfig) error {
-	if config.Name == "" {
-		return fmt.Errorf("project name is required")
+	if err := ValidateProjectName(config.Name); err != nil {
+		return err
 	}
 
 	if config.Content == "" {
 		return fmt.Errorf("compose content is required")
 	}
 
+	if config.ComposeProjectName != "" {
+		if err := ValidateComposeProjectName(config.ComposeProjectName); err != nil {
+			return err
+		}
+	}
+
 	// Set default compose file name
 	if config.ComposeFile == "" {
 		config.ComposeFile = "docker-compose.yml"
 	}
+	if !filepath.IsLocal(config.ComposeFile) {
+		return fmt.Errorf("%w: compose file %q must be inside the project directory", errdefs.ErrInvalidInput, config.ComposeFile)
+	}
+
+	composeFiles := config.ComposeFiles
+	if config.OverrideContent != "" && len(composeFiles) == 0 {
+		composeFiles = []string{config.ComposeFile, OverrideFile}
+	}
+	for _, file := range composeFiles {
+		if file == "" || validateSubPath(file) != nil {
+			return fmt.Errorf("invalid compose file %q", file)
+		}
+	}
+	extraFiles := make([]string, 0, len(config.Files))
+	for file := range config.Files {
+		if err := validateProjectFile(file); err != nil {
+			return err
+		}
+		// The compose, override and .env files have their own fields
+		if slices.Contains([]string{config.ComposeFile, OverrideFile, ".env"}, filepath.Clean(file)) {
+			return fmt.Errorf("%w: project file %q is written from its own field", errdefs.ErrInvalidInput, file)
+		}
+		extraFiles = append(extraFiles, file)
+	}
+	sort.Strings(extraFiles)
 
 	projectPath := filepath.Join(m.basePath, config.Name)
 
@@ -70,6 +122,47 @@ func (m *Manager) CreateProject(config ProjectConfig) error {
 		}
 	}
 
+	if config.OverrideContent != "" {
+		overridePath := filepath.Join(projectPath, OverrideFile)
+		if err := m.writeFileIfNotExists(overridePath, config.OverrideContent, config.Override); err != nil {
+			return fmt.Errorf("failed to create override file: %w", err)
+		}
+	}
+
+	for _, file := range extraFiles {
+		filePath := filepath.Join(projectPath, file)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", file, err)
+		}
+		if err := m.writeFileIfNotExists(filePath, config.Files[file], config.Override); err != nil {
+			return fmt.Errorf("failed to create project file: %w", err)
+		}
+	}
+
+	// Only touch metadata when a persisted setting changes
+	meta, err := m.LoadMetadata(config.Name)
+	if err != nil {
+		return err
+	}
+	changed := false
+	if config.AutoUpdate != nil && meta.AutoUpdate != *config.AutoUpdate {
+		meta.AutoUpdate = *config.AutoUpdate
+		changed = true
+	}
+	if len(composeFiles) > 0 && !slices.Equal(meta.ComposeFiles, composeFiles) {
+		meta.ComposeFiles = composeFiles
+		changed = true
+	}
+	if config.ComposeProjectName != "" && meta.ComposeProjectName != config.ComposeProjectName {
+		meta.ComposeProjectName = config.ComposeProjectName
+		changed = true
+	}
+	if changed {
+		if err := m.SaveMetadata(config.Name, meta); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -81,15 +174,15 @@ func (m *Manager) UpdateProject(config ProjectConfig) error {
 
 // DeleteProject removes a project directory
 func (m *Manager) DeleteProject(projectName string) error {
-	if projectName == "" {
-		return fmt.Errorf("project name is required")
+	if err := ValidateProjectName(projectName); err != nil {
+		return err
 	}
 
 	projectPath := filepath.Join(m.basePath, projectName)
 
 	// Check if project exists
 	if _, err := os.Stat(projectPath); os.IsNotExist(err) {
-		return fmt.Errorf("project %s does not exist", projectName)
+		return fmt.Errorf("%w: project %s does not exist", errdefs.ErrNotFound, projectName)
 	}
 
 	// Remove project directory
@@ -110,8 +203,8 @@ func (m *Manager) ListProjects() ([]map[string]interface{}, error) {
 
 	projects := make([]map[string]interface{}, 0)
 	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue // Skip non-directories
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue // Skip non-directories and in-progress imports
 		}
 
 		projectName := entry.Name()
@@ -124,10 +217,11 @@ func (m *Manager) ListProjects() ([]map[string]interface{}, error) {
 		}
 
 		// Look for compose file
-		composeFilePath := filepath.Join(projectPath, "docker-compose.yml")
+		composeDir := m.GetComposeDir(projectName)
+		composeFilePath := m.GetComposeFiles(projectName, "")[0]
 		if _, err := os.Stat(composeFilePath); os.IsNotExist(err) {
 			// Try alternate filename
-			composeFilePath = filepath.Join(projectPath, "compose.yml")
+			composeFilePath = filepath.Join(composeDir, "compose.yml")
 			if _, err := os.Stat(composeFilePath); os.IsNotExist(err) {
 				continue // Skip if no compose file
 			}
@@ -138,7 +232,7 @@ func (m *Manager) ListProjects() ([]map[string]interface{}, error) {
 
 		// Check for .env file
 		envContent := ""
-		envFilePath := filepath.Join(projectPath, ".env")
+		envFilePath := filepath.Join(composeDir, ".env")
 		if envBytes, err := os.ReadFile(envFilePath); err == nil {
 			envContent = string(envBytes)
 		}
@@ -164,8 +258,48 @@ func (m *Manager) ListProjects() ([]map[string]interface{}, error) {
 	return projects, nil
 }
 
+// ValidateProjectName rejects names that are not a single path element, so a
+// project directory can never resolve outside the base path
+func ValidateProjectName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: project name is required", errdefs.ErrInvalidInput)
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") || filepath.IsAbs(name) {
+		return fmt.Errorf("%w: invalid project name %q", errdefs.ErrInvalidInput, name)
+	}
+	return nil
+}
+
+// composeProjectNamePattern matches the project names docker compose accepts
+var composeProjectNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidateComposeProjectName checks a compose project name override: lowercase
+// letters, digits, dashes and underscores, starting with a letter or digit
+func ValidateComposeProjectName(name string) error {
+	if !composeProjectNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: invalid compose project name %q", errdefs.ErrInvalidInput, name)
+	}
+	return nil
+}
+
+// validateProjectFile checks the path of an extra project file: relative,
+// inside the project directory and not one of the agent's own files
+func validateProjectFile(file string) error {
+	cleaned := filepath.Clean(file)
+	switch {
+	case file == "" || cleaned == "." || validateSubPath(file) != nil:
+		return fmt.Errorf("%w: invalid project file path %q", errdefs.ErrInvalidInput, file)
+	case cleaned == MetadataFile || cleaned == ".git" || strings.HasPrefix(cleaned, ".git"+string(filepath.Separator)):
+		return fmt.Errorf("%w: project file %q is reserved", errdefs.ErrInvalidInput, file)
+	}
+	return nil
+}
+
 // ProjectExists checks if a project directory exists
 func (m *Manager) ProjectExists(projectName string) bool {
+	if ValidateProjectName(projectName) != nil {
+		return false
+	}
 	projectPath := filepath.Join(m.basePath, projectName)
 	_, err := os.Stat(projectPath)
 	return !os.IsNotExist(err)
@@ -181,7 +315,58 @@ func (m *Manager) GetComposePath(projectName, composeFile string) string {
 	if composeFile == "" {
 		composeFile = "docker-compose.yml"
 	}
-	return filepath.Join(m.basePath, projectName, composeFile)
+	return filepath.Join(m.GetComposeDir(projectName), composeFile)
+}
+
+// GetComposeFiles returns the ordered compose files for a project. An explicit
+// composeFile wins; otherwise the files recorded in metadata are used, falling
+// back to docker-compose.yml.
+func (m *Manager) GetComposeFiles(projectName, composeFile string) []string {
+	if composeFile == "" {
+		if meta, err := m.LoadMetadata(projectName); err == nil && len(meta.ComposeFiles) > 0 {
+			dir := m.GetComposeDir(projectName)
+			files := make([]string, 0, len(meta.ComposeFiles))
+			for _, file := range meta.ComposeFiles {
+				files = append(files, filepath.Join(dir, file))
+			}
+			return files
+		}
+	}
+	return []string{m.GetComposePath(projectName, composeFile)}
+}
+
+// SelectComposeFiles resolves an explicit, ordered selection of compose files
+// for a project. Each file must be a path inside the project's compose
+// directory and must already exist there.
+func (m *Manager) SelectComposeFiles(projectName string, files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: no compose files selected", errdefs.ErrInvalidInput)
+	}
+
+	dir := m.GetComposeDir(projectName)
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		if file == "" || !filepath.IsLocal(file) {
+			return nil, fmt.Errorf("%w: compose file %q must be a relative path inside the stack directory", errdefs.ErrInvalidInput, file)
+		}
+		path := filepath.Join(dir, file)
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%w: compose file %s does not exist in project %s", errdefs.ErrNotFound, file, projectName)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// GetComposeDir returns the directory holding a project's compose files. For
+// git-backed projects this is the configured sub path within the clone.
+func (m *Manager) GetComposeDir(projectName string) string {
+	dir := filepath.Join(m.basePath, projectName)
+	if meta, err := m.LoadMetadata(projectName); err == nil && meta.Git != nil && meta.Git.SubPath != "" {
+		dir = filepath.Join(dir, meta.Git.SubPath)
+	}
+	return dir
 }
 
 // writeFileIfNotExists writes content to a file, optionally overriding existing files
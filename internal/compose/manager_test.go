@@ -1,9 +1,14 @@
 package compose
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
 )
 
 func TestNewManager(t *testing.T) {
@@ -86,6 +91,28 @@ func TestCreateProject(t *testing.T) {
 	}
 }
 
+func TestUpdateProjectKeepsAutoUpdate(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	enabled, disabled := true, false
+
+	if err := manager.CreateProject(ProjectConfig{Name: "web", Content: "services: {}", AutoUpdate: &enabled}); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	if err := manager.UpdateProject(ProjectConfig{Name: "web", Content: "services: {}\n"}); err != nil {
+		t.Fatalf("UpdateProject failed: %v", err)
+	}
+	if meta, err := manager.LoadMetadata("web"); err != nil || !meta.AutoUpdate {
+		t.Errorf("expected auto-update to survive an update without the setting, got %+v, %v", meta, err)
+	}
+
+	if err := manager.UpdateProject(ProjectConfig{Name: "web", Content: "services: {}\n", AutoUpdate: &disabled}); err != nil {
+		t.Fatalf("UpdateProject failed: %v", err)
+	}
+	if meta, err := manager.LoadMetadata("web"); err != nil || meta.AutoUpdate {
+		t.Errorf("expected auto-update to be turned off, got %+v, %v", meta, err)
+	}
+}
+
 func TestCreateProjectWithCustomComposeFile(t *testing.T) {
 	tempDir := filepath.Join(os.TempDir(), "arcane-test-compose")
 	defer os.RemoveAll(tempDir)
@@ -111,6 +138,80 @@ func TestCreateProjectWithCustomComposeFile(t *testing.T) {
 	}
 }
 
+func TestCreateProjectWithOverride(t *testing.T) {
+	tempDir := t.TempDir()
+	manager := NewManager(tempDir)
+
+	config := ProjectConfig{
+		Name:            "layered",
+		Content:         "services:\n  web:\n    image: nginx",
+		OverrideContent: "services:\n  web:\n    ports:\n      - 8080:80",
+	}
+	if err := manager.CreateProject(config); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "layered", OverrideFile)); err != nil {
+		t.Errorf("Override file was not created: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "layered", "docker-compose.yml"),
+		filepath.Join(tempDir, "layered", OverrideFile),
+	}
+	if got := manager.GetComposeFiles("layered", ""); !slices.Equal(got, expected) {
+		t.Errorf("GetComposeFiles() = %v, want %v", got, expected)
+	}
+
+	// An explicit compose file bypasses the recorded layering
+	explicit := manager.GetComposeFiles("layered", "other.yml")
+	if len(explicit) != 1 || explicit[0] != filepath.Join(tempDir, "layered", "other.yml") {
+		t.Errorf("GetComposeFiles() with explicit file = %v", explicit)
+	}
+
+	// Updating without override settings keeps the recorded files
+	config.OverrideContent = ""
+	if err := manager.UpdateProject(config); err != nil {
+		t.Fatalf("UpdateProject failed: %v", err)
+	}
+	if got := manager.GetComposeFiles("layered", ""); !slices.Equal(got, expected) {
+		t.Errorf("GetComposeFiles() after update = %v, want %v", got, expected)
+	}
+}
+
+func TestCreateProjectComposeFilesOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	manager := NewManager(tempDir)
+
+	err := manager.CreateProject(ProjectConfig{
+		Name:         "ordered",
+		Content:      "services:\n  web:\n    image: nginx",
+		ComposeFiles: []string{"docker-compose.yml", "compose.prod.yaml", "compose.override.yaml"},
+	})
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	got := manager.GetComposeFiles("ordered", "")
+	want := []string{"docker-compose.yml", "compose.prod.yaml", "compose.override.yaml"}
+	for i, file := range want {
+		if filepath.Base(got[i]) != file {
+			t.Errorf("file %d = %s, want %s", i, got[i], file)
+		}
+	}
+
+	for _, bad := range []string{"../escape.yml", "/etc/compose.yml", ""} {
+		err := manager.CreateProject(ProjectConfig{
+			Name:         "bad",
+			Content:      "services: {}",
+			ComposeFiles: []string{bad},
+		})
+		if err == nil {
+			t.Errorf("expected error for compose file %q", bad)
+		}
+	}
+}
+
 func TestCreateProjectValidation(t *testing.T) {
 	tempDir := filepath.Join(os.TempDir(), "arcane-test-compose")
 	defer os.RemoveAll(tempDir)
@@ -257,7 +358,208 @@ func TestProjectExists(t *testing.T) {
 	}
 }
 
+func TestValidateProjectName(t *testing.T) {
+	valid := []string{"web", "my-stack", "stack_2", "app.v2", "..hidden"}
+	for _, name := range valid {
+		if err := ValidateProjectName(name); err != nil {
+			t.Errorf("ValidateProjectName(%q) = %v, expected nil", name, err)
+		}
+	}
+
+	invalid := []string{"", ".", "..", "../../etc", "a/b", "/etc", `..\windows`, "web/..", "we\x00b"}
+	for _, name := range invalid {
+		err := ValidateProjectName(name)
+		if !errors.Is(err, errdefs.ErrInvalidInput) {
+			t.Errorf("ValidateProjectName(%q) = %v, expected ErrInvalidInput", name, err)
+		}
+	}
+}
+
+func TestCreateProjectFiles(t *testing.T) {
+	manager := NewManager(t.TempDir())
+
+	err := manager.CreateProject(ProjectConfig{
+		Name:    "db",
+		Content: "services:\n  db:\n    image: postgres:16\n    secrets: [db_password]\nsecrets:\n  db_password:\n    file: ./secrets/db_password.txt\n",
+		Files:   map[string]string{"secrets/db_password.txt": "hunter2"},
+	})
+	if err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(manager.GetProjectPath("db"), "secrets", "db_password.txt"))
+	if err != nil || string(content) != "hunter2" {
+		t.Errorf("secret file = %q, %v, want hunter2", content, err)
+	}
+
+	// Existing files are only replaced on override
+	if err := manager.CreateProject(ProjectConfig{
+		Name:    "db",
+		Content: "services: {}\n",
+		Files:   map[string]string{"secrets/db_password.txt": "changed"},
+	}); err == nil {
+		t.Error("Expected error writing over an existing file without override")
+	}
+
+	invalid := []string{"", ".", "/etc/passwd", "../escape.txt", "secrets/../../escape.txt", MetadataFile, ".git/config", "docker-compose.yml", ".env"}
+	for _, file := range invalid {
+		err := manager.CreateProject(ProjectConfig{
+			Name:     "rejected",
+			Content:  "services: {}\n",
+			Files:    map[string]string{file: "x"},
+			Override: true,
+		})
+		if !errors.Is(err, errdefs.ErrInvalidInput) {
+			t.Errorf("CreateProject() with file %q = %v, expected ErrInvalidInput", file, err)
+		}
+	}
+	if manager.ProjectExists("rejected") {
+		t.Error("Expected no project directory after rejected files")
+	}
+}
+
+func TestValidateComposeProjectName(t *testing.T) {
+	for _, name := range []string{"web", "shared-infra", "app_2", "0stack"} {
+		if err := ValidateComposeProjectName(name); err != nil {
+			t.Errorf("ValidateComposeProjectName(%q) = %v, expected nil", name, err)
+		}
+	}
+	for _, name := range []string{"", "Web", "-web", "_web", "my stack", "a/b"} {
+		if err := ValidateComposeProjectName(name); !errors.Is(err, errdefs.ErrInvalidInput) {
+			t.Errorf("ValidateComposeProjectName(%q) = %v, expected ErrInvalidInput", name, err)
+		}
+	}
+}
+
+func TestProjectPathTraversal(t *testing.T) {
+	root := t.TempDir()
+	basePath := filepath.Join(root, "stacks")
+	manager := NewManager(basePath)
+	if err := manager.EnsureBaseDirectory(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A sibling directory a traversal attempt would otherwise reach
+	victim := filepath.Join(root, "victim")
+	if err := os.MkdirAll(victim, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{"../victim", "../../etc", victim}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			err := manager.CreateProject(ProjectConfig{Name: name, Content: "services: {}"})
+			if !errors.Is(err, errdefs.ErrInvalidInput) {
+				t.Errorf("CreateProject(%q) = %v, expected ErrInvalidInput", name, err)
+			}
+			if err := manager.DeleteProject(name); !errors.Is(err, errdefs.ErrInvalidInput) {
+				t.Errorf("DeleteProject(%q) = %v, expected ErrInvalidInput", name, err)
+			}
+			if err := manager.ImportProjectArchive(name, strings.NewReader("")); !errors.Is(err, errdefs.ErrInvalidInput) {
+				t.Errorf("ImportProjectArchive(%q) = %v, expected ErrInvalidInput", name, err)
+			}
+			if _, err := manager.LoadMetadata(name); !errors.Is(err, errdefs.ErrInvalidInput) {
+				t.Errorf("LoadMetadata(%q) = %v, expected ErrInvalidInput", name, err)
+			}
+			if manager.ProjectExists(name) {
+				t.Errorf("ProjectExists(%q) = true, expected false", name)
+			}
+		})
+	}
+
+	for _, file := range []string{"../../victim/docker-compose.yml", "../x.yml", filepath.Join(victim, "docker-compose.yml")} {
+		t.Run("compose file "+file, func(t *testing.T) {
+			err := manager.CreateProject(ProjectConfig{Name: "web", ComposeFile: file, Content: "services: {}"})
+			if !errors.Is(err, errdefs.ErrInvalidInput) {
+				t.Errorf("CreateProject(ComposeFile: %q) = %v, expected ErrInvalidInput", file, err)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(basePath, "x.yml")); !os.IsNotExist(err) {
+		t.Errorf("Expected no compose file written beside the stack, got %v", err)
+	}
+
+	if _, err := os.Stat(victim); err != nil {
+		t.Errorf("Expected directory outside the base path to survive, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(victim, "docker-compose.yml")); !os.IsNotExist(err) {
+		t.Errorf("Expected no compose file written outside the base path, got %v", err)
+	}
+}
+
+func TestLoadMetadataRejectsEscapes(t *testing.T) {
+	tests := []struct {
+		name string
+		meta string
+	}{
+		{"git sub path", `{"git":{"repo_url":"https://example.com/r.git","sub_path":"../../.."}}`},
+		{"compose file", `{"compose_files":["../other/docker-compose.yml"]}`},
+		{"absolute compose file", `{"compose_files":["/etc/compose.yml"]}`},
+		{"compose project name", `{"compose_project_name":"../Other"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager(t.TempDir())
+			if err := manager.CreateProject(ProjectConfig{Name: "web", Content: "services: {}"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(manager.GetProjectPath("web"), MetadataFile), []byte(tt.meta), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := manager.LoadMetadata("web"); !errors.Is(err, errdefs.ErrInvalidInput) {
+				t.Errorf("LoadMetadata() = %v, expected ErrInvalidInput", err)
+			}
+			if dir := manager.GetComposeDir("web"); dir != manager.GetProjectPath("web") {
+				t.Errorf("GetComposeDir() = %s, expected the project directory", dir)
+			}
+		})
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr ||
 		(len(s) > len(substr) && contains(s[1:], substr))
 }
+
+func TestSelectComposeFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	manager := NewManager(tempDir)
+
+	err := manager.CreateProject(ProjectConfig{
+		Name:    "shop",
+		Content: "services:\n  web:\n    image: nginx",
+		Files:   map[string]string{"compose.prod.yaml": "services:\n  web:\n    restart: always"},
+	})
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	dir := filepath.Join(tempDir, "shop")
+	got, err := manager.SelectComposeFiles("shop", []string{"compose.prod.yaml", "docker-compose.yml"})
+	if err != nil {
+		t.Fatalf("SelectComposeFiles() error = %v", err)
+	}
+	if want := []string{filepath.Join(dir, "compose.prod.yaml"), filepath.Join(dir, "docker-compose.yml")}; !slices.Equal(got, want) {
+		t.Errorf("SelectComposeFiles() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  error
+	}{
+		{"none", nil, errdefs.ErrInvalidInput},
+		{"outside the stack", []string{"../other/docker-compose.yml"}, errdefs.ErrInvalidInput},
+		{"absolute", []string{"/etc/compose.yaml"}, errdefs.ErrInvalidInput},
+		{"missing", []string{"compose.staging.yaml"}, errdefs.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.SelectComposeFiles("shop", tt.files); !errors.Is(err, tt.want) {
+				t.Errorf("SelectComposeFiles() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
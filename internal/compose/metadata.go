@@ -0,0 +1,118 @@
+package compose
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// MetadataFile is the per-project file recording how a stack was created
+const MetadataFile = ".stack-metadata.json"
+
+// ProjectMetadata holds agent-managed settings persisted alongside a project
+type ProjectMetadata struct {
+	Git        *GitSource `json:"git,omitempty"`
+	AutoUpdate bool       `json:"auto_update,omitempty"`
+	// ComposeFiles lists the compose files passed as repeated -f flags, in order
+	ComposeFiles []string `json:"compose_files,omitempty"`
+	// Profiles are the compose profiles enabled by the last deploy, used by
+	// later operations that do not name profiles themselves
+	Profiles []string `json:"profiles,omitempty"`
+	// ComposeProjectName overrides the folder name as the compose project (-p)
+	// for every operation on the stack. External networks and volumes the
+	// stack references must already exist; compose never creates them.
+	ComposeProjectName string `json:"compose_project_name,omitempty"`
+}
+
+// GitSource records the origin of a git-backed project. Credentials are never stored.
+type GitSource struct {
+	RepoURL string `json:"repo_url"`
+	Ref     string `json:"ref,omitempty"`
+	SubPath string `json:"sub_path,omitempty"`
+}
+
+// LoadMetadata reads a project's metadata, returning empty metadata when none exists
+func (m *Manager) LoadMetadata(projectName string) (*ProjectMetadata, error) {
+	if err := ValidateProjectName(projectName); err != nil {
+		return nil, err
+	}
+
+	meta := &ProjectMetadata{}
+
+	data, err := os.ReadFile(filepath.Join(m.GetProjectPath(projectName), MetadataFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return meta, nil
+		}
+		return nil, fmt.Errorf("failed to read metadata for %s: %w", projectName, err)
+	}
+
+	if err := json.Unmarshal(data, meta); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata for %s: %w", projectName, err)
+	}
+	if err := meta.validate(); err != nil {
+		return nil, fmt.Errorf("invalid metadata for %s: %w", projectName, err)
+	}
+
+	return meta, nil
+}
+
+// validate rejects metadata whose paths would leave the project directory or
+// whose compose project name would act on another stack
+func (meta *ProjectMetadata) validate() error {
+	if meta.Git != nil {
+		if err := validateGitSource(meta.Git); err != nil {
+			return err
+		}
+	}
+	for _, file := range meta.ComposeFiles {
+		if !filepath.IsLocal(file) {
+			return fmt.Errorf("%w: compose file %q is outside the project", errdefs.ErrInvalidInput, file)
+		}
+	}
+	if meta.ComposeProjectName != "" {
+		return ValidateComposeProjectName(meta.ComposeProjectName)
+	}
+	return nil
+}
+
+// SaveMetadata writes a project's metadata file
+func (m *Manager) SaveMetadata(projectName string, meta *ProjectMetadata) error {
+	if err := ValidateProjectName(projectName); err != nil {
+		return err
+	}
+
+	data, err := jsonMetadata(meta)
+	if err != nil {
+		return err
+	}
+
+	metaPath := filepath.Join(m.GetProjectPath(projectName), MetadataFile)
+	if err := os.WriteFile(metaPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write metadata for %s: %w", projectName, err)
+	}
+
+	return nil
+}
+
+// SetProfiles records the active compose profiles for a project
+func (m *Manager) SetProfiles(projectName string, profiles []string) error {
+	meta, err := m.LoadMetadata(projectName)
+	if err != nil {
+		return err
+	}
+	if slices.Equal(meta.Profiles, profiles) {
+		return nil
+	}
+
+	meta.Profiles = profiles
+	return m.SaveMetadata(projectName, meta)
+}
+
+func jsonMetadata(meta *ProjectMetadata) ([]byte, error) {
+	return json.MarshalIndent(meta, "", "  ")
+}
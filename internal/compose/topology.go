@@ -0,0 +1,183 @@
+package compose
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Topology is the declared shape of a project: every service in the compose
+// files whether or not it is running, plus the networks and volumes they use
+type Topology struct {
+	Services []ServiceTopology `json:"services"`
+	Networks []string          `json:"networks"`
+	Volumes  []string          `json:"volumes"`
+}
+
+// ServiceTopology describes one declared service
+type ServiceTopology struct {
+	Name        string            `json:"name"`
+	Image       string            `json:"image,omitempty"`
+	DependsOn   []Dependency      `json:"depends_on"`
+	Networks    []string          `json:"networks"`
+	Volumes     []VolumeMount     `json:"volumes"`
+	Ports       []PortMapping     `json:"ports"`
+	Environment map[string]string `json:"environment"`
+	HealthCheck *HealthCheck      `json:"healthcheck,omitempty"`
+}
+
+// Dependency is a depends_on entry in compose long syntax
+type Dependency struct {
+	Service   string `json:"service"`
+	Condition string `json:"condition,omitempty"`
+	Required  bool   `json:"required"`
+}
+
+// PortMapping is a published or exposed service port
+type PortMapping struct {
+	Target    int    `json:"target"`
+	Published string `json:"published,omitempty"`
+	Protocol  string `json:"protocol,omitempty"`
+	HostIP    string `json:"host_ip,omitempty"`
+}
+
+// HealthCheck is a service healthcheck as declared in the compose file
+type HealthCheck struct {
+	Test        []string `json:"test,omitempty"`
+	Interval    string   `json:"interval,omitempty"`
+	Timeout     string   `json:"timeout,omitempty"`
+	StartPeriod string   `json:"start_period,omitempty"`
+	Retries     int      `json:"retries,omitempty"`
+	Disable     bool     `json:"disable,omitempty"`
+}
+
+type topologyConfig struct {
+	Services map[string]struct {
+		Image     string `json:"image"`
+		DependsOn map[string]struct {
+			Condition string `json:"condition"`
+			Required  *bool  `json:"required"`
+		} `json:"depends_on"`
+		Networks    map[string]json.RawMessage `json:"networks"`
+		Volumes     []VolumeMount              `json:"volumes"`
+		Ports       []topologyPort             `json:"ports"`
+		Environment map[string]*string         `json:"environment"`
+		HealthCheck *HealthCheck               `json:"healthcheck"`
+	} `json:"services"`
+	Networks map[string]json.RawMessage `json:"networks"`
+	Volumes  map[string]json.RawMessage `json:"volumes"`
+}
+
+type topologyPort struct {
+	Target    int             `json:"target"`
+	Published json.RawMessage `json:"published"`
+	Protocol  string          `json:"protocol"`
+	HostIP    string          `json:"host_ip"`
+}
+
+// ParseTopology builds a project's topology from the JSON emitted by
+// `docker compose config --format json`. Everything is sorted by name so the
+// result is stable across calls.
+func ParseTopology(data []byte) (*Topology, error) {
+	var config topologyConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse compose config: %w", err)
+	}
+
+	topology := &Topology{
+		Services: make([]ServiceTopology, 0, len(config.Services)),
+		Networks: sortedKeys(config.Networks),
+		Volumes:  sortedKeys(config.Volumes),
+	}
+
+	for _, name := range sortedKeys(config.Services) {
+		service := config.Services[name]
+
+		st := ServiceTopology{
+			Name:        name,
+			Image:       service.Image,
+			DependsOn:   make([]Dependency, 0, len(service.DependsOn)),
+			Networks:    sortedKeys(service.Networks),
+			Volumes:     service.Volumes,
+			Ports:       make([]PortMapping, 0, len(service.Ports)),
+			Environment: make(map[string]string, len(service.Environment)),
+			HealthCheck: service.HealthCheck,
+		}
+		if st.Volumes == nil {
+			st.Volumes = []VolumeMount{}
+		}
+
+		for _, dep := range sortedKeys(service.DependsOn) {
+			spec := service.DependsOn[dep]
+			// Compose treats dependencies as required unless told otherwise
+			required := spec.Required == nil || *spec.Required
+			st.DependsOn = append(st.DependsOn, Dependency{Service: dep, Condition: spec.Condition, Required: required})
+		}
+
+		for _, port := range service.Ports {
+			st.Ports = append(st.Ports, PortMapping{
+				Target:    port.Target,
+				Published: publishedPort(port.Published),
+				Protocol:  port.Protocol,
+				HostIP:    port.HostIP,
+			})
+		}
+
+		for key, value := range service.Environment {
+			if value != nil {
+				st.Environment[key] = *value
+			} else {
+				st.Environment[key] = ""
+			}
+		}
+
+		topology.Services = append(topology.Services, st)
+	}
+
+	return topology, nil
+}
+
+// ServiceNames returns the declared service names in sorted order
+func (t *Topology) ServiceNames() []string {
+	names := make([]string, 0, len(t.Services))
+	for _, service := range t.Services {
+		names = append(names, service.Name)
+	}
+	return names
+}
+
+// Images returns the distinct image references of the declared services in
+// sorted order. Services that are only built have no image and are skipped.
+func (t *Topology) Images() []string {
+	seen := map[string]bool{}
+	images := []string{}
+	for _, service := range t.Services {
+		if service.Image == "" || seen[service.Image] {
+			continue
+		}
+		seen[service.Image] = true
+		images = append(images, service.Image)
+	}
+	sort.Strings(images)
+	return images
+}
+
+// publishedPort accepts the published port as either a string or a number,
+// which differs between compose versions
+func publishedPort(raw json.RawMessage) string {
+	value := strings.TrimSpace(string(raw))
+	if value == "" || value == "null" {
+		return ""
+	}
+	return strings.Trim(value, `"`)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
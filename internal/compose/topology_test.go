@@ -0,0 +1,117 @@
+package compose
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sampleTopologyConfig mirrors `docker compose config --format json` for a
+// three-tier stack where only some services would be running
+const sampleTopologyConfig = `{
+  "name": "shop",
+  "services": {
+    "web": {
+      "image": "nginx:1.27",
+      "depends_on": {
+        "api": {"condition": "service_healthy", "required": true},
+        "cache": {"condition": "service_started", "required": false}
+      },
+      "networks": {"front": null, "back": {"aliases": ["www"]}},
+      "ports": [
+        {"mode": "ingress", "target": 80, "published": "8080", "protocol": "tcp"},
+        {"mode": "ingress", "host_ip": "127.0.0.1", "target": 443, "published": 8443, "protocol": "tcp"}
+      ]
+    },
+    "api": {
+      "image": "shop/api:2",
+      "depends_on": {"db": {"condition": "service_healthy"}},
+      "environment": {"DATABASE_URL": "postgres://db/shop", "DEBUG": null},
+      "networks": {"back": null},
+      "healthcheck": {
+        "test": ["CMD", "curl", "-f", "http://localhost/health"],
+        "interval": "30s",
+        "timeout": "5s",
+        "retries": 3
+      }
+    },
+    "db": {
+      "image": "postgres:16",
+      "volumes": [{"type": "volume", "source": "pgdata", "target": "/var/lib/postgresql/data"}],
+      "networks": {"back": null}
+    },
+    "cache": {"image": "redis:7"}
+  },
+  "networks": {"front": {"name": "shop_front"}, "back": {"name": "shop_back"}},
+  "volumes": {"pgdata": {"name": "shop_pgdata"}}
+}`
+
+func TestParseTopology(t *testing.T) {
+	topology, err := ParseTopology([]byte(sampleTopologyConfig))
+	if err != nil {
+		t.Fatalf("ParseTopology failed: %v", err)
+	}
+
+	var names []string
+	services := map[string]ServiceTopology{}
+	for _, service := range topology.Services {
+		names = append(names, service.Name)
+		services[service.Name] = service
+	}
+	if !reflect.DeepEqual(names, []string{"api", "cache", "db", "web"}) {
+		t.Errorf("Expected services sorted by name, got %v", names)
+	}
+	if !reflect.DeepEqual(topology.Networks, []string{"back", "front"}) {
+		t.Errorf("Expected networks [back front], got %v", topology.Networks)
+	}
+	if !reflect.DeepEqual(topology.Volumes, []string{"pgdata"}) {
+		t.Errorf("Expected volumes [pgdata], got %v", topology.Volumes)
+	}
+
+	web := services["web"]
+	wantDeps := []Dependency{
+		{Service: "api", Condition: "service_healthy", Required: true},
+		{Service: "cache", Condition: "service_started", Required: false},
+	}
+	if !reflect.DeepEqual(web.DependsOn, wantDeps) {
+		t.Errorf("web depends_on = %+v, want %+v", web.DependsOn, wantDeps)
+	}
+	if !reflect.DeepEqual(web.Networks, []string{"back", "front"}) {
+		t.Errorf("web networks = %v", web.Networks)
+	}
+	wantPorts := []PortMapping{
+		{Target: 80, Published: "8080", Protocol: "tcp"},
+		{Target: 443, Published: "8443", Protocol: "tcp", HostIP: "127.0.0.1"},
+	}
+	if !reflect.DeepEqual(web.Ports, wantPorts) {
+		t.Errorf("web ports = %+v, want %+v", web.Ports, wantPorts)
+	}
+
+	api := services["api"]
+	if len(api.DependsOn) != 1 || api.DependsOn[0].Service != "db" || !api.DependsOn[0].Required {
+		t.Errorf("Expected api to require db by default, got %+v", api.DependsOn)
+	}
+	wantEnv := map[string]string{"DATABASE_URL": "postgres://db/shop", "DEBUG": ""}
+	if !reflect.DeepEqual(api.Environment, wantEnv) {
+		t.Errorf("api environment = %v, want %v", api.Environment, wantEnv)
+	}
+	if api.HealthCheck == nil || api.HealthCheck.Interval != "30s" || api.HealthCheck.Retries != 3 || len(api.HealthCheck.Test) != 4 {
+		t.Errorf("Unexpected api healthcheck %+v", api.HealthCheck)
+	}
+
+	db := services["db"]
+	if len(db.Volumes) != 1 || db.Volumes[0].Source != "pgdata" {
+		t.Errorf("Unexpected db volumes %+v", db.Volumes)
+	}
+
+	cache := services["cache"]
+	if cache.DependsOn == nil || cache.Networks == nil || cache.Volumes == nil || cache.Ports == nil || cache.Environment == nil {
+		t.Errorf("Expected empty collections rather than nil for cache, got %+v", cache)
+	}
+	if cache.HealthCheck != nil {
+		t.Errorf("Expected no healthcheck for cache, got %+v", cache.HealthCheck)
+	}
+
+	if _, err := ParseTopology([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
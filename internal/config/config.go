@@ -1,10 +1,15 @@
 package config
 
 import (
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,20 +17,129 @@ type Config struct {
 	ArcaneHost      string        `json:"arcane_host"`
 	ArcanePort      int           `json:"arcane_port"`
 	AgentID         string        `json:"agent_id"`
+	Token           string        `json:"-"`
 	TLSEnabled      bool          `json:"tls_enabled"`
+	Debug           bool          `json:"debug"`
 	ReconnectDelay  time.Duration `json:"reconnect_delay"`
 	HeartbeatRate   time.Duration `json:"heartbeat_rate"`
 	ComposeBasePath string        `json:"compose_base_path"`
+	LogLevel        string        `json:"log_level"`
+	LogFormat       string        `json:"log_format"`
+
+	// TaskPollInterval is how often the agent polls the server for tasks
+	TaskPollInterval time.Duration `json:"task_poll_interval"`
+
+	// StackStatusInterval controls background refresh of cached stack
+	// statuses. Zero disables the cache.
+	StackStatusInterval time.Duration `json:"stack_status_interval"`
+
+	// MetricsInterval is how often heartbeat metrics are collected; heartbeats
+	// in between reuse the last result. Zero collects on every heartbeat.
+	MetricsInterval time.Duration `json:"metrics_interval"`
+
+	// AutoUpdateEnabled is the master switch for pulling and redeploying
+	// stacks that have auto-update turned on
+	AutoUpdateEnabled  bool          `json:"auto_update_enabled"`
+	AutoUpdateInterval time.Duration `json:"auto_update_interval"`
+
+	// RunJobMaxTimeout caps the timeout a run_job task may ask for
+	RunJobMaxTimeout time.Duration `json:"run_job_max_timeout"`
+
+	// TaskHistorySize is how many executed tasks are kept for task_list and
+	// task_get; zero disables the history
+	TaskHistorySize int `json:"task_history_size"`
+
+	// MaintenanceMode makes the agent refuse tasks that change the host while
+	// read-only tasks keep working
+	MaintenanceMode bool `json:"maintenance_mode"`
+
+	// AllowedBindPaths restricts which host paths may be bind-mounted into
+	// containers. An empty list leaves bind mounts unrestricted.
+	AllowedBindPaths []string `json:"allowed_bind_paths,omitempty"`
+
+	// DockerBin is the docker executable and DockerHost, when set, is exported
+	// as DOCKER_HOST for rootless or multi-daemon setups
+	DockerBin  string `json:"docker_bin"`
+	DockerHost string `json:"docker_host,omitempty"`
+
+	// DockerContext pins every docker and compose command to a named docker
+	// context; it cannot be combined with DockerHost
+	DockerContext string `json:"docker_context,omitempty"`
+
+	// StackEnvAccess controls how stack .env files are returned to the
+	// server: "full", "masked" (values hidden) or "none"
+	StackEnvAccess string `json:"stack_env_access"`
+
+	// SecretEnvPatterns are case-insensitive glob patterns; environment keys
+	// matching one have their values redacted in compose_effective_env
+	SecretEnvPatterns []string `json:"secret_env_patterns"`
+
+	// ImageScanner picks the vulnerability scanner: "auto" (trivy, then
+	// docker scout), "trivy", "scout" or "none"
+	ImageScanner string `json:"image_scanner"`
 }
 
+// DefaultSecretEnvPatterns is used when SECRET_ENV_PATTERNS is unset
+var DefaultSecretEnvPatterns = []string{"*PASSWORD*", "*PASSWD*", "*TOKEN*", "*SECRET*", "*KEY*", "*CREDENTIAL*"}
+
+// Stack env access modes
+const (
+	StackEnvFull   = "full"
+	StackEnvMasked = "masked"
+	StackEnvNone   = "none"
+)
+
 func Load() (*Config, error) {
+	env := &envReader{}
 	cfg := &Config{
 		ArcaneHost:      getEnv("ARCANE_HOST", "localhost"),
-		ArcanePort:      getEnvInt("ARCANE_PORT", 3000),
-		TLSEnabled:      getEnvBool("TLS_ENABLED", false),
-		ReconnectDelay:  getEnvDuration("RECONNECT_DELAY", 5*time.Second),
-		HeartbeatRate:   getEnvDuration("HEARTBEAT_RATE", 30*time.Second),
+		ArcanePort:      env.int("ARCANE_PORT", 3000),
+		Token:           getEnv("AGENT_TOKEN", ""),
+		TLSEnabled:      env.bool("TLS_ENABLED", false),
+		Debug:           env.bool("DEBUG", false),
+		ReconnectDelay:  env.duration("RECONNECT_DELAY", 5*time.Second),
+		HeartbeatRate:   env.duration("HEARTBEAT_RATE", 30*time.Second),
 		ComposeBasePath: getEnv("COMPOSE_BASE_PATH", "data/agent/compose-projects"),
+		LogLevel:        getEnv("LOG_LEVEL", "info"),
+		LogFormat:       getEnv("LOG_FORMAT", "text"),
+
+		TaskPollInterval: env.duration("TASK_POLL_INTERVAL", 5*time.Second),
+
+		StackStatusInterval: env.duration("STACK_STATUS_INTERVAL", 30*time.Second),
+
+		MetricsInterval: env.duration("METRICS_INTERVAL", 60*time.Second),
+
+		AutoUpdateEnabled:  env.bool("AUTO_UPDATE_ENABLED", false),
+		AutoUpdateInterval: env.duration("AUTO_UPDATE_INTERVAL", time.Hour),
+
+		RunJobMaxTimeout: env.duration("RUN_JOB_MAX_TIMEOUT", 10*time.Minute),
+
+		TaskHistorySize: env.int("TASK_HISTORY_SIZE", 100),
+
+		MaintenanceMode: env.bool("MAINTENANCE_MODE", false),
+
+		AllowedBindPaths: getEnvList("ALLOWED_BIND_PATHS"),
+
+		DockerBin:  getEnv("DOCKER_BIN", "docker"),
+		DockerHost: getEnv("DOCKER_HOST", ""),
+
+		DockerContext: getEnv("DOCKER_CONTEXT", ""),
+
+		StackEnvAccess: getEnv("STACK_ENV_ACCESS", StackEnvFull),
+
+		SecretEnvPatterns: getEnvList("SECRET_ENV_PATTERNS"),
+
+		ImageScanner: getEnv("IMAGE_SCANNER", "auto"),
+	}
+
+	// Unparseable values are reported rather than replaced by defaults the
+	// operator never chose
+	if err := env.err(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if len(cfg.SecretEnvPatterns) == 0 {
+		cfg.SecretEnvPatterns = DefaultSecretEnvPatterns
 	}
 
 	// Get or generate agent ID
@@ -35,9 +149,86 @@ func Load() (*Config, error) {
 	}
 	cfg.AgentID = agentID
 
+	// DEBUG is shorthand for LOG_LEVEL=debug
+	if cfg.Debug {
+		cfg.LogLevel = "debug"
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	return cfg, nil
 }
 
+// LoadMaintenanceMode reads MAINTENANCE_MODE, so a reload can pick up a
+// changed value without reloading the rest of the configuration
+func LoadMaintenanceMode() (bool, error) {
+	return getEnvBool("MAINTENANCE_MODE", false)
+}
+
+// Validate checks that the loaded values are usable
+func (c *Config) Validate() error {
+	if strings.TrimSpace(c.ArcaneHost) == "" {
+		return fmt.Errorf("ARCANE_HOST must not be empty")
+	}
+	if c.ArcanePort < 1 || c.ArcanePort > 65535 {
+		return fmt.Errorf("ARCANE_PORT must be between 1 and 65535, got %d", c.ArcanePort)
+	}
+	if c.ReconnectDelay <= 0 {
+		return fmt.Errorf("RECONNECT_DELAY must be positive, got %v", c.ReconnectDelay)
+	}
+	if c.HeartbeatRate <= 0 {
+		return fmt.Errorf("HEARTBEAT_RATE must be positive, got %v", c.HeartbeatRate)
+	}
+	if c.TaskPollInterval <= 0 {
+		return fmt.Errorf("TASK_POLL_INTERVAL must be positive, got %v", c.TaskPollInterval)
+	}
+	if c.RunJobMaxTimeout < 0 {
+		return fmt.Errorf("RUN_JOB_MAX_TIMEOUT must not be negative, got %v", c.RunJobMaxTimeout)
+	}
+	if c.DockerHost != "" && c.DockerContext != "" {
+		return fmt.Errorf("DOCKER_HOST and DOCKER_CONTEXT cannot both be set")
+	}
+	for _, pattern := range c.SecretEnvPatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("SECRET_ENV_PATTERNS contains an invalid pattern %q", pattern)
+		}
+	}
+	if c.TaskHistorySize < 0 {
+		return fmt.Errorf("TASK_HISTORY_SIZE must not be negative, got %d", c.TaskHistorySize)
+	}
+	if c.MetricsInterval < 0 {
+		return fmt.Errorf("METRICS_INTERVAL must not be negative, got %v", c.MetricsInterval)
+	}
+
+	switch strings.ToLower(c.LogLevel) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		return fmt.Errorf("LOG_LEVEL must be one of debug, info, warn, error, got %q", c.LogLevel)
+	}
+
+	switch strings.ToLower(c.LogFormat) {
+	case "text", "json":
+	default:
+		return fmt.Errorf("LOG_FORMAT must be text or json, got %q", c.LogFormat)
+	}
+
+	switch c.StackEnvAccess {
+	case "", StackEnvFull, StackEnvMasked, StackEnvNone:
+	default:
+		return fmt.Errorf("STACK_ENV_ACCESS must be one of full, masked, none, got %q", c.StackEnvAccess)
+	}
+
+	switch c.ImageScanner {
+	case "", "auto", "trivy", "scout", "none":
+	default:
+		return fmt.Errorf("IMAGE_SCANNER must be one of auto, trivy, scout, none, got %q", c.ImageScanner)
+	}
+
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -45,31 +236,90 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
 	}
-	return defaultValue
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("%s must be an integer, got %q", key, value)
+	}
+	return intValue, nil
 }
 
-func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if duration, err := time.ParseDuration(value); err == nil {
-			return duration
-		}
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
 	}
-	return defaultValue
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("%s must be a duration with a unit such as 30s or 5m, got %q", key, value)
+	}
+	return duration, nil
 }
 
-func getEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if boolValue, err := strconv.ParseBool(value); err == nil {
-			return boolValue
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("%s must be true or false, got %q", key, value)
+	}
+	return boolValue, nil
+}
+
+// envReader reads typed env vars for Load, collecting the ones that do not
+// parse so they can be reported together
+type envReader struct {
+	errs []error
+}
+
+func (r *envReader) int(key string, defaultValue int) int {
+	value, err := getEnvInt(key, defaultValue)
+	r.add(err)
+	return value
+}
+
+func (r *envReader) duration(key string, defaultValue time.Duration) time.Duration {
+	value, err := getEnvDuration(key, defaultValue)
+	r.add(err)
+	return value
+}
+
+func (r *envReader) bool(key string, defaultValue bool) bool {
+	value, err := getEnvBool(key, defaultValue)
+	r.add(err)
+	return value
+}
+
+func (r *envReader) add(err error) {
+	if err != nil {
+		r.errs = append(r.errs, err)
+	}
+}
+
+func (r *envReader) err() error {
+	return errors.Join(r.errs...)
+}
+
+// getEnvList splits a comma-separated env var, dropping empty entries
+func getEnvList(key string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
-	return defaultValue
+	return items
 }
 
 func getOrCreateAgentID() (string, error) {
@@ -81,27 +331,46 @@ func getOrCreateAgentID() (string, error) {
 	// Try to load from file
 	agentIDFile := getAgentIDFile()
 	if data, err := os.ReadFile(agentIDFile); err == nil {
-		agentID := string(data)
+		agentID := strings.TrimSpace(string(data))
 		if agentID != "" {
 			return agentID, nil
 		}
 	}
 
 	// Generate new agent ID and save it
-	agentID := generateAgentID()
-	if err := saveAgentID(agentID); err != nil {
+	agentID, err := generateAgentID()
+	if err != nil {
 		return "", err
 	}
+
+	// An unwritable data dir should not stop the agent from starting, but the
+	// ID will change on the next restart
+	if err := saveAgentID(agentID); err != nil {
+		slog.Warn("Failed to persist agent ID, using in-memory ID", "path", agentIDFile, "error", err)
+	}
 	return agentID, nil
 }
 
-func generateAgentID() string {
-	hostname, _ := os.Hostname()
-	return fmt.Sprintf("agent-%s-%d", hostname, time.Now().Unix())
+// generateAgentID returns a random "agent-<uuid>" identifier
+func generateAgentID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate agent ID: %w", err)
+	}
+
+	// Set the version 4 and RFC 4122 variant bits
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("agent-%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
 }
 
 func getAgentIDFile() string {
-	// Store in user's home directory or current directory
+	// AGENT_DATA_DIR wins, then the user's home directory, then the working directory
+	if dataDir := os.Getenv("AGENT_DATA_DIR"); dataDir != "" {
+		return filepath.Join(dataDir, "agent_id")
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return ".agent_id"
@@ -3,6 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -16,7 +19,25 @@ func TestLoad(t *testing.T) {
 		"RECONNECT_DELAY":   os.Getenv("RECONNECT_DELAY"),
 		"HEARTBEAT_RATE":    os.Getenv("HEARTBEAT_RATE"),
 		"TLS_ENABLED":       os.Getenv("TLS_ENABLED"),
+		"AGENT_TOKEN":       os.Getenv("AGENT_TOKEN"),
+		"DEBUG":             os.Getenv("DEBUG"),
 		"COMPOSE_BASE_PATH": os.Getenv("COMPOSE_BASE_PATH"),
+		"LOG_LEVEL":         os.Getenv("LOG_LEVEL"),
+		"LOG_FORMAT":        os.Getenv("LOG_FORMAT"),
+
+		"TASK_POLL_INTERVAL":    os.Getenv("TASK_POLL_INTERVAL"),
+		"STACK_STATUS_INTERVAL": os.Getenv("STACK_STATUS_INTERVAL"),
+		"METRICS_INTERVAL":      os.Getenv("METRICS_INTERVAL"),
+		"RUN_JOB_MAX_TIMEOUT":   os.Getenv("RUN_JOB_MAX_TIMEOUT"),
+		"TASK_HISTORY_SIZE":     os.Getenv("TASK_HISTORY_SIZE"),
+		"MAINTENANCE_MODE":      os.Getenv("MAINTENANCE_MODE"),
+		"AUTO_UPDATE_ENABLED":   os.Getenv("AUTO_UPDATE_ENABLED"),
+		"AUTO_UPDATE_INTERVAL":  os.Getenv("AUTO_UPDATE_INTERVAL"),
+		"DOCKER_BIN":            os.Getenv("DOCKER_BIN"),
+		"DOCKER_HOST":           os.Getenv("DOCKER_HOST"),
+		"DOCKER_CONTEXT":        os.Getenv("DOCKER_CONTEXT"),
+		"STACK_ENV_ACCESS":      os.Getenv("STACK_ENV_ACCESS"),
+		"SECRET_ENV_PATTERNS":   os.Getenv("SECRET_ENV_PATTERNS"),
 	}
 
 	// Clean env vars
@@ -68,6 +89,58 @@ func TestLoad(t *testing.T) {
 		if cfg.AgentID == "" {
 			t.Error("Expected AgentID to be generated, got empty string")
 		}
+
+		if cfg.LogLevel != "info" {
+			t.Errorf("Expected LogLevel 'info', got '%s'", cfg.LogLevel)
+		}
+
+		if cfg.LogFormat != "text" {
+			t.Errorf("Expected LogFormat 'text', got '%s'", cfg.LogFormat)
+		}
+
+		if cfg.TaskPollInterval != 5*time.Second {
+			t.Errorf("Expected TaskPollInterval 5s, got %v", cfg.TaskPollInterval)
+		}
+
+		if cfg.StackStatusInterval != 30*time.Second {
+			t.Errorf("Expected StackStatusInterval 30s, got %v", cfg.StackStatusInterval)
+		}
+
+		if cfg.MetricsInterval != 60*time.Second {
+			t.Errorf("Expected MetricsInterval 60s, got %v", cfg.MetricsInterval)
+		}
+
+		if cfg.AutoUpdateEnabled {
+			t.Error("Expected AutoUpdateEnabled to default to false")
+		}
+
+		if cfg.AutoUpdateInterval != time.Hour {
+			t.Errorf("Expected AutoUpdateInterval 1h, got %v", cfg.AutoUpdateInterval)
+		}
+
+		if cfg.RunJobMaxTimeout != 10*time.Minute {
+			t.Errorf("Expected RunJobMaxTimeout 10m, got %v", cfg.RunJobMaxTimeout)
+		}
+
+		if cfg.TaskHistorySize != 100 {
+			t.Errorf("Expected TaskHistorySize 100, got %d", cfg.TaskHistorySize)
+		}
+
+		if cfg.MaintenanceMode {
+			t.Error("Expected MaintenanceMode to default to false")
+		}
+
+		if !slices.Equal(cfg.SecretEnvPatterns, DefaultSecretEnvPatterns) {
+			t.Errorf("Expected default SecretEnvPatterns, got %v", cfg.SecretEnvPatterns)
+		}
+
+		if cfg.DockerBin != "docker" || cfg.DockerHost != "" {
+			t.Errorf("Expected DockerBin 'docker' and no DockerHost, got '%s' '%s'", cfg.DockerBin, cfg.DockerHost)
+		}
+
+		if cfg.StackEnvAccess != StackEnvFull {
+			t.Errorf("Expected StackEnvAccess 'full', got '%s'", cfg.StackEnvAccess)
+		}
 	})
 
 	t.Run("custom values from env", func(t *testing.T) {
@@ -78,6 +151,11 @@ func TestLoad(t *testing.T) {
 		os.Setenv("HEARTBEAT_RATE", "60s")
 		os.Setenv("TLS_ENABLED", "true")
 		os.Setenv("COMPOSE_BASE_PATH", "/custom/compose/path")
+		os.Setenv("TASK_POLL_INTERVAL", "2s")
+		os.Setenv("AGENT_TOKEN", "secret-token")
+		os.Setenv("DEBUG", "true")
+		os.Setenv("DOCKER_BIN", "/usr/local/bin/docker")
+		os.Setenv("DOCKER_HOST", "unix:///run/user/1000/docker.sock")
 
 		cfg, err := Load()
 		if err != nil {
@@ -112,6 +190,26 @@ func TestLoad(t *testing.T) {
 			t.Errorf("Expected ComposeBasePath '/custom/compose/path', got '%s'", cfg.ComposeBasePath)
 		}
 
+		if cfg.TaskPollInterval != 2*time.Second {
+			t.Errorf("Expected TaskPollInterval 2s, got %v", cfg.TaskPollInterval)
+		}
+
+		if cfg.Token != "secret-token" {
+			t.Errorf("Expected Token 'secret-token', got '%s'", cfg.Token)
+		}
+
+		if !cfg.Debug || cfg.LogLevel != "debug" {
+			t.Errorf("Expected DEBUG to force LogLevel 'debug', got Debug=%v LogLevel='%s'", cfg.Debug, cfg.LogLevel)
+		}
+
+		if cfg.DockerBin != "/usr/local/bin/docker" {
+			t.Errorf("Expected DockerBin '/usr/local/bin/docker', got '%s'", cfg.DockerBin)
+		}
+
+		if cfg.DockerHost != "unix:///run/user/1000/docker.sock" {
+			t.Errorf("Expected DockerHost 'unix:///run/user/1000/docker.sock', got '%s'", cfg.DockerHost)
+		}
+
 		// Clean up env vars for this test
 		os.Unsetenv("ARCANE_HOST")
 		os.Unsetenv("ARCANE_PORT")
@@ -120,9 +218,93 @@ func TestLoad(t *testing.T) {
 		os.Unsetenv("HEARTBEAT_RATE")
 		os.Unsetenv("TLS_ENABLED")
 		os.Unsetenv("COMPOSE_BASE_PATH")
+		os.Unsetenv("TASK_POLL_INTERVAL")
+		os.Unsetenv("AGENT_TOKEN")
+		os.Unsetenv("DEBUG")
+		os.Unsetenv("DOCKER_BIN")
+		os.Unsetenv("DOCKER_HOST")
+	})
+
+	t.Run("invalid values are rejected", func(t *testing.T) {
+		os.Setenv("ARCANE_PORT", "70000")
+		defer os.Unsetenv("ARCANE_PORT")
+
+		if _, err := Load(); err == nil {
+			t.Error("Expected Load() to fail for out-of-range ARCANE_PORT")
+		}
+	})
+
+	t.Run("unparseable values are rejected", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"ARCANE_PORT":      "abc",
+			"HEARTBEAT_RATE":   "30",
+			"TLS_ENABLED":      "yes",
+			"MAINTENANCE_MODE": "on",
+		} {
+			os.Setenv(key, value)
+			_, err := Load()
+			os.Unsetenv(key)
+			if err == nil || !strings.Contains(err.Error(), key) {
+				t.Errorf("Expected Load() to fail naming %s=%q, got %v", key, value, err)
+			}
+		}
 	})
 }
 
+func TestValidate(t *testing.T) {
+	valid := func() *Config {
+		return &Config{
+			ArcaneHost:       "localhost",
+			ArcanePort:       3000,
+			ReconnectDelay:   5 * time.Second,
+			HeartbeatRate:    30 * time.Second,
+			TaskPollInterval: 5 * time.Second,
+			LogLevel:         "info",
+			LogFormat:        "text",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{"valid", func(c *Config) {}, false},
+		{"empty host", func(c *Config) { c.ArcaneHost = " " }, true},
+		{"port zero", func(c *Config) { c.ArcanePort = 0 }, true},
+		{"port too large", func(c *Config) { c.ArcanePort = 65536 }, true},
+		{"zero reconnect delay", func(c *Config) { c.ReconnectDelay = 0 }, true},
+		{"negative heartbeat rate", func(c *Config) { c.HeartbeatRate = -time.Second }, true},
+		{"zero poll interval", func(c *Config) { c.TaskPollInterval = 0 }, true},
+		{"zero metrics interval", func(c *Config) { c.MetricsInterval = 0 }, false},
+		{"negative metrics interval", func(c *Config) { c.MetricsInterval = -time.Second }, true},
+		{"negative run job timeout", func(c *Config) { c.RunJobMaxTimeout = -time.Second }, true},
+		{"negative task history size", func(c *Config) { c.TaskHistorySize = -1 }, true},
+		{"docker context", func(c *Config) { c.DockerContext = "prod" }, false},
+		{"secret env patterns", func(c *Config) { c.SecretEnvPatterns = []string{"*_PASS", "DB_?SER"} }, false},
+		{"invalid secret env pattern", func(c *Config) { c.SecretEnvPatterns = []string{"[PASS"} }, true},
+		{"docker host and context", func(c *Config) { c.DockerHost = "tcp://10.0.0.5:2375"; c.DockerContext = "prod" }, true},
+		{"unknown log level", func(c *Config) { c.LogLevel = "verbose" }, true},
+		{"uppercase log level", func(c *Config) { c.LogLevel = "DEBUG" }, false},
+		{"json log format", func(c *Config) { c.LogFormat = "json" }, false},
+		{"unknown log format", func(c *Config) { c.LogFormat = "xml" }, true},
+		{"masked stack env", func(c *Config) { c.StackEnvAccess = StackEnvMasked }, false},
+		{"unknown stack env access", func(c *Config) { c.StackEnvAccess = "hidden" }, true},
+		{"trivy image scanner", func(c *Config) { c.ImageScanner = "trivy" }, false},
+		{"unknown image scanner", func(c *Config) { c.ImageScanner = "grype" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestLoadWithComposeConfig(t *testing.T) {
 	// Save original env vars
 	originalComposeBasePath := os.Getenv("COMPOSE_BASE_PATH")
@@ -193,6 +375,7 @@ func TestGetEnvInt(t *testing.T) {
 		defaultValue int
 		envValue     string
 		expected     int
+		wantErr      bool
 	}{
 		{
 			name:         "returns env value when valid int",
@@ -209,11 +392,12 @@ func TestGetEnvInt(t *testing.T) {
 			expected:     42,
 		},
 		{
-			name:         "returns default when env invalid",
+			name:         "returns error when env invalid",
 			key:          "INVALID_INT",
 			defaultValue: 42,
 			envValue:     "not_a_number",
 			expected:     42,
+			wantErr:      true,
 		},
 	}
 
@@ -224,7 +408,10 @@ func TestGetEnvInt(t *testing.T) {
 				defer os.Unsetenv(tt.key)
 			}
 
-			result := getEnvInt(tt.key, tt.defaultValue)
+			result, err := getEnvInt(tt.key, tt.defaultValue)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
 			if result != tt.expected {
 				t.Errorf("Expected %d, got %d", tt.expected, result)
 			}
@@ -239,7 +426,16 @@ func TestGetEnvDuration(t *testing.T) {
 		defaultValue time.Duration
 		envValue     string
 		expected     time.Duration
+		wantErr      bool
 	}{
+		{
+			name:         "returns error when unit missing",
+			key:          "UNITLESS_DURATION",
+			defaultValue: 5 * time.Second,
+			envValue:     "30",
+			expected:     5 * time.Second,
+			wantErr:      true,
+		},
 		{
 			name:         "returns env value when valid duration",
 			key:          "TEST_DURATION",
@@ -255,11 +451,12 @@ func TestGetEnvDuration(t *testing.T) {
 			expected:     5 * time.Second,
 		},
 		{
-			name:         "returns default when env invalid",
+			name:         "returns error when env invalid",
 			key:          "INVALID_DURATION",
 			defaultValue: 5 * time.Second,
 			envValue:     "not_a_duration",
 			expected:     5 * time.Second,
+			wantErr:      true,
 		},
 	}
 
@@ -270,7 +467,10 @@ func TestGetEnvDuration(t *testing.T) {
 				defer os.Unsetenv(tt.key)
 			}
 
-			result := getEnvDuration(tt.key, tt.defaultValue)
+			result, err := getEnvDuration(tt.key, tt.defaultValue)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
 			if result != tt.expected {
 				t.Errorf("Expected %v, got %v", tt.expected, result)
 			}
@@ -285,6 +485,7 @@ func TestGetEnvBool(t *testing.T) {
 		defaultValue bool
 		envValue     string
 		expected     bool
+		wantErr      bool
 	}{
 		{
 			name:         "returns true when env is 'true'",
@@ -308,11 +509,12 @@ func TestGetEnvBool(t *testing.T) {
 			expected:     true,
 		},
 		{
-			name:         "returns default when env invalid",
+			name:         "returns error when env invalid",
 			key:          "INVALID_BOOL",
 			defaultValue: false,
 			envValue:     "not_a_bool",
 			expected:     false,
+			wantErr:      true,
 		},
 	}
 
@@ -323,7 +525,10 @@ func TestGetEnvBool(t *testing.T) {
 				defer os.Unsetenv(tt.key)
 			}
 
-			result := getEnvBool(tt.key, tt.defaultValue)
+			result, err := getEnvBool(tt.key, tt.defaultValue)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
 			if result != tt.expected {
 				t.Errorf("Expected %v, got %v", tt.expected, result)
 			}
@@ -332,7 +537,10 @@ func TestGetEnvBool(t *testing.T) {
 }
 
 func TestGenerateAgentID(t *testing.T) {
-	agentID := generateAgentID()
+	agentID, err := generateAgentID()
+	if err != nil {
+		t.Fatalf("generateAgentID() failed: %v", err)
+	}
 
 	if agentID == "" {
 		t.Error("Expected non-empty agent ID")
@@ -346,6 +554,16 @@ func TestGenerateAgentID(t *testing.T) {
 	if agentID[:6] != "agent-" {
 		t.Errorf("Expected agent ID to start with 'agent-', got %s", agentID)
 	}
+
+	uuidPattern := regexp.MustCompile(`^agent-[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuidPattern.MatchString(agentID) {
+		t.Errorf("Expected agent-<uuid v4>, got %s", agentID)
+	}
+
+	other, _ := generateAgentID()
+	if other == agentID {
+		t.Error("Expected generated agent IDs to differ")
+	}
 }
 
 func TestGetOrCreateAgentID(t *testing.T) {
@@ -370,13 +588,10 @@ func TestGetOrCreateAgentID(t *testing.T) {
 		}
 	})
 
-	t.Run("generates new agent ID when env not set", func(t *testing.T) {
+	t.Run("generates and persists agent ID when env not set", func(t *testing.T) {
 		os.Unsetenv("AGENT_ID")
-
-		// Clean up any existing agent ID file
-		agentIDFile := getAgentIDFile()
-		os.Remove(agentIDFile)
-		os.RemoveAll(filepath.Dir(agentIDFile))
+		dataDir := t.TempDir()
+		t.Setenv("AGENT_DATA_DIR", dataDir)
 
 		agentID, err := getOrCreateAgentID()
 		if err != nil {
@@ -389,5 +604,98 @@ func TestGetOrCreateAgentID(t *testing.T) {
 		if agentID[:6] != "agent-" {
 			t.Errorf("Expected agent ID to start with 'agent-', got %s", agentID)
 		}
+
+		data, err := os.ReadFile(filepath.Join(dataDir, "agent_id"))
+		if err != nil {
+			t.Fatalf("Expected agent ID file to be written: %v", err)
+		}
+		if string(data) != agentID {
+			t.Errorf("Expected persisted ID '%s', got '%s'", agentID, string(data))
+		}
+	})
+
+	t.Run("reuses persisted agent ID", func(t *testing.T) {
+		os.Unsetenv("AGENT_ID")
+		dataDir := t.TempDir()
+		t.Setenv("AGENT_DATA_DIR", dataDir)
+
+		if err := os.WriteFile(filepath.Join(dataDir, "agent_id"), []byte("agent-existing\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		agentID, err := getOrCreateAgentID()
+		if err != nil {
+			t.Fatalf("getOrCreateAgentID() failed: %v", err)
+		}
+		if agentID != "agent-existing" {
+			t.Errorf("Expected 'agent-existing', got '%s'", agentID)
+		}
+	})
+
+	t.Run("env AGENT_ID takes precedence over persisted ID", func(t *testing.T) {
+		dataDir := t.TempDir()
+		t.Setenv("AGENT_DATA_DIR", dataDir)
+		os.WriteFile(filepath.Join(dataDir, "agent_id"), []byte("agent-existing"), 0644)
+
+		os.Setenv("AGENT_ID", "test-env-agent")
+		defer os.Unsetenv("AGENT_ID")
+
+		agentID, err := getOrCreateAgentID()
+		if err != nil {
+			t.Fatalf("getOrCreateAgentID() failed: %v", err)
+		}
+		if agentID != "test-env-agent" {
+			t.Errorf("Expected 'test-env-agent', got '%s'", agentID)
+		}
+	})
+
+	t.Run("falls back to in-memory ID when data dir is not writable", func(t *testing.T) {
+		os.Unsetenv("AGENT_ID")
+
+		// A regular file where the data dir should be makes MkdirAll fail
+		blocker := filepath.Join(t.TempDir(), "not-a-dir")
+		if err := os.WriteFile(blocker, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("AGENT_DATA_DIR", filepath.Join(blocker, "data"))
+
+		agentID, err := getOrCreateAgentID()
+		if err != nil {
+			t.Fatalf("getOrCreateAgentID() failed: %v", err)
+		}
+		if agentID[:6] != "agent-" {
+			t.Errorf("Expected agent ID to start with 'agent-', got %s", agentID)
+		}
 	})
 }
+
+func TestGetEnvList(t *testing.T) {
+	os.Setenv("TEST_LIST", " /srv/data, ,/opt/apps ")
+	defer os.Unsetenv("TEST_LIST")
+
+	result := getEnvList("TEST_LIST")
+	if len(result) != 2 || result[0] != "/srv/data" || result[1] != "/opt/apps" {
+		t.Errorf("Expected [/srv/data /opt/apps], got %v", result)
+	}
+
+	if result := getEnvList("NONEXISTENT_LIST"); result != nil {
+		t.Errorf("Expected nil for unset env, got %v", result)
+	}
+}
+
+func TestLoadMaintenanceMode(t *testing.T) {
+	t.Setenv("MAINTENANCE_MODE", "true")
+	if enabled, err := LoadMaintenanceMode(); err != nil || !enabled {
+		t.Errorf("Expected maintenance mode with MAINTENANCE_MODE=true, got %v, %v", enabled, err)
+	}
+
+	t.Setenv("MAINTENANCE_MODE", "false")
+	if enabled, err := LoadMaintenanceMode(); err != nil || enabled {
+		t.Errorf("Expected no maintenance mode with MAINTENANCE_MODE=false, got %v, %v", enabled, err)
+	}
+
+	t.Setenv("MAINTENANCE_MODE", "on")
+	if _, err := LoadMaintenanceMode(); err == nil {
+		t.Error("Expected an error for MAINTENANCE_MODE=on")
+	}
+}
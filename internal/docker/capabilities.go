@@ -0,0 +1,33 @@
+package docker
+
+import (
+	"context"
+	"strings"
+)
+
+// HasComposeV2 reports whether the compose CLI is version 2 or later
+func (c *Client) HasComposeV2(ctx context.Context) bool {
+	version, err := c.ComposeVersion(ctx)
+	if err != nil {
+		return false
+	}
+	major, _, _ := strings.Cut(version, ".")
+	return major != "" && major != "0" && major != "1"
+}
+
+// SwarmActive reports whether the daemon is part of an active swarm
+func (c *Client) SwarmActive(ctx context.Context) bool {
+	output, err := c.commandContext(ctx, "info", "--format", "{{.Swarm.LocalNodeState}}").Output()
+	return err == nil && strings.TrimSpace(string(output)) == "active"
+}
+
+// HasBuildx reports whether the buildx plugin is installed
+func (c *Client) HasBuildx(ctx context.Context) bool {
+	return c.commandContext(ctx, "buildx", "version").Run() == nil
+}
+
+// HasScanner reports whether ScanImage has a scanner to run
+func (c *Client) HasScanner(ctx context.Context) bool {
+	_, err := c.detectScanner(ctx)
+	return err == nil
+}
@@ -1,43 +1,176 @@
 package docker
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
 )
 
+// Client is a thin wrapper around the docker and docker-compose CLIs
 type Client struct {
-	// Simple Docker CLI client
+	binary  string
+	compose string
+	host    string
+	context string
+	scanner string
+	trivy   string
+}
+
+// ClientOptions configures how the CLI is invoked
+type ClientOptions struct {
+	// Binary is the docker executable, "docker" from PATH when empty
+	Binary string
+	// Host is exported as DOCKER_HOST to every command when set
+	Host string
+	// Context selects a named docker context: passed as --context to docker
+	// and exported as DOCKER_CONTEXT to docker-compose
+	Context string
+	// Scanner selects the image scanner: "auto", "trivy", "scout" or "none"
+	Scanner string
 }
 
 func NewClient() *Client {
-	return &Client{}
+	return NewClientWithOptions(ClientOptions{})
+}
+
+// NewClientWithOptions creates a client using a specific docker binary and daemon
+func NewClientWithOptions(opts ClientOptions) *Client {
+	binary := opts.Binary
+	if binary == "" {
+		binary = "docker"
+	}
+	return &Client{binary: binary, compose: "docker-compose", host: opts.Host, context: opts.Context, scanner: opts.Scanner, trivy: "trivy"}
+}
+
+// command builds a docker CLI invocation
+func (c *Client) command(args ...string) *exec.Cmd {
+	return c.withEnv(exec.Command(c.binary, c.globalArgs(args)...))
+}
+
+// commandContext builds a docker CLI invocation bound to ctx
+func (c *Client) commandContext(ctx context.Context, args ...string) *exec.Cmd {
+	return c.withEnv(exec.CommandContext(ctx, c.binary, c.globalArgs(args)...))
+}
+
+// globalArgs prepends the docker flags that apply to every command
+func (c *Client) globalArgs(args []string) []string {
+	if c.context == "" {
+		return args
+	}
+	return append([]string{"--context", c.context}, args...)
+}
+
+// composeCommand builds a docker-compose invocation against the same daemon,
+// killed when ctx is cancelled
+func (c *Client) composeCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return c.withEnv(exec.CommandContext(ctx, c.compose, args...))
+}
+
+func (c *Client) withEnv(cmd *exec.Cmd) *exec.Cmd {
+	if c.host != "" || c.context != "" {
+		cmd.Env = c.environ()
+	}
+	return cmd
+}
+
+// environ is the process environment with the configured DOCKER_HOST and
+// DOCKER_CONTEXT applied
+func (c *Client) environ() []string {
+	env := os.Environ()
+	if c.host != "" {
+		env = append(env, "DOCKER_HOST="+c.host)
+	}
+	if c.context != "" {
+		env = append(env, "DOCKER_CONTEXT="+c.context)
+	}
+	return env
+}
+
+// ValidateContext checks that the configured docker context exists. It only
+// reads the CLI configuration, so it works while the daemon is down.
+func (c *Client) ValidateContext(ctx context.Context) error {
+	if c.context == "" {
+		return nil
+	}
+	output, err := exec.CommandContext(ctx, c.binary, "context", "inspect", c.context).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: docker context %q: %s", errdefs.ErrInvalidInput, c.context, strings.TrimSpace(string(output)))
+	}
+	return nil
 }
 
-// ExecuteCommand runs any docker command with args
-func (c *Client) ExecuteCommand(command string, args []string) (string, error) {
+// ExecuteCommand runs any docker command with args, killing it when ctx is
+// cancelled
+func (c *Client) ExecuteCommand(ctx context.Context, command string, args []string) (string, error) {
 	cmdArgs := append([]string{command}, args...)
-	cmd := exec.Command("docker", cmdArgs...)
+	cmd := c.commandContext(ctx, cmdArgs...)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("docker %s failed: %s", command, string(output))
+		return "", classifyError(fmt.Errorf("docker %s failed: %s", command, string(output)), string(output))
 	}
 
 	return strings.TrimSpace(string(output)), nil
 }
 
+// classifyError wraps a CLI failure with the matching errdefs category so
+// callers can tell a missing object or an unreachable daemon from other errors
+func classifyError(err error, output string) error {
+	if category := errorCategory(output); category != nil {
+		return fmt.Errorf("%w: %w", category, err)
+	}
+	return err
+}
+
+// errorCategory returns the errdefs sentinel matching CLI output, or nil
+func errorCategory(output string) error {
+	lower := strings.ToLower(output)
+	switch {
+	case strings.Contains(lower, "cannot connect to the docker daemon"),
+		strings.Contains(lower, "is the docker daemon running"),
+		strings.Contains(lower, "error during connect"):
+		return errdefs.ErrDockerUnavailable
+	case strings.Contains(lower, "no such container"),
+		strings.Contains(lower, "no such image"),
+		strings.Contains(lower, "no such object"),
+		strings.Contains(lower, "no such volume"),
+		strings.Contains(lower, "no such network"):
+		return errdefs.ErrNotFound
+	case strings.Contains(lower, "is already in use"),
+		strings.Contains(lower, "conflict:"):
+		return errdefs.ErrConflict
+	default:
+		return nil
+	}
+}
+
 // IsDockerAvailable checks if Docker is available
 func (c *Client) IsDockerAvailable() bool {
-	cmd := exec.Command("docker", "version")
+	cmd := c.command("version")
 	return cmd.Run() == nil
 }
 
 // ListContainers gets all containers in JSON format
 func (c *Client) ListContainers(ctx context.Context) (interface{}, error) {
-	output, err := c.ExecuteCommand("ps", []string{"-a", "--format", "json"})
+	return c.ListContainersWithFilters(ctx, nil)
+}
+
+// ListContainersWithFilters lists containers matching `docker ps --filter` style filters
+func (c *Client) ListContainersWithFilters(ctx context.Context, filters map[string][]string) (interface{}, error) {
+	args := append([]string{"-a", "--format", "json"}, buildFilterArgs(filters)...)
+	output, err := c.ExecuteCommand(ctx, "ps", args)
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +185,8 @@ func (c *Client) ListContainers(ctx context.Context) (interface{}, error) {
 		}
 		var container map[string]interface{}
 		if err := json.Unmarshal([]byte(line), &container); err == nil {
+			status, _ := container["Status"].(string)
+			container["health"] = parseHealthStatus(status)
 			containers = append(containers, container)
 		}
 	}
@@ -61,9 +196,42 @@ func (c *Client) ListContainers(ctx context.Context) (interface{}, error) {
 	}, nil
 }
 
+// parseHealthStatus extracts the healthcheck state from a `docker ps` status
+// such as "Up 5 minutes (healthy)". Containers without a healthcheck report "none".
+func parseHealthStatus(status string) string {
+	switch {
+	case strings.Contains(status, "(health: starting)"):
+		return "starting"
+	case strings.Contains(status, "(unhealthy)"):
+		return "unhealthy"
+	case strings.Contains(status, "(healthy)"):
+		return "healthy"
+	default:
+		return "none"
+	}
+}
+
+// containerHealth maps short container IDs to their health status
+func (c *Client) containerHealth(ctx context.Context) (map[string]string, error) {
+	output, err := c.ExecuteCommand(ctx, "ps", []string{"-a", "--format", "{{.ID}}\t{{.Status}}"})
+	if err != nil {
+		return nil, err
+	}
+
+	health := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		id, status, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		health[strings.TrimSpace(id)] = parseHealthStatus(status)
+	}
+	return health, nil
+}
+
 // StartContainer starts a container by ID or name
 func (c *Client) StartContainer(ctx context.Context, containerID string) (interface{}, error) {
-	output, err := c.ExecuteCommand("start", []string{containerID})
+	output, err := c.ExecuteCommand(ctx, "start", []string{containerID})
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +245,17 @@ func (c *Client) StartContainer(ctx context.Context, containerID string) (interf
 
 // StopContainer stops a container by ID or name
 func (c *Client) StopContainer(ctx context.Context, containerID string) (interface{}, error) {
-	output, err := c.ExecuteCommand("stop", []string{containerID})
+	return c.StopContainerWithTimeout(ctx, containerID, nil)
+}
+
+// StopContainerWithTimeout stops a container, waiting timeout seconds after
+// SIGTERM before killing it. A nil timeout keeps Docker's default grace period.
+func (c *Client) StopContainerWithTimeout(ctx context.Context, containerID string, timeout *int) (interface{}, error) {
+	if timeout != nil && *timeout < 0 {
+		return nil, fmt.Errorf("stop timeout must not be negative, got %d", *timeout)
+	}
+
+	output, err := c.ExecuteCommand(ctx, "stop", stopArgs(containerID, timeout))
 	if err != nil {
 		return nil, err
 	}
@@ -89,9 +267,17 @@ func (c *Client) StopContainer(ctx context.Context, containerID string) (interfa
 	}, nil
 }
 
+// stopArgs builds the `docker stop` arguments, adding -t only when a timeout is given
+func stopArgs(containerID string, timeout *int) []string {
+	if timeout == nil {
+		return []string{containerID}
+	}
+	return []string{"-t", strconv.Itoa(*timeout), containerID}
+}
+
 // RestartContainer restarts a container by ID or name
 func (c *Client) RestartContainer(ctx context.Context, containerID string) (interface{}, error) {
-	output, err := c.ExecuteCommand("restart", []string{containerID})
+	output, err := c.ExecuteCommand(ctx, "restart", []string{containerID})
 	if err != nil {
 		return nil, err
 	}
@@ -103,9 +289,69 @@ func (c *Client) RestartContainer(ctx context.Context, containerID string) (inte
 	}, nil
 }
 
+// PauseContainer suspends all processes in a container
+func (c *Client) PauseContainer(ctx context.Context, containerID string) (interface{}, error) {
+	output, err := c.ExecuteCommand(ctx, "pause", []string{containerID})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"container_id": containerID,
+		"status":       "paused",
+		"paused":       true,
+		"output":       output,
+	}, nil
+}
+
+// UnpauseContainer resumes a paused container
+func (c *Client) UnpauseContainer(ctx context.Context, containerID string) (interface{}, error) {
+	output, err := c.ExecuteCommand(ctx, "unpause", []string{containerID})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"container_id": containerID,
+		"status":       "unpaused",
+		"paused":       false,
+		"output":       output,
+	}, nil
+}
+
+// containerNamePattern mirrors the Docker daemon's restricted name pattern
+var containerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// ValidateContainerName checks a name against Docker's allowed character set
+func ValidateContainerName(name string) error {
+	if !containerNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid container name %q: only [a-zA-Z0-9][a-zA-Z0-9_.-] are allowed", name)
+	}
+	return nil
+}
+
+// RenameContainer renames a container
+func (c *Client) RenameContainer(ctx context.Context, containerID, newName string) (interface{}, error) {
+	if err := ValidateContainerName(newName); err != nil {
+		return nil, err
+	}
+
+	output, err := c.ExecuteCommand(ctx, "rename", []string{containerID, newName})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"container_id": containerID,
+		"name":         newName,
+		"status":       "renamed",
+		"output":       output,
+	}, nil
+}
+
 // PullImage pulls a Docker image
 func (c *Client) PullImage(ctx context.Context, image string) (interface{}, error) {
-	output, err := c.ExecuteCommand("pull", []string{image})
+	output, err := c.ExecuteCommand(ctx, "pull", []string{image})
 	if err != nil {
 		return nil, err
 	}
@@ -117,9 +363,46 @@ func (c *Client) PullImage(ctx context.Context, image string) (interface{}, erro
 	}, nil
 }
 
+// RemoveImage removes an image by ID or reference. Without force, images used
+// by a container or tagged in several repositories are left alone.
+func (c *Client) RemoveImage(ctx context.Context, image string, force bool) (interface{}, error) {
+	args := []string{"rm"}
+	if force {
+		args = append(args, "--force")
+	}
+	args = append(args, image)
+
+	output, err := c.ExecuteCommand(ctx, "image", args)
+	if err != nil {
+		return nil, err
+	}
+
+	untagged, deleted := []string{}, []string{}
+	for _, line := range strings.Split(output, "\n") {
+		if ref, ok := strings.CutPrefix(strings.TrimSpace(line), "Untagged: "); ok {
+			untagged = append(untagged, ref)
+		} else if id, ok := strings.CutPrefix(strings.TrimSpace(line), "Deleted: "); ok {
+			deleted = append(deleted, id)
+		}
+	}
+
+	return map[string]interface{}{
+		"image":    image,
+		"status":   "removed",
+		"untagged": untagged,
+		"deleted":  deleted,
+	}, nil
+}
+
 // ListImages gets all images in JSON format
 func (c *Client) ListImages(ctx context.Context) (interface{}, error) {
-	output, err := c.ExecuteCommand("images", []string{"--format", "json"})
+	return c.ListImagesWithFilters(ctx, nil)
+}
+
+// ListImagesWithFilters lists images matching `docker images --filter` style filters
+func (c *Client) ListImagesWithFilters(ctx context.Context, filters map[string][]string) (interface{}, error) {
+	args := append([]string{"--format", "json"}, buildFilterArgs(filters)...)
+	output, err := c.ExecuteCommand(ctx, "images", args)
 	if err != nil {
 		return nil, err
 	}
@@ -143,9 +426,107 @@ func (c *Client) ListImages(ctx context.Context) (interface{}, error) {
 	}, nil
 }
 
+// InspectImage returns the parsed `docker image inspect` output for an image
+func (c *Client) InspectImage(ctx context.Context, image string) (map[string]interface{}, error) {
+	output, err := c.ExecuteCommand(ctx, "image", []string{"inspect", "--format", "{{json .}}", image})
+	if err != nil {
+		return nil, err
+	}
+
+	var details map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &details); err != nil {
+		return nil, fmt.Errorf("failed to parse image inspect output: %w", err)
+	}
+
+	return details, nil
+}
+
+// ImageHistory returns the layer history of an image, newest layer first
+func (c *Client) ImageHistory(ctx context.Context, image string) (interface{}, error) {
+	output, err := c.ExecuteCommand(ctx, "history", []string{"--no-trunc", "--human=false", "--format", "{{json .}}", image})
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := parseImageHistory(output)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalSize int64
+	for _, layer := range layers {
+		totalSize += layer["size"].(int64)
+	}
+
+	return map[string]interface{}{
+		"image":     image,
+		"layers":    layers,
+		"totalSize": totalSize,
+	}, nil
+}
+
+// parseImageHistory parses `docker history --format '{{json .}}'` output
+func parseImageHistory(output string) ([]map[string]interface{}, error) {
+	layers := make([]map[string]interface{}, 0)
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		var entry map[string]string
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse image history output: %w", err)
+		}
+
+		// With --human=false the size is a plain byte count
+		size, err := strconv.ParseInt(entry["Size"], 10, 64)
+		if err != nil {
+			size, _ = parseHumanSize(entry["Size"])
+		}
+
+		layers = append(layers, map[string]interface{}{
+			"id":        entry["ID"],
+			"created":   entry["CreatedAt"],
+			"createdBy": entry["CreatedBy"],
+			"size":      size,
+			"comment":   entry["Comment"],
+		})
+	}
+
+	return layers, nil
+}
+
+// RemoteImageDigest returns the registry digest an image reference currently
+// resolves to, without pulling it. The top-level manifest digest is used so it
+// matches the RepoDigests recorded for multi-platform images.
+func (c *Client) RemoteImageDigest(ctx context.Context, image string) (string, error) {
+	output, err := c.ExecuteCommand(ctx, "buildx", []string{"imagetools", "inspect", "--format", "{{json .Manifest}}", image})
+	if err != nil {
+		return "", err
+	}
+
+	return parseManifestDigest(output)
+}
+
+// parseManifestDigest extracts the digest from a manifest descriptor
+func parseManifestDigest(output string) (string, error) {
+	var descriptor struct {
+		Digest string `json:"digest"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &descriptor); err != nil {
+		return "", fmt.Errorf("failed to parse manifest output: %w", err)
+	}
+	if descriptor.Digest == "" {
+		return "", fmt.Errorf("manifest output has no digest")
+	}
+
+	return descriptor.Digest, nil
+}
+
 // GetSystemInfo gets Docker system information
 func (c *Client) GetSystemInfo(ctx context.Context) (interface{}, error) {
-	output, err := c.ExecuteCommand("system", []string{"info", "--format", "json"})
+	output, err := c.ExecuteCommand(ctx, "system", []string{"info", "--format", "json"})
 	if err != nil {
 		return nil, err
 	}
@@ -161,168 +542,1153 @@ func (c *Client) GetSystemInfo(ctx context.Context) (interface{}, error) {
 	return systemInfo, nil
 }
 
-// Additional useful methods
-
-// RemoveContainer removes a container
-func (c *Client) RemoveContainer(ctx context.Context, containerID string, force bool) (interface{}, error) {
-	args := []string{"rm", containerID}
-	if force {
-		args = []string{"rm", "-f", containerID}
-	}
-
-	output, err := c.ExecuteCommand("rm", args[1:])
+// GetDockerInfo summarizes the daemon: version, platform and object counts
+func (c *Client) GetDockerInfo(ctx context.Context) (map[string]interface{}, error) {
+	output, err := c.ExecuteCommand(ctx, "system", []string{"info", "--format", "json"})
 	if err != nil {
 		return nil, err
 	}
 
+	return parseDockerInfo(output)
+}
+
+// parseDockerInfo maps `docker info --format json` onto the summary keys
+func parseDockerInfo(output string) (map[string]interface{}, error) {
+	var info struct {
+		ServerVersion     string
+		OperatingSystem   string
+		OSType            string
+		Architecture      string
+		KernelVersion     string
+		Containers        int
+		ContainersRunning int
+		ContainersPaused  int
+		ContainersStopped int
+		Images            int
+		NCPU              int
+		MemTotal          int64
+	}
+	if err := json.Unmarshal([]byte(output), &info); err != nil {
+		return nil, fmt.Errorf("failed to parse docker info output: %w", err)
+	}
+
 	return map[string]interface{}{
-		"container_id": containerID,
-		"status":       "removed",
-		"output":       output,
+		"version":            info.ServerVersion,
+		"os":                 info.OperatingSystem,
+		"os_type":            info.OSType,
+		"architecture":       info.Architecture,
+		"kernel_version":     info.KernelVersion,
+		"containers":         info.Containers,
+		"containers_running": info.ContainersRunning,
+		"containers_paused":  info.ContainersPaused,
+		"containers_stopped": info.ContainersStopped,
+		"images":             info.Images,
+		"cpus":               info.NCPU,
+		"memory_total":       info.MemTotal,
 	}, nil
 }
 
-// GetContainerLogs gets logs from a container
-func (c *Client) GetContainerLogs(ctx context.Context, containerID string, tail int) (interface{}, error) {
-	args := []string{"logs"}
-	if tail > 0 {
-		args = append(args, "--tail", fmt.Sprintf("%d", tail))
+// ComposeVersion returns the docker-compose version, e.g. "2.29.1"
+func (c *Client) ComposeVersion(ctx context.Context) (string, error) {
+	output, err := c.composeCommand(ctx, "version", "--short").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("docker-compose version failed: %s", strings.TrimSpace(string(output)))
 	}
-	args = append(args, containerID)
+	return strings.TrimPrefix(strings.TrimSpace(string(output)), "v"), nil
+}
 
-	output, err := c.ExecuteCommand("logs", args[1:])
+// GetDiskUsage reports space used by images, containers, volumes and build cache
+func (c *Client) GetDiskUsage(ctx context.Context) (interface{}, error) {
+	output, err := c.ExecuteCommand(ctx, "system", []string{"df", "--format", "json"})
 	if err != nil {
 		return nil, err
 	}
 
-	return map[string]interface{}{
-		"container_id": containerID,
-		"logs":         output,
-	}, nil
+	return parseSystemDf(output)
 }
 
-// ComposeUp runs docker-compose up
-func (c *Client) ComposeUp(ctx context.Context, composeFile string) (interface{}, error) {
-	cmd := exec.Command("docker-compose", "-f", composeFile, "up", "-d")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("docker-compose up failed: %s", string(output))
+// parseSystemDf parses the JSON lines emitted by `docker system df --format json`
+func parseSystemDf(output string) (map[string]interface{}, error) {
+	usage := make([]map[string]interface{}, 0)
+	var totalSize, totalReclaimable int64
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		var entry map[string]string
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse system df output: %w", err)
+		}
+
+		size, _ := parseHumanSize(entry["Size"])
+
+		// Reclaimable looks like "1.2GB (50%)"
+		reclaimableStr := strings.TrimSpace(strings.SplitN(entry["Reclaimable"], " ", 2)[0])
+		reclaimable, _ := parseHumanSize(reclaimableStr)
+
+		totalCount, _ := strconv.Atoi(entry["TotalCount"])
+		active, _ := strconv.Atoi(entry["Active"])
+
+		usage = append(usage, map[string]interface{}{
+			"type":        entry["Type"],
+			"totalCount":  totalCount,
+			"active":      active,
+			"size":        size,
+			"reclaimable": reclaimable,
+		})
+
+		totalSize += size
+		totalReclaimable += reclaimable
 	}
 
 	return map[string]interface{}{
-		"compose_file": composeFile,
-		"status":       "started",
-		"output":       string(output),
+		"usage":            usage,
+		"totalSize":        totalSize,
+		"totalReclaimable": totalReclaimable,
 	}, nil
 }
 
-// ComposeDown runs docker-compose down
-func (c *Client) ComposeDown(ctx context.Context, composeFile string) (interface{}, error) {
-	cmd := exec.Command("docker-compose", "-f", composeFile, "down")
-	output, err := cmd.CombinedOutput()
+// InspectContainer returns the full `docker inspect` output for a container,
+// with its health status lifted to a top-level "health" field
+func (c *Client) InspectContainer(ctx context.Context, containerID string) (map[string]interface{}, error) {
+	output, err := c.ExecuteCommand(ctx, "container", []string{"inspect", "--format", "{{json .}}", containerID})
 	if err != nil {
-		return nil, fmt.Errorf("docker-compose down failed: %s", string(output))
+		return nil, err
 	}
 
-	return map[string]interface{}{
-		"compose_file": composeFile,
-		"status":       "stopped",
-		"output":       string(output),
-	}, nil
+	return parseContainerInspect(output)
 }
 
-// ComposeUpWithProject runs docker-compose up with a specific project name
-func (c *Client) ComposeUpWithProject(ctx context.Context, composeFile, projectName string) (interface{}, error) {
-	args := []string{"-f", composeFile}
-	if projectName != "" {
-		args = append(args, "-p", projectName)
+// parseContainerInspect decodes inspect JSON and summarizes State.Health. Containers
+// without a healthcheck report a status of "none".
+func parseContainerInspect(output string) (map[string]interface{}, error) {
+	var details map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &details); err != nil {
+		return nil, fmt.Errorf("failed to parse container inspect output: %w", err)
 	}
-	args = append(args, "up", "-d")
 
-	cmd := exec.Command("docker-compose", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("docker-compose up failed: %s", string(output))
+	health := map[string]interface{}{"status": "none"}
+	if state, ok := details["State"].(map[string]interface{}); ok {
+		if h, ok := state["Health"].(map[string]interface{}); ok {
+			if status, ok := h["Status"].(string); ok && status != "" {
+				health["status"] = status
+			}
+			health["failingStreak"] = h["FailingStreak"]
+
+			// Log holds recent probe results, oldest first
+			if log, ok := h["Log"].([]interface{}); ok && len(log) > 0 {
+				if last, ok := log[len(log)-1].(map[string]interface{}); ok {
+					health["lastExitCode"] = last["ExitCode"]
+					health["lastOutput"] = strings.TrimSpace(fmt.Sprint(last["Output"]))
+				}
+			}
+		}
 	}
+	details["health"] = health
 
-	return map[string]interface{}{
-		"compose_file": composeFile,
-		"project_name": projectName,
-		"status":       "started",
-		"output":       string(output),
-	}, nil
+	return details, nil
 }
 
-// ComposeDownWithProject runs docker-compose down with a specific project name
-func (c *Client) ComposeDownWithProject(ctx context.Context, composeFile, projectName string) (interface{}, error) {
-	args := []string{"-f", composeFile}
-	if projectName != "" {
-		args = append(args, "-p", projectName)
+// GetContainerStats returns a one-shot resource usage snapshot for containers.
+// An empty containerID reports on all running containers.
+func (c *Client) GetContainerStats(ctx context.Context, containerID string) (interface{}, error) {
+	args := []string{"--no-stream", "--format", "json"}
+	if containerID != "" {
+		args = append(args, containerID)
 	}
-	args = append(args, "down")
 
-	cmd := exec.Command("docker-compose", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := c.ExecuteCommand(ctx, "stats", args)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		summary, err := parseStatsLine(line)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, summary)
+	}
+
+	// Health is not part of docker stats output, so join it in from docker ps
+	health, err := c.containerHealth(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("docker-compose down failed: %s", string(output))
+		health = map[string]string{}
+	}
+	for _, summary := range stats {
+		summary["health"] = "none"
+		if status, ok := health[summary["id"].(string)]; ok {
+			summary["health"] = status
+		}
 	}
 
 	return map[string]interface{}{
-		"compose_file": composeFile,
-		"project_name": projectName,
-		"status":       "stopped",
-		"output":       string(output),
+		"stats": stats,
 	}, nil
 }
 
-func (c *Client) ComposePs(ctx context.Context, composeFile, projectName string) (interface{}, error) {
-	args := []string{"-f", composeFile}
-	if projectName != "" {
-		args = append(args, "-p", projectName)
+// parseStatsLine converts a `docker stats --format json` line into numeric fields
+func parseStatsLine(line string) (map[string]interface{}, error) {
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse stats output: %w", err)
 	}
-	args = append(args, "ps", "--format", "json")
 
-	cmd := exec.Command("docker-compose", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("docker-compose ps failed: %s", string(output))
-	}
+	memUsage, memLimit := parseSizePair(raw["MemUsage"])
+	netRx, netTx := parseSizePair(raw["NetIO"])
+	blockRead, blockWrite := parseSizePair(raw["BlockIO"])
+	pids, _ := strconv.Atoi(strings.TrimSpace(raw["PIDs"]))
 
 	return map[string]interface{}{
-		"compose_file": composeFile,
-		"project_name": projectName,
-		"services":     string(output),
+		"id":              raw["ID"],
+		"name":            raw["Name"],
+		"cpuPercent":      parsePercent(raw["CPUPerc"]),
+		"memoryUsage":     memUsage,
+		"memoryLimit":     memLimit,
+		"memoryPercent":   parsePercent(raw["MemPerc"]),
+		"networkRxBytes":  netRx,
+		"networkTxBytes":  netTx,
+		"blockReadBytes":  blockRead,
+		"blockWriteBytes": blockWrite,
+		"pids":            pids,
 	}, nil
 }
 
-// ComposeLogs gets logs from compose services
-func (c *Client) ComposeLogs(ctx context.Context, composeFile, projectName, serviceName string, tail int) (interface{}, error) {
-	args := []string{"-f", composeFile}
-	if projectName != "" {
-		args = append(args, "-p", projectName)
+// parseSizePair parses "<a> / <b>" size columns such as MemUsage, NetIO and BlockIO
+func parseSizePair(value string) (int64, int64) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0
 	}
-	args = append(args, "logs")
-	if tail > 0 {
+	first, _ := parseHumanSize(parts[0])
+	second, _ := parseHumanSize(parts[1])
+	return first, second
+}
+
+// parsePercent parses values like "12.34%"
+func parsePercent(value string) float64 {
+	percent, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	return percent
+}
+
+// PruneImages removes unused images. With dangling set only untagged images are
+// removed, otherwise all images without a container are.
+func (c *Client) PruneImages(ctx context.Context, dangling bool, filters map[string][]string) (interface{}, error) {
+	args := []string{"prune", "-f"}
+	if !dangling {
+		args = append(args, "-a")
+	}
+	args = append(args, buildFilterArgs(filters)...)
+
+	output, err := c.ExecuteCommand(ctx, "image", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return pruneResult(output), nil
+}
+
+// PruneContainers removes all stopped containers
+func (c *Client) PruneContainers(ctx context.Context, filters map[string][]string) (interface{}, error) {
+	args := append([]string{"prune", "-f"}, buildFilterArgs(filters)...)
+
+	output, err := c.ExecuteCommand(ctx, "container", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return pruneResult(output), nil
+}
+
+// PruneBuildCache removes unused build cache
+func (c *Client) PruneBuildCache(ctx context.Context, filters map[string][]string) (interface{}, error) {
+	args := append([]string{"prune", "-f"}, buildFilterArgs(filters)...)
+
+	output, err := c.ExecuteCommand(ctx, "builder", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return pruneResult(output), nil
+}
+
+// SystemPrune removes stopped containers, unused networks, dangling images and
+// build cache in one go. all also removes unused images; volumes also removes
+// anonymous volumes not used by any container.
+func (c *Client) SystemPrune(ctx context.Context, all, volumes bool) (interface{}, error) {
+	output, err := c.ExecuteCommand(ctx, "system", systemPruneArgs(all, volumes))
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, reclaimed := parseSystemPruneOutput(output)
+	return map[string]interface{}{
+		"deleted":        deleted,
+		"spaceReclaimed": reclaimed,
+		"output":         output,
+	}, nil
+}
+
+func systemPruneArgs(all, volumes bool) []string {
+	args := []string{"prune", "-f"}
+	if all {
+		args = append(args, "-a")
+	}
+	if volumes {
+		args = append(args, "--volumes")
+	}
+	return args
+}
+
+// systemPruneSections maps the "Deleted ...:" headings of system prune output
+// to result categories
+var systemPruneSections = map[string]string{
+	"deleted containers:":          "containers",
+	"deleted networks:":            "networks",
+	"deleted volumes:":             "volumes",
+	"deleted images:":              "images",
+	"deleted build cache objects:": "buildCache",
+}
+
+// parseSystemPruneOutput groups removed objects by category. Docker only
+// reports the reclaimed space as a single total.
+func parseSystemPruneOutput(output string) (map[string][]string, int64) {
+	deleted := map[string][]string{}
+	for _, category := range systemPruneSections {
+		deleted[category] = []string{}
+	}
+
+	var reclaimed int64
+	section := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if category, ok := systemPruneSections[strings.ToLower(line)]; ok {
+			section = category
+			continue
+		}
+
+		switch {
+		case line == "":
+			section = ""
+		case strings.HasPrefix(line, "Total reclaimed space:"):
+			reclaimed, _ = parseHumanSize(strings.TrimPrefix(line, "Total reclaimed space:"))
+		case section == "images":
+			if strings.HasPrefix(line, "untagged:") {
+				continue
+			}
+			deleted[section] = append(deleted[section], strings.TrimSpace(strings.TrimPrefix(line, "deleted:")))
+		case section != "":
+			deleted[section] = append(deleted[section], line)
+		}
+	}
+
+	return deleted, reclaimed
+}
+
+// buildFilterArgs turns a filter map into sorted --filter key=value flags
+func buildFilterArgs(filters map[string][]string) []string {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := []string{}
+	for _, key := range keys {
+		for _, value := range filters[key] {
+			args = append(args, "--filter", key+"="+value)
+		}
+	}
+	return args
+}
+
+func pruneResult(output string) map[string]interface{} {
+	deleted, reclaimed := parsePruneOutput(output)
+	return map[string]interface{}{
+		"deleted":        deleted,
+		"spaceReclaimed": reclaimed,
+		"output":         output,
+	}
+}
+
+// parsePruneOutput extracts removed IDs and reclaimed bytes from docker prune output
+func parsePruneOutput(output string) ([]string, int64) {
+	deleted := []string{}
+	var reclaimed int64
+	inDeleted, inTable := false, false
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+			inDeleted, inTable = false, false
+		case strings.HasPrefix(line, "Total reclaimed space:"):
+			reclaimed, _ = parseHumanSize(strings.TrimPrefix(line, "Total reclaimed space:"))
+		case strings.HasPrefix(line, "Total:"):
+			reclaimed, _ = parseHumanSize(strings.TrimPrefix(line, "Total:"))
+		case strings.HasPrefix(line, "Deleted ") && strings.HasSuffix(line, ":"):
+			inDeleted = true
+		case strings.HasPrefix(line, "ID") && strings.Contains(line, "RECLAIMABLE"):
+			// Newer builder prune prints a table of removed cache records
+			inTable = true
+		case inTable:
+			if fields := strings.Fields(line); len(fields) > 0 {
+				deleted = append(deleted, strings.TrimSuffix(fields[0], "*"))
+			}
+		case inDeleted:
+			// Image prune lists both "untagged: <ref>" and "deleted: <id>" entries
+			if strings.HasPrefix(line, "untagged:") {
+				continue
+			}
+			deleted = append(deleted, strings.TrimSpace(strings.TrimPrefix(line, "deleted:")))
+		}
+	}
+
+	return deleted, reclaimed
+}
+
+// portProbeImage runs `cat` in a container's network namespace when the
+// container itself has no cat, e.g. distroless or scratch images
+const portProbeImage = "busybox:stable"
+
+// PortListener is a TCP socket in LISTEN state inside a container
+type PortListener struct {
+	Port    int    `json:"port"`
+	Address string `json:"address"`
+	// Loopback is set for sockets bound to 127.0.0.0/8 or ::1, which a
+	// published port cannot reach
+	Loopback bool `json:"loopback"`
+}
+
+// CheckContainerPorts compares the TCP ports listening inside a running
+// container with the ports it exposes and publishes. Published ports whose
+// listeners are all bound to loopback are reported separately, since they
+// accept no traffic from the published host port.
+func (c *Client) CheckContainerPorts(ctx context.Context, containerID string) (interface{}, error) {
+	procOutput, err := c.readProcNetTCP(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	listeners := parseProcNetTCP(procOutput)
+
+	published := []map[string]interface{}{}
+	if portOutput, err := c.ExecuteCommand(ctx, "port", []string{containerID}); err == nil {
+		published = parseDockerPortOutput(portOutput)
+	}
+
+	exposed := []string{}
+	if exposedOutput, err := c.ExecuteCommand(ctx, "inspect", []string{"--format", "{{json .Config.ExposedPorts}}", containerID}); err == nil {
+		var exposedPorts map[string]interface{}
+		if json.Unmarshal([]byte(exposedOutput), &exposedPorts) == nil {
+			for port := range exposedPorts {
+				exposed = append(exposed, port)
+			}
+			sort.Strings(exposed)
+		}
+	}
+
+	// A port is reachable when at least one of its listeners is not loopback
+	listening := []int{}
+	reachable := map[int]bool{}
+	for _, listener := range listeners {
+		if _, seen := reachable[listener.Port]; !seen {
+			listening = append(listening, listener.Port)
+		}
+		reachable[listener.Port] = reachable[listener.Port] || !listener.Loopback
+	}
+
+	publishedNotListening := []int{}
+	publishedLoopbackOnly := []int{}
+	publishedSet := map[int]bool{}
+	for _, mapping := range published {
+		port, _ := mapping["containerPort"].(int)
+		proto, _ := mapping["protocol"].(string)
+		if proto != "tcp" || publishedSet[port] {
+			continue
+		}
+		publishedSet[port] = true
+		switch isReachable, isListening := reachable[port]; {
+		case !isListening:
+			publishedNotListening = append(publishedNotListening, port)
+		case !isReachable:
+			publishedLoopbackOnly = append(publishedLoopbackOnly, port)
+		}
+	}
+
+	listeningNotPublished := []int{}
+	for _, port := range listening {
+		if !publishedSet[port] {
+			listeningNotPublished = append(listeningNotPublished, port)
+		}
+	}
+
+	return map[string]interface{}{
+		"container_id":          containerID,
+		"listening":             listening,
+		"listeners":             listeners,
+		"exposed":               exposed,
+		"published":             published,
+		"publishedNotListening": publishedNotListening,
+		"publishedLoopbackOnly": publishedLoopbackOnly,
+		"listeningNotPublished": listeningNotPublished,
+	}, nil
+}
+
+// readProcNetTCP reads /proc/net/tcp and /proc/net/tcp6 of a container's
+// network namespace. Each file is read on its own because tcp6 is missing
+// when IPv6 is disabled. Images without cat are read through a throwaway
+// portProbeImage container sharing the container's network namespace.
+func (c *Client) readProcNetTCP(ctx context.Context, containerID string) (string, error) {
+	read := func(file string) (string, error) {
+		return c.ExecuteCommand(ctx, "exec", []string{containerID, "cat", file})
+	}
+	output, err := read("/proc/net/tcp")
+	if err != nil && strings.Contains(err.Error(), "executable file not found") {
+		read = func(file string) (string, error) {
+			return c.ExecuteCommand(ctx, "run", []string{"--rm", "--network", "container:" + containerID, "--entrypoint", "cat", portProbeImage, file})
+		}
+		output, err = read("/proc/net/tcp")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if tcp6, err := read("/proc/net/tcp6"); err == nil {
+		output += "\n" + tcp6
+	}
+	return output, nil
+}
+
+// parseProcNetTCP returns the sockets in LISTEN state from the contents of
+// /proc/net/tcp and /proc/net/tcp6, sorted by port and address
+func parseProcNetTCP(output string) []PortListener {
+	seen := map[PortListener]bool{}
+	listeners := []PortListener{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		// sl local_address rem_address st ...
+		if len(fields) < 4 || fields[0] == "sl" || fields[3] != "0A" {
+			continue
+		}
+
+		hexAddr, hexPort, found := strings.Cut(fields[1], ":")
+		if !found {
+			continue
+		}
+		port, err := strconv.ParseInt(hexPort, 16, 32)
+		if err != nil {
+			continue
+		}
+		ip := parseProcNetAddress(hexAddr)
+		if ip == nil {
+			continue
+		}
+
+		listener := PortListener{Port: int(port), Address: ip.String(), Loopback: ip.IsLoopback()}
+		if seen[listener] {
+			continue
+		}
+		seen[listener] = true
+		listeners = append(listeners, listener)
+	}
+
+	sort.Slice(listeners, func(i, j int) bool {
+		if listeners[i].Port != listeners[j].Port {
+			return listeners[i].Port < listeners[j].Port
+		}
+		return listeners[i].Address < listeners[j].Address
+	})
+	return listeners
+}
+
+// parseProcNetAddress decodes a /proc/net/tcp{,6} address: the IP as hex
+// 32-bit words, each in host (little-endian) byte order
+func parseProcNetAddress(hexAddr string) net.IP {
+	raw, err := hex.DecodeString(hexAddr)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return nil
+	}
+	ip := make(net.IP, len(raw))
+	for word := 0; word < len(raw); word += 4 {
+		for i := 0; i < 4; i++ {
+			ip[word+i] = raw[word+3-i]
+		}
+	}
+	return ip
+}
+
+// parseDockerPortOutput parses `docker port` lines like "80/tcp -> 0.0.0.0:8080"
+func parseDockerPortOutput(output string) []map[string]interface{} {
+	mappings := []map[string]interface{}{}
+
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.Split(strings.TrimSpace(line), " -> ")
+		if len(parts) != 2 {
+			continue
+		}
+
+		portProto := strings.SplitN(parts[0], "/", 2)
+		if len(portProto) != 2 {
+			continue
+		}
+		containerPort, err := strconv.Atoi(portProto[0])
+		if err != nil {
+			continue
+		}
+
+		hostIdx := strings.LastIndex(parts[1], ":")
+		if hostIdx < 0 {
+			continue
+		}
+		hostPort, _ := strconv.Atoi(parts[1][hostIdx+1:])
+
+		mappings = append(mappings, map[string]interface{}{
+			"containerPort": containerPort,
+			"protocol":      portProto[1],
+			"hostIp":        parts[1][:hostIdx],
+			"hostPort":      hostPort,
+		})
+	}
+
+	return mappings
+}
+
+// Additional useful methods
+
+// RemoveContainer removes a container
+func (c *Client) RemoveContainer(ctx context.Context, containerID string, force bool) (interface{}, error) {
+	args := []string{"rm", containerID}
+	if force {
+		args = []string{"rm", "-f", containerID}
+	}
+
+	output, err := c.ExecuteCommand(ctx, "rm", args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"container_id": containerID,
+		"status":       "removed",
+		"output":       output,
+	}, nil
+}
+
+// GetContainerLogs gets logs from a container
+func (c *Client) GetContainerLogs(ctx context.Context, containerID string, tail int) (interface{}, error) {
+	args := []string{"logs"}
+	if tail > 0 {
 		args = append(args, "--tail", fmt.Sprintf("%d", tail))
 	}
-	if serviceName != "" {
-		args = append(args, serviceName)
+	args = append(args, containerID)
+
+	output, err := c.ExecuteCommand(ctx, "logs", args[1:])
+	if err != nil {
+		return nil, err
 	}
 
-	cmd := exec.Command("docker-compose", args...)
+	return map[string]interface{}{
+		"container_id": containerID,
+		"logs":         output,
+	}, nil
+}
+
+// ComposeUp runs docker-compose up
+func (c *Client) ComposeUp(ctx context.Context, composeFile string) (interface{}, error) {
+	cmd := c.composeCommand(ctx, composeArgs(composeFile, "", ComposeProfiles(ctx), "up", "-d")...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return nil, fmt.Errorf("docker-compose logs failed: %s", string(output))
+		return nil, newComposeError("up", "", string(output))
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"status":       "started",
+		"output":       string(output),
+	}, nil
+}
+
+// ComposeDown runs docker-compose down
+func (c *Client) ComposeDown(ctx context.Context, composeFile string) (interface{}, error) {
+	cmd := c.composeCommand(ctx, composeArgs(composeFile, "", ComposeProfiles(ctx), "down")...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, newComposeError("down", "", string(output))
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"status":       "stopped",
+		"output":       string(output),
+	}, nil
+}
+
+// JoinComposeFiles combines several compose files into the single composeFile
+// argument the Compose methods accept, using the same list separator as
+// COMPOSE_FILE. Later files override earlier ones.
+func JoinComposeFiles(files []string) string {
+	return strings.Join(files, string(filepath.ListSeparator))
+}
+
+type composeProfilesKey struct{}
+
+// WithComposeProfiles returns a context under which every compose command
+// enables the given profiles. Applying the same profiles to up, down, ps and
+// logs keeps profile-gated services visible to all of them.
+func WithComposeProfiles(ctx context.Context, profiles []string) context.Context {
+	return context.WithValue(ctx, composeProfilesKey{}, profiles)
+}
+
+// ComposeProfiles returns the profiles set by WithComposeProfiles
+func ComposeProfiles(ctx context.Context) []string {
+	profiles, _ := ctx.Value(composeProfilesKey{}).([]string)
+	return profiles
+}
+
+type composeProjectNameKey struct{}
+
+// WithComposeProjectName returns a context under which project-scoped compose
+// commands use name as the compose project (-p) instead of the name they were
+// called with, so a stack folder can map to a differently named project
+func WithComposeProjectName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, composeProjectNameKey{}, name)
+}
+
+// ComposeProjectName returns the compose project name set by
+// WithComposeProjectName, falling back to projectName
+func ComposeProjectName(ctx context.Context, projectName string) string {
+	if name, _ := ctx.Value(composeProjectNameKey{}).(string); name != "" {
+		return name
+	}
+	return projectName
+}
+
+// composeArgs builds the -f/-p/--profile prefix shared by project-scoped
+// compose commands. composeFile may hold several files joined by JoinComposeFiles.
+func composeArgs(composeFile, projectName string, profiles []string, args ...string) []string {
+	cmdArgs := []string{}
+	for _, file := range filepath.SplitList(composeFile) {
+		cmdArgs = append(cmdArgs, "-f", file)
+	}
+	if projectName != "" {
+		cmdArgs = append(cmdArgs, "-p", projectName)
+	}
+	for _, profile := range profiles {
+		cmdArgs = append(cmdArgs, "--profile", profile)
+	}
+	return append(cmdArgs, args...)
+}
+
+// runCompose executes a project-scoped docker-compose subcommand
+func (c *Client) runCompose(ctx context.Context, composeFile, projectName string, args ...string) (string, error) {
+	cmd := c.composeCommand(ctx, composeArgs(composeFile, ComposeProjectName(ctx, projectName), ComposeProfiles(ctx), args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", newComposeError(args[0], ComposeProjectName(ctx, projectName), string(output))
+	}
+	return string(output), nil
+}
+
+// ComposeCreate creates the containers of a compose project, or only those of
+// the given services, without starting them
+func (c *Client) ComposeCreate(ctx context.Context, composeFile, projectName string, services ...string) (interface{}, error) {
+	output, err := c.runCompose(ctx, composeFile, projectName, append([]string{"create"}, services...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"project_name": projectName,
+		"services":     services,
+		"status":       "created",
+		"output":       output,
+	}, nil
+}
+
+// ComposeStart starts existing containers of a compose project, or only
+// those of the given services
+func (c *Client) ComposeStart(ctx context.Context, composeFile, projectName string, services ...string) (interface{}, error) {
+	output, err := c.runCompose(ctx, composeFile, projectName, append([]string{"start"}, services...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"project_name": projectName,
+		"services":     services,
+		"status":       "started",
+		"output":       output,
+	}, nil
+}
+
+// ComposeStop stops running containers of a compose project without removing them
+func (c *Client) ComposeStop(ctx context.Context, composeFile, projectName string) (interface{}, error) {
+	output, err := c.runCompose(ctx, composeFile, projectName, "stop")
+	if err != nil {
+		return nil, err
 	}
 
 	return map[string]interface{}{
 		"compose_file": composeFile,
 		"project_name": projectName,
-		"service_name": serviceName,
+		"status":       "stopped",
+		"output":       output,
+	}, nil
+}
+
+// ComposeRestart restarts the containers of a compose project, or only those
+// of the given services
+func (c *Client) ComposeRestart(ctx context.Context, composeFile, projectName string, services ...string) (interface{}, error) {
+	output, err := c.runCompose(ctx, composeFile, projectName, append([]string{"restart"}, services...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"project_name": projectName,
+		"services":     services,
+		"status":       "restarted",
+		"output":       output,
+	}, nil
+}
+
+// ComposePause pauses all running containers of a compose project
+func (c *Client) ComposePause(ctx context.Context, composeFile, projectName string) (interface{}, error) {
+	output, err := c.runCompose(ctx, composeFile, projectName, "pause")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"project_name": projectName,
+		"status":       "paused",
+		"output":       output,
+	}, nil
+}
+
+// ComposeUnpause resumes the paused containers of a compose project
+func (c *Client) ComposeUnpause(ctx context.Context, composeFile, projectName string) (interface{}, error) {
+	output, err := c.runCompose(ctx, composeFile, projectName, "unpause")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"project_name": projectName,
+		"status":       "running",
+		"output":       output,
+	}, nil
+}
+
+// ComposeUpOptions tunes a compose up invocation
+type ComposeUpOptions struct {
+	ForceRecreate bool
+	Profiles      []string
+	EnvOverrides  map[string]string
+}
+
+// ComposeUpWithProject runs docker-compose up with a specific project name
+func (c *Client) ComposeUpWithProject(ctx context.Context, composeFile, projectName string) (interface{}, error) {
+	return c.ComposeUpWithOptions(ctx, composeFile, projectName, ComposeUpOptions{})
+}
+
+// ComposeUpWithOptions runs docker-compose up with profiles, environment
+// overrides and optional forced recreation
+func (c *Client) ComposeUpWithOptions(ctx context.Context, composeFile, projectName string, opts ComposeUpOptions) (interface{}, error) {
+	if len(opts.Profiles) == 0 {
+		opts.Profiles = ComposeProfiles(ctx)
+	}
+	cmd := c.composeCommand(ctx, composeUpArgs(composeFile, ComposeProjectName(ctx, projectName), opts)...)
+	if len(opts.EnvOverrides) > 0 {
+		cmd.Env = mergeEnv(c.environ(), opts.EnvOverrides)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, newComposeError("up", ComposeProjectName(ctx, projectName), string(output))
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"project_name": projectName,
+		"status":       "started",
+		"output":       string(output),
+	}, nil
+}
+
+func composeUpArgs(composeFile, projectName string, opts ComposeUpOptions) []string {
+	args := composeArgs(composeFile, projectName, opts.Profiles, "up", "-d")
+	if opts.ForceRecreate {
+		args = append(args, "--force-recreate")
+	}
+	return args
+}
+
+// ComposeDownWithProject runs docker-compose down with a specific project name
+func (c *Client) ComposeDownWithProject(ctx context.Context, composeFile, projectName string) (interface{}, error) {
+	args := composeArgs(composeFile, ComposeProjectName(ctx, projectName), ComposeProfiles(ctx), "down")
+
+	cmd := c.composeCommand(ctx, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, newComposeError("down", ComposeProjectName(ctx, projectName), string(output))
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"project_name": projectName,
+		"status":       "stopped",
+		"output":       string(output),
+	}, nil
+}
+
+func (c *Client) ComposePs(ctx context.Context, composeFile, projectName string) (interface{}, error) {
+	// --all keeps exited replicas in the output, so a scaled service with a
+	// dead replica is not reported as running
+	args := composeArgs(composeFile, ComposeProjectName(ctx, projectName), ComposeProfiles(ctx), "ps", "--all", "--format", "json")
+
+	cmd := c.composeCommand(ctx, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, newComposeError("ps", ComposeProjectName(ctx, projectName), string(output))
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"project_name": projectName,
+		"services":     string(output),
+	}, nil
+}
+
+// ComposeConfig renders the normalized compose configuration as JSON
+func (c *Client) ComposeConfig(ctx context.Context, composeFile, projectName string) (string, error) {
+	return c.ComposeConfigWithEnv(ctx, composeFile, projectName, nil)
+}
+
+// ComposeConfigWithEnv is ComposeConfig interpolated with envOverrides the way
+// ComposeUpWithOptions applies them, so validation sees what a deploy will run
+func (c *Client) ComposeConfigWithEnv(ctx context.Context, composeFile, projectName string, envOverrides map[string]string) (string, error) {
+	return c.runComposeConfig(ctx, composeFile, projectName, "", envOverrides, "--format", "json")
+}
+
+// RenderComposeConfig renders the fully resolved compose YAML the way
+// `docker compose config` does, with envOverrides taking precedence over the
+// agent environment and the project's .env file
+func (c *Client) RenderComposeConfig(ctx context.Context, composeFile, projectName string, envOverrides map[string]string) (string, error) {
+	return c.runComposeConfig(ctx, composeFile, projectName, "", envOverrides)
+}
+
+// RenderComposeConfigWithEnvFile renders the compose YAML interpolated from
+// envFile instead of the project's own .env file
+func (c *Client) RenderComposeConfigWithEnvFile(ctx context.Context, composeFile, projectName, envFile string) (string, error) {
+	return c.runComposeConfig(ctx, composeFile, projectName, envFile, nil)
+}
+
+func (c *Client) runComposeConfig(ctx context.Context, composeFile, projectName, envFile string, envOverrides map[string]string, extraArgs ...string) (string, error) {
+	var subcommand []string
+	if envFile != "" {
+		subcommand = append(subcommand, "--env-file", envFile)
+	}
+	subcommand = append(subcommand, "config")
+	args := composeArgs(composeFile, ComposeProjectName(ctx, projectName), ComposeProfiles(ctx), append(subcommand, extraArgs...)...)
+
+	cmd := c.composeCommand(ctx, args...)
+	if len(envOverrides) > 0 {
+		cmd.Env = mergeEnv(c.environ(), envOverrides)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", newComposeError("config", ComposeProjectName(ctx, projectName), stderr.String())
+	}
+
+	return string(output), nil
+}
+
+// mergeEnv appends overrides to a KEY=VALUE environment, replacing existing keys
+func mergeEnv(base []string, overrides map[string]string) []string {
+	env := make([]string, 0, len(base)+len(overrides))
+	for _, entry := range base {
+		key := strings.SplitN(entry, "=", 2)[0]
+		if _, overridden := overrides[key]; !overridden {
+			env = append(env, entry)
+		}
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		env = append(env, key+"="+overrides[key])
+	}
+	return env
+}
+
+// ComposePull pulls images for a compose project, optionally limited to specific services
+func (c *Client) ComposePull(ctx context.Context, composeFile, projectName string, services []string) (interface{}, error) {
+	args := composeArgs(composeFile, ComposeProjectName(ctx, projectName), ComposeProfiles(ctx), append([]string{"pull"}, services...)...)
+
+	cmd := c.composeCommand(ctx, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, newComposeError("pull", ComposeProjectName(ctx, projectName), string(output))
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"project_name": projectName,
+		"status":       "pulled",
+		"output":       string(output),
+		"progress":     parseComposePullOutput(string(output)),
+	}, nil
+}
+
+// PullEvent is the latest pull state reported for one compose service
+type PullEvent struct {
+	Service string `json:"service"`
+	Status  string `json:"status"` // pulling, waiting, pulled, skipped, error, interrupted
+	Detail  string `json:"detail,omitempty"`
+}
+
+var (
+	layerIDPattern = regexp.MustCompile(`^[0-9a-f]{12}$`)
+	pullStatuses   = map[string]string{
+		"pulling":     "pulling",
+		"waiting":     "waiting",
+		"pulled":      "pulled",
+		"skipped":     "skipped",
+		"error":       "error",
+		"interrupted": "interrupted",
+	}
+	// docker-compose v1 prints "Pulling web ... done"
+	legacyPullPattern = regexp.MustCompile(`^Pulling (\S+)(?:\s+\(([^)]*)\))?\s*\.\.\.\s*(\w*)`)
+)
+
+// parseComposePullOutput reduces compose pull output to one event per service,
+// in the order services first appear. Per-layer lines are ignored.
+func parseComposePullOutput(output string) []PullEvent {
+	events := []PullEvent{}
+	index := map[string]int{}
+
+	record := func(event PullEvent) {
+		if i, ok := index[event.Service]; ok {
+			events[i] = event
+			return
+		}
+		index[event.Service] = len(events)
+		events = append(events, event)
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		// Strip the spinner and check mark glyphs compose v2 prefixes lines with
+		line = strings.TrimLeftFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r > 127
+		})
+		if line == "" {
+			continue
+		}
+
+		if match := legacyPullPattern.FindStringSubmatch(line); match != nil {
+			status := "pulling"
+			switch strings.ToLower(match[3]) {
+			case "done":
+				status = "pulled"
+			case "error":
+				status = "error"
+			}
+			record(PullEvent{Service: match[1], Status: status, Detail: match[2]})
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || layerIDPattern.MatchString(fields[0]) {
+			continue
+		}
+		status, ok := pullStatuses[strings.ToLower(fields[1])]
+		if !ok {
+			continue
+		}
+
+		detail := strings.TrimSpace(strings.Join(fields[2:], " "))
+		detail = strings.TrimSpace(strings.TrimPrefix(detail, "-"))
+		record(PullEvent{Service: fields[0], Status: status, Detail: detail})
+	}
+
+	return events
+}
+
+// ComposeLogs gets logs from compose services
+func (c *Client) ComposeLogs(ctx context.Context, composeFile, projectName, serviceName string, tail int) (interface{}, error) {
+	return c.ComposeLogsWithOptions(ctx, composeFile, projectName, ComposeLogsOptions{
+		Service: serviceName,
+		Tail:    tail,
+	})
+}
+
+// ComposeLogsOptions narrows a one-shot compose logs collection
+type ComposeLogsOptions struct {
+	Service    string
+	Tail       int
+	Since      string // timestamp or relative duration such as "10m"
+	Until      string // same formats as Since
+	Timestamps bool
+}
+
+// ComposeLogsWithOptions collects compose logs once, without following
+func (c *Client) ComposeLogsWithOptions(ctx context.Context, composeFile, projectName string, opts ComposeLogsOptions) (interface{}, error) {
+	cmd := c.composeCommand(ctx, composeLogsArgs(composeFile, ComposeProjectName(ctx, projectName), ComposeProfiles(ctx), opts)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, newComposeError("logs", ComposeProjectName(ctx, projectName), string(output))
+	}
+
+	return map[string]interface{}{
+		"compose_file": composeFile,
+		"project_name": projectName,
+		"service_name": opts.Service,
 		"logs":         string(output),
 	}, nil
 }
 
+func composeLogsArgs(composeFile, projectName string, profiles []string, opts ComposeLogsOptions) []string {
+	args := composeArgs(composeFile, projectName, profiles, "logs")
+	if opts.Tail > 0 {
+		args = append(args, "--tail", fmt.Sprintf("%d", opts.Tail))
+	}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	if opts.Until != "" {
+		args = append(args, "--until", opts.Until)
+	}
+	if opts.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	if opts.Service != "" {
+		args = append(args, opts.Service)
+	}
+	return args
+}
+
 // GetMetrics collects various Docker metrics
 func (c *Client) GetMetrics(ctx context.Context) (interface{}, error) {
 	metrics := make(map[string]interface{})
@@ -350,7 +1716,7 @@ func (c *Client) GetMetrics(ctx context.Context) (interface{}, error) {
 	}
 
 	// Get stack count (using docker stack ls)
-	if stackOutput, err := c.ExecuteCommand("stack", []string{"ls", "--format", "json"}); err == nil {
+	if stackOutput, err := c.ExecuteCommand(ctx, "stack", []string{"ls", "--format", "json"}); err == nil {
 		lines := strings.Split(strings.TrimSpace(stackOutput), "\n")
 		stackCount := 0
 		for _, line := range lines {
@@ -364,7 +1730,7 @@ func (c *Client) GetMetrics(ctx context.Context) (interface{}, error) {
 	}
 
 	// Get network count
-	if networkOutput, err := c.ExecuteCommand("network", []string{"ls", "--format", "json"}); err == nil {
+	if networkOutput, err := c.ExecuteCommand(ctx, "network", []string{"ls", "--format", "json"}); err == nil {
 		lines := strings.Split(strings.TrimSpace(networkOutput), "\n")
 		networkCount := 0
 		for _, line := range lines {
@@ -378,7 +1744,7 @@ func (c *Client) GetMetrics(ctx context.Context) (interface{}, error) {
 	}
 
 	// Get volume count
-	if volumeOutput, err := c.ExecuteCommand("volume", []string{"ls", "--format", "json"}); err == nil {
+	if volumeOutput, err := c.ExecuteCommand(ctx, "volume", []string{"ls", "--format", "json"}); err == nil {
 		lines := strings.Split(strings.TrimSpace(volumeOutput), "\n")
 		volumeCount := 0
 		for _, line := range lines {
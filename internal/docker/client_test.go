@@ -2,7 +2,17 @@ package docker
 
 import (
 	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
 )
 
 func TestNewClient(t *testing.T) {
@@ -12,6 +22,102 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+func TestClientOptions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the docker binary")
+	}
+
+	// A fake docker binary that echoes the daemon it was pointed at
+	bin := filepath.Join(t.TempDir(), "fake-docker")
+	script := "#!/bin/sh\necho \"$DOCKER_HOST $*\"\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker: %v", err)
+	}
+
+	client := NewClientWithOptions(ClientOptions{Binary: bin, Host: "tcp://10.0.0.5:2375"})
+
+	output, err := client.ExecuteCommand(context.Background(), "ps", []string{"-a"})
+	if err != nil {
+		t.Fatalf("ExecuteCommand() error = %v", err)
+	}
+	if output != "tcp://10.0.0.5:2375 ps -a" {
+		t.Errorf("ExecuteCommand() output = %q", output)
+	}
+
+	cmd := client.composeCommand(context.Background(), "ps")
+	if !slices.Contains(cmd.Env, "DOCKER_HOST=tcp://10.0.0.5:2375") {
+		t.Error("compose command does not export DOCKER_HOST")
+	}
+
+	// Env overrides are layered on top of the configured host
+	merged := mergeEnv(client.environ(), map[string]string{"TAG": "1.0"})
+	if !slices.Contains(merged, "DOCKER_HOST=tcp://10.0.0.5:2375") || !slices.Contains(merged, "TAG=1.0") {
+		t.Errorf("mergeEnv() dropped DOCKER_HOST or overrides")
+	}
+
+	// Defaults leave the environment untouched
+	defaults := NewClient()
+	if cmd := defaults.command("version"); cmd.Env != nil || cmd.Args[0] != "docker" {
+		t.Errorf("default command = %v, env = %v", cmd.Args, cmd.Env)
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the docker binary")
+	}
+
+	// A fake docker binary that knows a single context, "prod"
+	bin := filepath.Join(t.TempDir(), "fake-docker")
+	script := `#!/bin/sh
+if [ "$1" = "context" ] && [ "$2" = "inspect" ]; then
+  [ "$3" = "prod" ] && exit 0
+  echo "context \"$3\" does not exist" >&2
+  exit 1
+fi
+echo "$*"
+`
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker: %v", err)
+	}
+
+	client := NewClientWithOptions(ClientOptions{Binary: bin, Context: "prod"})
+
+	for _, cmd := range []*exec.Cmd{
+		client.command("ps", "-a"),
+		client.commandContext(context.Background(), "ps", "-a"),
+	} {
+		if want := []string{bin, "--context", "prod", "ps", "-a"}; !slices.Equal(cmd.Args, want) {
+			t.Errorf("command args = %v, want %v", cmd.Args, want)
+		}
+	}
+
+	output, err := client.ExecuteCommand(context.Background(), "images", []string{"-q"})
+	if err != nil {
+		t.Fatalf("ExecuteCommand() error = %v", err)
+	}
+	if output != "--context prod images -q" {
+		t.Errorf("ExecuteCommand() output = %q", output)
+	}
+
+	// docker-compose has no --context flag and reads DOCKER_CONTEXT instead
+	cmd := client.composeCommand(context.Background(), "ps")
+	if !slices.Contains(cmd.Env, "DOCKER_CONTEXT=prod") || slices.Contains(cmd.Args, "--context") {
+		t.Errorf("compose command args = %v, env missing DOCKER_CONTEXT = %v", cmd.Args, !slices.Contains(cmd.Env, "DOCKER_CONTEXT=prod"))
+	}
+
+	if err := client.ValidateContext(context.Background()); err != nil {
+		t.Errorf("ValidateContext() error = %v", err)
+	}
+	missing := NewClientWithOptions(ClientOptions{Binary: bin, Context: "staging"})
+	if err := missing.ValidateContext(context.Background()); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected missing context error, got %v", err)
+	}
+	if err := NewClientWithOptions(ClientOptions{Binary: bin}).ValidateContext(context.Background()); err != nil {
+		t.Errorf("Expected no validation without a context, got %v", err)
+	}
+}
+
 func TestIsDockerAvailable(t *testing.T) {
 	client := NewClient()
 
@@ -27,7 +133,7 @@ func TestExecuteCommand(t *testing.T) {
 	client := NewClient()
 
 	t.Run("invalid command should return error", func(t *testing.T) {
-		_, err := client.ExecuteCommand("invalid-command-that-does-not-exist", []string{})
+		_, err := client.ExecuteCommand(context.Background(), "invalid-command-that-does-not-exist", []string{})
 		if err == nil {
 			t.Error("Expected error for invalid command")
 		}
@@ -93,3 +199,842 @@ func TestRemoveContainer(t *testing.T) {
 	// Don't assert error here as Docker behavior may vary
 	t.Logf("Force remove result: %v", err)
 }
+
+func TestMergeEnv(t *testing.T) {
+	base := []string{"PATH=/usr/bin", "TAG=1.0", "EMPTY="}
+	env := mergeEnv(base, map[string]string{"TAG": "2.0", "NEW": "value"})
+
+	expected := []string{"PATH=/usr/bin", "EMPTY=", "NEW=value", "TAG=2.0"}
+	if len(env) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, env)
+	}
+	for i := range expected {
+		if env[i] != expected[i] {
+			t.Errorf("Expected %s at %d, got %s", expected[i], i, env[i])
+		}
+	}
+}
+
+func TestRenderComposeConfig(t *testing.T) {
+	if _, err := exec.LookPath("docker-compose"); err != nil {
+		t.Skip("docker-compose not available, skipping render test")
+	}
+
+	dir := t.TempDir()
+	composeFile := filepath.Join(dir, "docker-compose.yml")
+	content := "services:\n  web:\n    image: nginx:${NGINX_TAG}\n"
+	if err := os.WriteFile(composeFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write compose file: %v", err)
+	}
+
+	client := NewClient()
+	rendered, err := client.RenderComposeConfig(context.Background(), composeFile, "render-test", map[string]string{
+		"NGINX_TAG": "1.27-alpine",
+	})
+	if err != nil {
+		t.Fatalf("RenderComposeConfig failed: %v", err)
+	}
+
+	if !strings.Contains(rendered, "nginx:1.27-alpine") {
+		t.Errorf("Expected substituted image tag in rendered config, got:\n%s", rendered)
+	}
+}
+
+func TestParseSystemDf(t *testing.T) {
+	output := `{"Active":"2","Reclaimable":"1.2GB (50%)","Size":"2.4GB","TotalCount":"5","Type":"Images"}
+{"Active":"1","Reclaimable":"0B (0%)","Size":"12.5kB","TotalCount":"3","Type":"Containers"}
+{"Active":"1","Reclaimable":"100MB (33%)","Size":"300MB","TotalCount":"2","Type":"Local Volumes"}
+{"Active":"0","Reclaimable":"50MB","Size":"50MB","TotalCount":"4","Type":"Build Cache"}`
+
+	result, err := parseSystemDf(output)
+	if err != nil {
+		t.Fatalf("parseSystemDf failed: %v", err)
+	}
+
+	usage, ok := result["usage"].([]map[string]interface{})
+	if !ok || len(usage) != 4 {
+		t.Fatalf("Expected 4 usage entries, got %v", result["usage"])
+	}
+
+	images := usage[0]
+	if images["type"] != "Images" {
+		t.Errorf("Expected type 'Images', got %v", images["type"])
+	}
+	if images["size"] != int64(2400000000) {
+		t.Errorf("Expected size 2400000000, got %v", images["size"])
+	}
+	if images["reclaimable"] != int64(1200000000) {
+		t.Errorf("Expected reclaimable 1200000000, got %v", images["reclaimable"])
+	}
+	if images["totalCount"] != 5 || images["active"] != 2 {
+		t.Errorf("Expected totalCount 5 and active 2, got %v and %v", images["totalCount"], images["active"])
+	}
+
+	expectedTotal := int64(2400000000 + 12500 + 300000000 + 50000000)
+	if result["totalSize"] != expectedTotal {
+		t.Errorf("Expected totalSize %d, got %v", expectedTotal, result["totalSize"])
+	}
+
+	expectedReclaimable := int64(1200000000 + 100000000 + 50000000)
+	if result["totalReclaimable"] != expectedReclaimable {
+		t.Errorf("Expected totalReclaimable %d, got %v", expectedReclaimable, result["totalReclaimable"])
+	}
+
+	if _, err := parseSystemDf("not json"); err == nil {
+		t.Error("Expected error for invalid output")
+	}
+}
+
+func TestParseStatsLine(t *testing.T) {
+	line := `{"BlockIO":"12.3MB / 4.5MB","CPUPerc":"1.25%","Container":"abc123","ID":"abc123","MemPerc":"0.50%","MemUsage":"10MiB / 2GiB","Name":"web","NetIO":"1.5kB / 500B","PIDs":"7"}`
+
+	stats, err := parseStatsLine(line)
+	if err != nil {
+		t.Fatalf("parseStatsLine failed: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"id":              "abc123",
+		"name":            "web",
+		"cpuPercent":      1.25,
+		"memoryUsage":     int64(10 * 1024 * 1024),
+		"memoryLimit":     int64(2 * 1024 * 1024 * 1024),
+		"memoryPercent":   0.5,
+		"networkRxBytes":  int64(1500),
+		"networkTxBytes":  int64(500),
+		"blockReadBytes":  int64(12300000),
+		"blockWriteBytes": int64(4500000),
+		"pids":            7,
+	}
+
+	for key, value := range expected {
+		if stats[key] != value {
+			t.Errorf("Expected %s = %v (%T), got %v (%T)", key, value, value, stats[key], stats[key])
+		}
+	}
+
+	if _, err := parseStatsLine("not json"); err == nil {
+		t.Error("Expected error for invalid stats line")
+	}
+}
+
+func TestParsePruneOutput(t *testing.T) {
+	tests := []struct {
+		name              string
+		output            string
+		expectedDeleted   []string
+		expectedReclaimed int64
+	}{
+		{
+			name: "image prune",
+			output: `Deleted Images:
+untagged: nginx@sha256:0123
+deleted: sha256:abc123
+deleted: sha256:def456
+
+Total reclaimed space: 1.2GB`,
+			expectedDeleted:   []string{"sha256:abc123", "sha256:def456"},
+			expectedReclaimed: 1200000000,
+		},
+		{
+			name: "container prune",
+			output: `Deleted Containers:
+4a7f7eebae0f
+2c6a4ad0b8c3
+
+Total reclaimed space: 12kB`,
+			expectedDeleted:   []string{"4a7f7eebae0f", "2c6a4ad0b8c3"},
+			expectedReclaimed: 12000,
+		},
+		{
+			name: "builder prune table",
+			output: `ID                                              RECLAIMABLE     SIZE            LAST ACCESSED
+x1y2z3*                                         true            1.5MB           2 days ago
+q9w8e7                                          true            500kB           3 days ago
+Total:  2MB`,
+			expectedDeleted:   []string{"x1y2z3", "q9w8e7"},
+			expectedReclaimed: 2000000,
+		},
+		{
+			name:              "nothing to prune",
+			output:            "Total reclaimed space: 0B",
+			expectedDeleted:   []string{},
+			expectedReclaimed: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted, reclaimed := parsePruneOutput(tt.output)
+
+			if len(deleted) != len(tt.expectedDeleted) {
+				t.Fatalf("Expected deleted %v, got %v", tt.expectedDeleted, deleted)
+			}
+			for i := range deleted {
+				if deleted[i] != tt.expectedDeleted[i] {
+					t.Errorf("Expected deleted[%d] = %s, got %s", i, tt.expectedDeleted[i], deleted[i])
+				}
+			}
+
+			if reclaimed != tt.expectedReclaimed {
+				t.Errorf("Expected reclaimed %d, got %d", tt.expectedReclaimed, reclaimed)
+			}
+		})
+	}
+}
+
+func TestBuildFilterArgs(t *testing.T) {
+	args := buildFilterArgs(map[string][]string{
+		"until": {"24h"},
+		"label": {"env=dev", "tier=web"},
+	})
+
+	expected := []string{"--filter", "label=env=dev", "--filter", "label=tier=web", "--filter", "until=24h"}
+	if strings.Join(args, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected %v, got %v", expected, args)
+	}
+
+	if args := buildFilterArgs(nil); len(args) != 0 {
+		t.Errorf("Expected no args for nil filters, got %v", args)
+	}
+}
+
+func TestParseProcNetTCP(t *testing.T) {
+	output := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12345 1
+   1: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12346 1
+   2: 0A000002:0050 0A000001:C350 01 00000000:00000000 00:00000000 00000000     0        0 12347 1
+  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0050 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12348 1`
+
+	listeners := parseProcNetTCP(output)
+
+	expected := []PortListener{
+		{Port: 80, Address: "0.0.0.0"},
+		{Port: 80, Address: "::"},
+		{Port: 8080, Address: "127.0.0.1", Loopback: true},
+	}
+	if !reflect.DeepEqual(listeners, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, listeners)
+	}
+
+	if ip := parseProcNetAddress("00000000000000000000000001000000"); ip.String() != "::1" || !ip.IsLoopback() {
+		t.Errorf("Expected ::1, got %v", ip)
+	}
+}
+
+func TestCheckContainerPorts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker is a shell script")
+	}
+
+	// The container has no cat and IPv6 disabled; only the probe container
+	// can read /proc/net/tcp. 80 listens on all addresses, 8080 on loopback.
+	bin := filepath.Join(t.TempDir(), "docker")
+	script := `#!/bin/sh
+case "$1 $*" in
+exec*) echo 'OCI runtime exec failed: exec: "cat": executable file not found in $PATH' >&2; exit 126 ;;
+run*/proc/net/tcp6) echo "cat: can't open '/proc/net/tcp6': No such file or directory" >&2; exit 1 ;;
+run*)
+  echo "  sl  local_address rem_address   st"
+  echo "   0: 00000000:0050 00000000:0000 0A"
+  echo "   1: 0100007F:1F90 00000000:0000 0A"
+  ;;
+port*)
+  echo "80/tcp -> 0.0.0.0:80"
+  echo "8080/tcp -> 0.0.0.0:8080"
+  echo "9000/tcp -> 0.0.0.0:9000"
+  ;;
+inspect*) echo '{"80/tcp":{}}' ;;
+esac
+`
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker: %v", err)
+	}
+	client := NewClientWithOptions(ClientOptions{Binary: bin})
+
+	result, err := client.CheckContainerPorts(context.Background(), "web")
+	if err != nil {
+		t.Fatalf("CheckContainerPorts() error = %v", err)
+	}
+	report := result.(map[string]interface{})
+	if got := report["listening"]; !reflect.DeepEqual(got, []int{80, 8080}) {
+		t.Errorf("listening = %v", got)
+	}
+	if got := report["publishedLoopbackOnly"]; !reflect.DeepEqual(got, []int{8080}) {
+		t.Errorf("publishedLoopbackOnly = %v", got)
+	}
+	if got := report["publishedNotListening"]; !reflect.DeepEqual(got, []int{9000}) {
+		t.Errorf("publishedNotListening = %v", got)
+	}
+}
+
+func TestParseDockerPortOutput(t *testing.T) {
+	output := `80/tcp -> 0.0.0.0:8080
+80/tcp -> [::]:8080
+53/udp -> 127.0.0.1:5353`
+
+	mappings := parseDockerPortOutput(output)
+	if len(mappings) != 3 {
+		t.Fatalf("Expected 3 mappings, got %d", len(mappings))
+	}
+
+	first := mappings[0]
+	if first["containerPort"] != 80 || first["protocol"] != "tcp" || first["hostIp"] != "0.0.0.0" || first["hostPort"] != 8080 {
+		t.Errorf("Unexpected first mapping: %v", first)
+	}
+
+	if mappings[1]["hostIp"] != "[::]" {
+		t.Errorf("Expected IPv6 host IP '[::]', got %v", mappings[1]["hostIp"])
+	}
+
+	if mappings[2]["protocol"] != "udp" || mappings[2]["hostPort"] != 5353 {
+		t.Errorf("Unexpected udp mapping: %v", mappings[2])
+	}
+}
+
+func TestComposeUpArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     ComposeUpOptions
+		expected []string
+	}{
+		{
+			name:     "defaults",
+			opts:     ComposeUpOptions{},
+			expected: []string{"-f", "/stacks/web/docker-compose.yml", "-p", "web", "up", "-d"},
+		},
+		{
+			name:     "force recreate",
+			opts:     ComposeUpOptions{ForceRecreate: true},
+			expected: []string{"-f", "/stacks/web/docker-compose.yml", "-p", "web", "up", "-d", "--force-recreate"},
+		},
+		{
+			name:     "profiles",
+			opts:     ComposeUpOptions{Profiles: []string{"debug", "metrics"}},
+			expected: []string{"-f", "/stacks/web/docker-compose.yml", "-p", "web", "--profile", "debug", "--profile", "metrics", "up", "-d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := composeUpArgs("/stacks/web/docker-compose.yml", "web", tt.opts)
+			if strings.Join(args, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("Expected %v, got %v", tt.expected, args)
+			}
+		})
+	}
+}
+
+func TestComposeArgsMultipleFiles(t *testing.T) {
+	composeFile := JoinComposeFiles([]string{
+		"/stacks/web/compose.yaml",
+		"/stacks/web/compose.override.yaml",
+		"/stacks/web/compose.prod.yaml",
+	})
+
+	args := composeUpArgs(composeFile, "web", ComposeUpOptions{})
+	expected := []string{
+		"-f", "/stacks/web/compose.yaml",
+		"-f", "/stacks/web/compose.override.yaml",
+		"-f", "/stacks/web/compose.prod.yaml",
+		"-p", "web", "up", "-d",
+	}
+	if strings.Join(args, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected %v, got %v", expected, args)
+	}
+}
+
+func TestComposeProfiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the compose binary")
+	}
+
+	// A fake docker-compose that echoes its arguments
+	bin := filepath.Join(t.TempDir(), "fake-compose")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"$*\"\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake compose: %v", err)
+	}
+	client := NewClient()
+	client.compose = bin
+
+	ctx := WithComposeProfiles(context.Background(), []string{"debug", "metrics"})
+	prefix := "-f /stacks/web/compose.yaml -p web --profile debug --profile metrics "
+
+	output := func(result interface{}, err error, key string) string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("compose operation failed: %v", err)
+		}
+		value, _ := result.(map[string]interface{})[key].(string)
+		return strings.TrimSpace(value)
+	}
+
+	tests := []struct {
+		name string
+		run  func() string
+		want string
+	}{
+		{"up", func() string {
+			r, err := client.ComposeUpWithOptions(ctx, "/stacks/web/compose.yaml", "web", ComposeUpOptions{})
+			return output(r, err, "output")
+		}, "up -d"},
+		{"down", func() string {
+			r, err := client.ComposeDownWithProject(ctx, "/stacks/web/compose.yaml", "web")
+			return output(r, err, "output")
+		}, "down"},
+		{"ps", func() string {
+			r, err := client.ComposePs(ctx, "/stacks/web/compose.yaml", "web")
+			return output(r, err, "services")
+		}, "ps --all --format json"},
+		{"logs", func() string {
+			r, err := client.ComposeLogsWithOptions(ctx, "/stacks/web/compose.yaml", "web", ComposeLogsOptions{Tail: 10})
+			return output(r, err, "logs")
+		}, "logs --tail 10"},
+		{"pull", func() string {
+			r, err := client.ComposePull(ctx, "/stacks/web/compose.yaml", "web", []string{"api"})
+			return output(r, err, "output")
+		}, "pull api"},
+		{"restart", func() string {
+			r, err := client.ComposeRestart(ctx, "/stacks/web/compose.yaml", "web", "api")
+			return output(r, err, "output")
+		}, "restart api"},
+		{"stop", func() string {
+			r, err := client.ComposeStop(ctx, "/stacks/web/compose.yaml", "web")
+			return output(r, err, "output")
+		}, "stop"},
+		{"config", func() string {
+			out, err := client.ComposeConfig(ctx, "/stacks/web/compose.yaml", "web")
+			if err != nil {
+				t.Fatalf("ComposeConfig() error = %v", err)
+			}
+			return strings.TrimSpace(out)
+		}, "config --format json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.run(); got != prefix+tt.want {
+				t.Errorf("args = %q, want %q", got, prefix+tt.want)
+			}
+		})
+	}
+
+	// Explicit up profiles win over the context
+	r, err := client.ComposeUpWithOptions(ctx, "/stacks/web/compose.yaml", "web", ComposeUpOptions{Profiles: []string{"prod"}})
+	if got := output(r, err, "output"); got != "-f /stacks/web/compose.yaml -p web --profile prod up -d" {
+		t.Errorf("explicit profiles args = %q", got)
+	}
+
+	// Without profiles no --profile flags are added
+	r, err = client.ComposeDownWithProject(context.Background(), "/stacks/web/compose.yaml", "web")
+	if got := output(r, err, "output"); got != "-f /stacks/web/compose.yaml -p web down" {
+		t.Errorf("no profiles args = %q", got)
+	}
+}
+
+func TestComposeProjectNameOverride(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the compose binary")
+	}
+
+	bin := filepath.Join(t.TempDir(), "fake-compose")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"$*\"\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake compose: %v", err)
+	}
+	client := NewClient()
+	client.compose = bin
+
+	ctx := WithComposeProjectName(context.Background(), "shared-infra")
+	if got := ComposeProjectName(ctx, "web"); got != "shared-infra" {
+		t.Errorf("ComposeProjectName() = %q, want shared-infra", got)
+	}
+	if got := ComposeProjectName(context.Background(), "web"); got != "web" {
+		t.Errorf("ComposeProjectName() without override = %q, want web", got)
+	}
+
+	r, err := client.ComposeUpWithOptions(ctx, "/stacks/web/compose.yaml", "web", ComposeUpOptions{})
+	if err != nil {
+		t.Fatalf("ComposeUpWithOptions() error = %v", err)
+	}
+	if got := strings.TrimSpace(r.(map[string]interface{})["output"].(string)); got != "-f /stacks/web/compose.yaml -p shared-infra up -d" {
+		t.Errorf("up args = %q", got)
+	}
+
+	out, err := client.ComposeConfig(ctx, "/stacks/web/compose.yaml", "web")
+	if err != nil {
+		t.Fatalf("ComposeConfig() error = %v", err)
+	}
+	if got := strings.TrimSpace(out); got != "-f /stacks/web/compose.yaml -p shared-infra config --format json" {
+		t.Errorf("config args = %q", got)
+	}
+}
+
+func TestComposeLogsArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     ComposeLogsOptions
+		expected []string
+	}{
+		{
+			name:     "defaults",
+			opts:     ComposeLogsOptions{},
+			expected: []string{"-f", "/stacks/web/docker-compose.yml", "-p", "web", "logs"},
+		},
+		{
+			name:     "tail and since",
+			opts:     ComposeLogsOptions{Tail: 50, Since: "10m"},
+			expected: []string{"-f", "/stacks/web/docker-compose.yml", "-p", "web", "logs", "--tail", "50", "--since", "10m"},
+		},
+		{
+			name:     "since and until window",
+			opts:     ComposeLogsOptions{Since: "2024-01-02T15:04:05Z", Until: "5m"},
+			expected: []string{"-f", "/stacks/web/docker-compose.yml", "-p", "web", "logs", "--since", "2024-01-02T15:04:05Z", "--until", "5m"},
+		},
+		{
+			name:     "timestamps for one service",
+			opts:     ComposeLogsOptions{Service: "api", Timestamps: true},
+			expected: []string{"-f", "/stacks/web/docker-compose.yml", "-p", "web", "logs", "--timestamps", "api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := composeLogsArgs("/stacks/web/docker-compose.yml", "web", nil, tt.opts)
+			if strings.Join(args, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("Expected %v, got %v", tt.expected, args)
+			}
+		})
+	}
+}
+
+func TestValidateContainerName(t *testing.T) {
+	valid := []string{"web", "web-1", "my_app.v2", "A1"}
+	for _, name := range valid {
+		if err := ValidateContainerName(name); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", name, err)
+		}
+	}
+
+	invalid := []string{"", "a", "-web", ".hidden", "web app", "web/1", "web:1", "ünicode"}
+	for _, name := range invalid {
+		if err := ValidateContainerName(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestParseManifestDigest(t *testing.T) {
+	output := `{"mediaType":"application/vnd.oci.image.index.v1+json","digest":"sha256:abc123","size":10229}` + "\n"
+
+	digest, err := parseManifestDigest(output)
+	if err != nil {
+		t.Fatalf("parseManifestDigest() error = %v", err)
+	}
+	if digest != "sha256:abc123" {
+		t.Errorf("parseManifestDigest() = %q, want sha256:abc123", digest)
+	}
+
+	for _, bad := range []string{"", "not json", `{"mediaType":"x"}`} {
+		if _, err := parseManifestDigest(bad); err == nil {
+			t.Errorf("parseManifestDigest(%q) expected error", bad)
+		}
+	}
+}
+
+func TestParseImageHistory(t *testing.T) {
+	output := `{"Comment":"","CreatedAt":"2024-05-01T10:00:00Z","CreatedBy":"CMD [\"nginx\" \"-g\" \"daemon off;\"]","CreatedSince":"5 months ago","ID":"sha256:abc","Size":"0","Tags":"nginx:latest"}
+{"Comment":"buildkit.dockerfile.v0","CreatedAt":"2024-05-01T09:59:00Z","CreatedBy":"RUN /bin/sh -c apt-get update","CreatedSince":"5 months ago","ID":"<missing>","Size":"52428800","Tags":"<none>"}
+{"Comment":"","CreatedAt":"2024-04-30T00:00:00Z","CreatedBy":"ADD rootfs.tar.xz /","CreatedSince":"5 months ago","ID":"<missing>","Size":"74.8MB","Tags":"<none>"}
+`
+
+	layers, err := parseImageHistory(output)
+	if err != nil {
+		t.Fatalf("parseImageHistory() error = %v", err)
+	}
+	if len(layers) != 3 {
+		t.Fatalf("expected 3 layers, got %d", len(layers))
+	}
+
+	if layers[0]["id"] != "sha256:abc" || layers[0]["size"] != int64(0) {
+		t.Errorf("unexpected first layer: %v", layers[0])
+	}
+	if layers[1]["size"] != int64(52428800) {
+		t.Errorf("expected byte size 52428800, got %v", layers[1]["size"])
+	}
+	if layers[1]["comment"] != "buildkit.dockerfile.v0" || layers[1]["createdBy"] != "RUN /bin/sh -c apt-get update" {
+		t.Errorf("unexpected second layer: %v", layers[1])
+	}
+	if layers[2]["size"] != int64(74800000) {
+		t.Errorf("expected human size to be parsed, got %v", layers[2]["size"])
+	}
+
+	if _, err := parseImageHistory("not json"); err == nil {
+		t.Error("expected error for invalid output")
+	}
+}
+
+func TestParseContainerInspect(t *testing.T) {
+	withHealth := `{
+		"Id": "abc123",
+		"Name": "/web",
+		"Config": {"Env": ["PORT=80"]},
+		"Mounts": [{"Type": "bind", "Source": "/srv/web", "Destination": "/data"}],
+		"NetworkSettings": {"Networks": {"bridge": {"IPAddress": "172.17.0.2"}}},
+		"State": {
+			"Status": "running",
+			"Health": {
+				"Status": "unhealthy",
+				"FailingStreak": 3,
+				"Log": [
+					{"ExitCode": 0, "Output": "ok\n"},
+					{"ExitCode": 1, "Output": "connection refused\n"}
+				]
+			}
+		}
+	}`
+
+	details, err := parseContainerInspect(withHealth)
+	if err != nil {
+		t.Fatalf("parseContainerInspect() error = %v", err)
+	}
+
+	health := details["health"].(map[string]interface{})
+	if health["status"] != "unhealthy" {
+		t.Errorf("expected status unhealthy, got %v", health["status"])
+	}
+	if health["failingStreak"] != float64(3) {
+		t.Errorf("expected failingStreak 3, got %v", health["failingStreak"])
+	}
+	if health["lastExitCode"] != float64(1) || health["lastOutput"] != "connection refused" {
+		t.Errorf("unexpected last probe: %v", health)
+	}
+	if _, ok := details["Mounts"]; !ok {
+		t.Error("expected full inspect details to be preserved")
+	}
+
+	noHealth, err := parseContainerInspect(`{"Id": "def456", "State": {"Status": "running"}}`)
+	if err != nil {
+		t.Fatalf("parseContainerInspect() error = %v", err)
+	}
+	if status := noHealth["health"].(map[string]interface{})["status"]; status != "none" {
+		t.Errorf("expected status none without healthcheck, got %v", status)
+	}
+
+	if _, err := parseContainerInspect("not json"); err == nil {
+		t.Error("expected error for invalid output")
+	}
+}
+
+func TestStopArgs(t *testing.T) {
+	if got := stopArgs("web", nil); !slices.Equal(got, []string{"web"}) {
+		t.Errorf("stopArgs() without timeout = %v", got)
+	}
+
+	zero, thirty := 0, 30
+	if got := stopArgs("web", &zero); !slices.Equal(got, []string{"-t", "0", "web"}) {
+		t.Errorf("stopArgs() with zero timeout = %v", got)
+	}
+	if got := stopArgs("web", &thirty); !slices.Equal(got, []string{"-t", "30", "web"}) {
+		t.Errorf("stopArgs() with timeout = %v", got)
+	}
+
+	negative := -1
+	if _, err := NewClient().StopContainerWithTimeout(context.Background(), "web", &negative); err == nil {
+		t.Error("StopContainerWithTimeout() expected error for negative timeout")
+	}
+}
+
+func TestParseDockerInfo(t *testing.T) {
+	output := `{"ID":"abc","Containers":7,"ContainersRunning":4,"ContainersPaused":1,"ContainersStopped":2,` +
+		`"Images":12,"ServerVersion":"27.3.1","OperatingSystem":"Ubuntu 24.04.1 LTS","OSType":"linux",` +
+		`"Architecture":"x86_64","KernelVersion":"6.8.0-45-generic","NCPU":8,"MemTotal":16777216000}`
+
+	info, err := parseDockerInfo(output)
+	if err != nil {
+		t.Fatalf("parseDockerInfo() error = %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"version":            "27.3.1",
+		"containers":         7,
+		"containers_running": 4,
+		"images":             12,
+		"os_type":            "linux",
+		"memory_total":       int64(16777216000),
+	}
+	for key, want := range expected {
+		if info[key] != want {
+			t.Errorf("info[%q] = %v, want %v", key, info[key], want)
+		}
+	}
+
+	if _, err := parseDockerInfo("Cannot connect to the Docker daemon"); err == nil {
+		t.Error("parseDockerInfo() expected error for non-JSON output")
+	}
+}
+
+func TestParseSystemPruneOutput(t *testing.T) {
+	output := `Deleted Containers:
+4a7f7eebae0f
+2c6a4ad0b8c3
+
+Deleted Networks:
+old_default
+
+Deleted Volumes:
+3c1f0a9b2d8e
+
+Deleted Images:
+untagged: nginx:1.25
+deleted: sha256:abc123
+
+Deleted build cache objects:
+x1y2z3
+q9w8e7
+
+Total reclaimed space: 1.5GB
+`
+
+	deleted, reclaimed := parseSystemPruneOutput(output)
+	want := map[string][]string{
+		"containers": {"4a7f7eebae0f", "2c6a4ad0b8c3"},
+		"networks":   {"old_default"},
+		"volumes":    {"3c1f0a9b2d8e"},
+		"images":     {"sha256:abc123"},
+		"buildCache": {"x1y2z3", "q9w8e7"},
+	}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted = %v, want %v", deleted, want)
+	}
+	if reclaimed != 1500000000 {
+		t.Errorf("reclaimed = %d, want 1500000000", reclaimed)
+	}
+
+	deleted, reclaimed = parseSystemPruneOutput("Total reclaimed space: 0B")
+	if reclaimed != 0 || len(deleted["containers"]) != 0 || len(deleted) != 5 {
+		t.Errorf("empty prune = %v, %d", deleted, reclaimed)
+	}
+}
+
+func TestSystemPruneArgs(t *testing.T) {
+	tests := []struct {
+		all, volumes bool
+		want         []string
+	}{
+		{false, false, []string{"prune", "-f"}},
+		{true, false, []string{"prune", "-f", "-a"}},
+		{false, true, []string{"prune", "-f", "--volumes"}},
+		{true, true, []string{"prune", "-f", "-a", "--volumes"}},
+	}
+
+	for _, tt := range tests {
+		if got := systemPruneArgs(tt.all, tt.volumes); !slices.Equal(got, tt.want) {
+			t.Errorf("systemPruneArgs(%v, %v) = %v, want %v", tt.all, tt.volumes, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		output string
+		want   error
+	}{
+		{"Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?", errdefs.ErrDockerUnavailable},
+		{"error during connect: Get \"http://%2F%2F.%2Fpipe%2Fdocker_engine/v1.47/containers/json\"", errdefs.ErrDockerUnavailable},
+		{"Error response from daemon: No such container: web", errdefs.ErrNotFound},
+		{"Error response from daemon: No such image: nginx:nope", errdefs.ErrNotFound},
+		{"Error: No such object: web", errdefs.ErrNotFound},
+		{`Error response from daemon: Conflict. The container name "/web" is already in use by container "abc"`, errdefs.ErrConflict},
+		{"Error response from daemon: permission denied", nil},
+	}
+
+	for _, tt := range tests {
+		base := errors.New(tt.output)
+		err := classifyError(base, tt.output)
+		if !errors.Is(err, base) {
+			t.Errorf("classifyError(%q) lost the original error", tt.output)
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("classifyError(%q) = %v, want %v", tt.output, err, tt.want)
+		}
+		if tt.want == nil && errdefs.Code(err) != errdefs.CodeInternal {
+			t.Errorf("classifyError(%q) unexpectedly classified as %s", tt.output, errdefs.Code(err))
+		}
+	}
+}
+
+func TestParseHealthStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"Up 5 minutes (healthy)", "healthy"},
+		{"Up 2 minutes (unhealthy)", "unhealthy"},
+		{"Up 3 seconds (health: starting)", "starting"},
+		{"Up 10 minutes", "none"},
+		{"Exited (0) 2 hours ago", "none"},
+		{"Up 1 minute (Paused)", "none"},
+		{"", "none"},
+	}
+
+	for _, tt := range tests {
+		if got := parseHealthStatus(tt.status); got != tt.want {
+			t.Errorf("parseHealthStatus(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestParseComposePullOutput(t *testing.T) {
+	t.Run("compose v2", func(t *testing.T) {
+		output := ` db Pulling 
+ web Pulling 
+ worker Skipped - No image to be pulled 
+ a2abf6c4d29d Pulling fs layer 
+ a2abf6c4d29d Downloading [=====>      ]  10.5MB/31.4MB
+ c7a4e4382001 Pull complete 
+ db Pulled 
+ ✔ web Pulled                                                  3.2s 
+`
+		events := parseComposePullOutput(output)
+		expected := []PullEvent{
+			{Service: "db", Status: "pulled"},
+			{Service: "web", Status: "pulled", Detail: "3.2s"},
+			{Service: "worker", Status: "skipped", Detail: "No image to be pulled"},
+		}
+		if len(events) != len(expected) {
+			t.Fatalf("expected %d events, got %+v", len(expected), events)
+		}
+		for i, want := range expected {
+			if events[i] != want {
+				t.Errorf("event %d = %+v, want %+v", i, events[i], want)
+			}
+		}
+	})
+
+	t.Run("compose v2 error", func(t *testing.T) {
+		output := " api Pulling \n ✘ api Error pull access denied for private/api \n"
+		events := parseComposePullOutput(output)
+		if len(events) != 1 || events[0].Status != "error" || events[0].Detail != "pull access denied for private/api" {
+			t.Errorf("unexpected events: %+v", events)
+		}
+	})
+
+	t.Run("docker-compose v1", func(t *testing.T) {
+		output := "Pulling db  (postgres:16)...\nPulling web ... done\nPulling cache ... error\n"
+		events := parseComposePullOutput(output)
+		expected := []PullEvent{
+			{Service: "db", Status: "pulling", Detail: "postgres:16"},
+			{Service: "web", Status: "pulled"},
+			{Service: "cache", Status: "error"},
+		}
+		if len(events) != len(expected) {
+			t.Fatalf("expected %d events, got %+v", len(expected), events)
+		}
+		for i, want := range expected {
+			if events[i] != want {
+				t.Errorf("event %d = %+v, want %+v", i, events[i], want)
+			}
+		}
+	})
+}
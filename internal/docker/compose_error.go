@@ -0,0 +1,160 @@
+package docker
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// ComposeError is a failed compose command, with the failing service and a
+// one-line reason picked out of the CLI output so a UI can show "service web:
+// port 0.0.0.0:80 is already allocated" instead of the whole transcript
+type ComposeError struct {
+	Command   string `json:"command"`
+	Service   string `json:"service,omitempty"`
+	Reason    string `json:"reason"`
+	RawOutput string `json:"raw_output"`
+
+	// category is the errdefs sentinel the failure falls under, if any
+	category error
+}
+
+func (e *ComposeError) Error() string {
+	if e.Service != "" {
+		return fmt.Sprintf("docker-compose %s failed: service %s: %s", e.Command, e.Service, e.Reason)
+	}
+	return fmt.Sprintf("docker-compose %s failed: %s", e.Command, e.Reason)
+}
+
+func (e *ComposeError) Unwrap() error {
+	return e.category
+}
+
+// composeFailure recognises a known compose failure and phrases its reason
+type composeFailure struct {
+	pattern  *regexp.Regexp
+	category error
+	reason   func(match []string) string
+}
+
+var composeFailures = []composeFailure{
+	{
+		regexp.MustCompile(`Bind for (\S+) failed: port is already allocated`),
+		errdefs.ErrConflict,
+		func(m []string) string { return fmt.Sprintf("port %s is already allocated", m[1]) },
+	},
+	{
+		regexp.MustCompile(`listen \S+ (\S+): bind: address already in use`),
+		errdefs.ErrConflict,
+		func(m []string) string { return fmt.Sprintf("port %s is already in use", m[1]) },
+	},
+	{
+		regexp.MustCompile(`manifest for (\S+) not found`),
+		errdefs.ErrNotFound,
+		func(m []string) string { return fmt.Sprintf("image %s not found", m[1]) },
+	},
+	{
+		regexp.MustCompile(`pull access denied for ([^,\s]+)`),
+		errdefs.ErrNotFound,
+		func(m []string) string {
+			return fmt.Sprintf("image %s not found or requires registry login", m[1])
+		},
+	},
+	{
+		regexp.MustCompile(`env file (\S+) not found`),
+		errdefs.ErrInvalidInput,
+		func(m []string) string {
+			return fmt.Sprintf("env file %s referenced by the compose file does not exist", filepath.Base(m[1]))
+		},
+	},
+	{
+		regexp.MustCompile(`(yaml: .+)`),
+		errdefs.ErrInvalidInput,
+		func(m []string) string { return "invalid compose file: " + m[1] },
+	},
+	{
+		regexp.MustCompile(`services\.[\w.-]+ (.+)`),
+		errdefs.ErrInvalidInput,
+		func(m []string) string { return m[1] },
+	},
+}
+
+var (
+	// " ✘ web Error ..." from pulls, "Container shop-web-1  Error" from starts
+	composeServiceErrorPattern = regexp.MustCompile(`(?m)^\s*(?:\S\s+)?(?:Container\s+)?(\S+)\s+Error\b`)
+	composeEndpointPattern     = regexp.MustCompile(`on endpoint (\S+)`)
+	composeStartingPattern     = regexp.MustCompile(`Container (\S+)\s+Starting`)
+	composeValidationPattern   = regexp.MustCompile(`services\.([\w-]+)`)
+	containerReplicaPattern    = regexp.MustCompile(`[-_]\d+$`)
+)
+
+// newComposeError builds a ComposeError from the output of a failed compose
+// command. projectName maps container names back to their service.
+func newComposeError(command, projectName, output string) error {
+	output = strings.TrimSpace(output)
+	e := &ComposeError{Command: command, RawOutput: output}
+
+	for _, failure := range composeFailures {
+		if match := failure.pattern.FindStringSubmatch(output); match != nil {
+			e.Reason = failure.reason(match)
+			e.category = failure.category
+			break
+		}
+	}
+	if e.Reason == "" {
+		e.Reason = lastErrorLine(output)
+		e.category = errorCategory(output)
+	}
+
+	e.Service = composeFailedService(output, projectName)
+	return e
+}
+
+// composeFailedService finds which service a compose failure is about
+func composeFailedService(output, projectName string) string {
+	if match := composeValidationPattern.FindStringSubmatch(output); match != nil {
+		return match[1]
+	}
+	var container string
+	if matches := composeServiceErrorPattern.FindAllStringSubmatch(output, -1); matches != nil {
+		container = matches[len(matches)-1][1]
+	} else if match := composeEndpointPattern.FindStringSubmatch(output); match != nil {
+		container = match[1]
+	} else if matches := composeStartingPattern.FindAllStringSubmatch(output, -1); matches != nil {
+		container = matches[len(matches)-1][1]
+	}
+	return serviceFromContainer(container, projectName)
+}
+
+// serviceFromContainer turns "shop-web-1" back into "web". Names that carry
+// no project prefix, such as the service names in pull progress, pass through.
+func serviceFromContainer(container, projectName string) string {
+	if projectName == "" {
+		return container
+	}
+	for _, sep := range []string{"-", "_"} {
+		if rest, ok := strings.CutPrefix(container, projectName+sep); ok {
+			return containerReplicaPattern.ReplaceAllString(rest, "")
+		}
+	}
+	return container
+}
+
+// lastErrorLine is the last non-empty output line without the daemon's
+// "Error response from daemon:" boilerplate
+func lastErrorLine(output string) string {
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		line = strings.TrimPrefix(line, "Error response from daemon: ")
+		line = strings.TrimPrefix(line, "Error: ")
+		return line
+	}
+	return "no output"
+}
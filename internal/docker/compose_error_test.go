@@ -0,0 +1,143 @@
+package docker
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+func TestNewComposeError(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		output   string
+		service  string
+		reason   string
+		category error
+	}{
+		{
+			name:    "port conflict",
+			command: "up",
+			output: ` Network shop_default  Creating
+ Network shop_default  Created
+ Container shop-db-1  Running
+ Container shop-web-1  Creating
+ Container shop-web-1  Created
+ Container shop-web-1  Starting
+Error response from daemon: driver failed programming external connectivity on endpoint shop-web-1 (5d1e0c3b9a): Bind for 0.0.0.0:80 failed: port is already allocated
+`,
+			service:  "web",
+			reason:   "port 0.0.0.0:80 is already allocated",
+			category: errdefs.ErrConflict,
+		},
+		{
+			name:    "image not found",
+			command: "up",
+			output: ` web Pulling
+ db Pulled
+ web Error manifest for nginx:nope not found: manifest unknown: manifest unknown
+Error response from daemon: manifest for nginx:nope not found: manifest unknown: manifest unknown
+`,
+			service:  "web",
+			reason:   "image nginx:nope not found",
+			category: errdefs.ErrNotFound,
+		},
+		{
+			name:     "private image",
+			command:  "pull",
+			output:   " ✘ api Error pull access denied for acme/api, repository does not exist or may require 'docker login'\n",
+			service:  "api",
+			reason:   "image acme/api not found or requires registry login",
+			category: errdefs.ErrNotFound,
+		},
+		{
+			name:     "bad yaml",
+			command:  "config",
+			output:   "yaml: line 3: mapping values are not allowed in this context\n",
+			reason:   "invalid compose file: yaml: line 3: mapping values are not allowed in this context",
+			category: errdefs.ErrInvalidInput,
+		},
+		{
+			name:     "schema violation",
+			command:  "up",
+			output:   "validating /stacks/shop/docker-compose.yml: services.web Additional property imagee is not allowed\n",
+			service:  "web",
+			reason:   "Additional property imagee is not allowed",
+			category: errdefs.ErrInvalidInput,
+		},
+		{
+			name:     "missing env file",
+			command:  "config",
+			output:   "env file /stacks/shop/secrets.env not found: stat /stacks/shop/secrets.env: no such file or directory\n",
+			reason:   "env file secrets.env referenced by the compose file does not exist",
+			category: errdefs.ErrInvalidInput,
+		},
+		{
+			name:     "daemon down",
+			command:  "ps",
+			output:   "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?\n",
+			reason:   "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?",
+			category: errdefs.ErrDockerUnavailable,
+		},
+		{
+			name:    "unrecognised failure",
+			command: "down",
+			output:  "something odd happened\nError: exit status 17\n",
+			reason:  "exit status 17",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newComposeError(tt.command, "shop", tt.output)
+
+			var composeErr *ComposeError
+			if !errors.As(err, &composeErr) {
+				t.Fatalf("Expected *ComposeError, got %T", err)
+			}
+			if composeErr.Service != tt.service {
+				t.Errorf("Service = %q, want %q", composeErr.Service, tt.service)
+			}
+			if composeErr.Reason != tt.reason {
+				t.Errorf("Reason = %q, want %q", composeErr.Reason, tt.reason)
+			}
+			if composeErr.RawOutput != strings.TrimSpace(tt.output) {
+				t.Errorf("RawOutput = %q", composeErr.RawOutput)
+			}
+			if tt.category != nil && !errors.Is(err, tt.category) {
+				t.Errorf("Expected %v in the chain, got %v", tt.category, err)
+			}
+			if tt.category == nil && errdefs.Code(err) != errdefs.CodeInternal {
+				t.Errorf("Expected internal code, got %s", errdefs.Code(err))
+			}
+			if strings.Contains(err.Error(), "\n") {
+				t.Errorf("Expected a one-line message, got %q", err.Error())
+			}
+		})
+	}
+
+	err := newComposeError("up", "shop", " Container shop-web-1  Starting\nError response from daemon: Bind for 0.0.0.0:80 failed: port is already allocated")
+	if want := "docker-compose up failed: service web: port 0.0.0.0:80 is already allocated"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestServiceFromContainer(t *testing.T) {
+	tests := []struct {
+		container, project, want string
+	}{
+		{"shop-web-1", "shop", "web"},
+		{"shop_worker_2", "shop", "worker"},
+		{"shop-api-gateway-1", "shop", "api-gateway"},
+		{"web", "shop", "web"},
+		{"other-web-1", "shop", "other-web-1"},
+		{"", "shop", ""},
+	}
+	for _, tt := range tests {
+		if got := serviceFromContainer(tt.container, tt.project); got != tt.want {
+			t.Errorf("serviceFromContainer(%q, %q) = %q, want %q", tt.container, tt.project, got, tt.want)
+		}
+	}
+}
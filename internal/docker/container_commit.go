@@ -0,0 +1,93 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+var (
+	// registryHostPattern and repositoryPathPattern follow the distribution
+	// reference grammar
+	registryHostPattern   = regexp.MustCompile(`^[a-zA-Z0-9](?:[a-zA-Z0-9.-]*[a-zA-Z0-9])?(?::[0-9]+)?$`)
+	repositoryPathPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagPattern            = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+	digestPattern         = regexp.MustCompile(`^[a-z0-9]+(?:[+._-][a-z0-9]+)*:[a-fA-F0-9]{32,}$`)
+)
+
+// ValidateImageReference checks a repository and tag against Docker's
+// reference format. Like docker, the first path component is only taken as a
+// registry host when it contains "." or ":" or is "localhost". An empty tag is
+// allowed and means "latest".
+func ValidateImageReference(repository, tag string) error {
+	path := repository
+	if host, rest, found := strings.Cut(repository, "/"); found && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		if !registryHostPattern.MatchString(host) {
+			return fmt.Errorf("%w: invalid registry host %q in repository %q", errdefs.ErrInvalidInput, host, repository)
+		}
+		path = rest
+	}
+	if len(repository) > 255 || !repositoryPathPattern.MatchString(path) {
+		return fmt.Errorf("%w: invalid repository %q: use lowercase path components, optionally prefixed by a registry host", errdefs.ErrInvalidInput, repository)
+	}
+	if tag != "" && !tagPattern.MatchString(tag) {
+		return fmt.Errorf("%w: invalid tag %q: up to 128 of [a-zA-Z0-9_.-], not starting with . or -", errdefs.ErrInvalidInput, tag)
+	}
+	return nil
+}
+
+// ValidateImage checks a full image reference such as
+// "ghcr.io/org/app:1.2@sha256:..." before it is handed to the docker CLI, so a
+// value like "--privileged" can never be parsed as a flag
+func ValidateImage(image string) error {
+	name, digest, hasDigest := strings.Cut(image, "@")
+	if hasDigest && !digestPattern.MatchString(digest) {
+		return fmt.Errorf("%w: invalid digest in image %q", errdefs.ErrInvalidInput, image)
+	}
+
+	repository, tag := name, ""
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		repository, tag = name[:i], name[i+1:]
+		if tag == "" {
+			return fmt.Errorf("%w: empty tag in image %q", errdefs.ErrInvalidInput, image)
+		}
+	}
+	return ValidateImageReference(repository, tag)
+}
+
+// CommitContainer snapshots a container's filesystem into a new image and
+// returns the image ID
+func (c *Client) CommitContainer(ctx context.Context, containerID, repository, tag, message string) (string, error) {
+	args, err := commitArgs(containerID, repository, tag, message)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := c.ExecuteCommand(ctx, "commit", args)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+func commitArgs(containerID, repository, tag, message string) ([]string, error) {
+	if containerID == "" {
+		return nil, fmt.Errorf("%w: container_id is required", errdefs.ErrInvalidInput)
+	}
+	if err := ValidateImageReference(repository, tag); err != nil {
+		return nil, err
+	}
+
+	args := []string{}
+	if message != "" {
+		args = append(args, "--message", message)
+	}
+	ref := repository
+	if tag != "" {
+		ref += ":" + tag
+	}
+	return append(args, containerID, ref), nil
+}
@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+func TestCommitArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		container  string
+		repository string
+		tag        string
+		message    string
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:       "repository and tag",
+			container:  "web",
+			repository: "snapshots/web",
+			tag:        "2026-10-16",
+			want:       []string{"web", "snapshots/web:2026-10-16"},
+		},
+		{
+			name:       "message and registry with port",
+			container:  "abc123",
+			repository: "registry.local:5000/team/web",
+			tag:        "v1.2",
+			message:    "before upgrade",
+			want:       []string{"--message", "before upgrade", "abc123", "registry.local:5000/team/web:v1.2"},
+		},
+		{
+			name:       "no tag",
+			container:  "web",
+			repository: "web-snapshot",
+			want:       []string{"web", "web-snapshot"},
+		},
+		{name: "missing container", repository: "web", wantErr: true},
+		{name: "uppercase repository", container: "web", repository: "Snapshots/web", wantErr: true},
+		{name: "repository with spaces", container: "web", repository: "my web", wantErr: true},
+		{name: "repository with tag", container: "web", repository: "web:1.0", wantErr: true},
+		{name: "trailing separator", container: "web", repository: "web-", wantErr: true},
+		{name: "tag starting with dot", container: "web", repository: "web", tag: ".hidden", wantErr: true},
+		{name: "tag with slash", container: "web", repository: "web", tag: "a/b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := commitArgs(tt.container, tt.repository, tt.tag, tt.message)
+			if tt.wantErr {
+				if !errors.Is(err, errdefs.ErrInvalidInput) {
+					t.Errorf("Expected invalid input, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("commitArgs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commitArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateImage(t *testing.T) {
+	tests := []struct {
+		image   string
+		wantErr bool
+	}{
+		{"alpine", false},
+		{"alpine:3.20", false},
+		{"ghcr.io/org/app:1.2", false},
+		{"registry.local:5000/team/web", false},
+		{"nginx@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", false},
+		{"--volume=/:/host", true},
+		{"-v", true},
+		{"--privileged", true},
+		{"alpine:", true},
+		{"Alpine", true},
+		{"nginx@sha256:xyz", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			err := ValidateImage(tt.image)
+			if tt.wantErr && !errors.Is(err, errdefs.ErrInvalidInput) {
+				t.Errorf("ValidateImage(%q) = %v, expected invalid input", tt.image, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("ValidateImage(%q) unexpected error: %v", tt.image, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// ContainerChange is one entry of `docker diff`: Kind is "A" (added),
+// "C" (changed) or "D" (deleted)
+type ContainerChange struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+}
+
+// ContainerDiff lists the paths changed in a container's filesystem since it was created
+func (c *Client) ContainerDiff(ctx context.Context, containerID string) ([]ContainerChange, error) {
+	if containerID == "" {
+		return nil, fmt.Errorf("container ID is required")
+	}
+
+	output, err := c.ExecuteCommand(ctx, "container", []string{"diff", containerID})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseContainerDiff(output)
+}
+
+// ContainerExport streams the container's filesystem as a tar archive. The
+// caller must close the reader; Close reports whether the export succeeded.
+func (c *Client) ContainerExport(ctx context.Context, containerID string) (io.ReadCloser, error) {
+	if containerID == "" {
+		return nil, fmt.Errorf("container ID is required")
+	}
+
+	return startStreamingCommand(c.commandContext(ctx, "container", "export", containerID))
+}
+
+// parseContainerDiff parses `docker diff` lines such as "A /etc/app.conf"
+func parseContainerDiff(output string) ([]ContainerChange, error) {
+	changes := []ContainerChange{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		kind, path, ok := strings.Cut(line, " ")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("unexpected docker diff line: %q", line)
+		}
+		switch kind {
+		case "A", "C", "D":
+		default:
+			return nil, fmt.Errorf("unknown docker diff change kind %q", kind)
+		}
+
+		changes = append(changes, ContainerChange{Kind: kind, Path: path})
+	}
+	return changes, nil
+}
+
+// CopyToContainer extracts a tar archive into destPath inside a container,
+// like `docker cp - <container>:<destPath>`. A missing destination directory
+// or container is reported as os.ErrNotExist.
+func (c *Client) CopyToContainer(ctx context.Context, containerID string, src io.Reader, destPath string) (interface{}, error) {
+	target, err := containerPathArg(containerID, destPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := c.commandContext(ctx, "cp", "-", target)
+	cmd.Stdin = src
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, copyError(strings.TrimSpace(string(output)))
+	}
+
+	return map[string]interface{}{
+		"container_id": containerID,
+		"path":         path.Clean(destPath),
+		"status":       "copied",
+	}, nil
+}
+
+// CopyFromContainer streams srcPath from a container as a tar archive, like
+// `docker cp <container>:<srcPath> -`. The caller must close the reader; Close
+// reports os.ErrNotExist when the source path or container does not exist.
+func (c *Client) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, error) {
+	source, err := containerPathArg(containerID, srcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := startStreamingCommand(c.commandContext(ctx, "cp", source, "-"))
+	if err != nil {
+		return nil, err
+	}
+	return &copyReader{ReadCloser: reader}, nil
+}
+
+// containerPathArg builds the "<container>:<path>" argument for docker cp
+func containerPathArg(containerID, containerPath string) (string, error) {
+	if containerID == "" || strings.ContainsAny(containerID, ":/") || strings.HasPrefix(containerID, "-") {
+		return "", fmt.Errorf("invalid container ID %q", containerID)
+	}
+	if !strings.HasPrefix(containerPath, "/") || strings.ContainsRune(containerPath, 0) {
+		return "", fmt.Errorf("container path must be absolute, got %q", containerPath)
+	}
+	return containerID + ":" + path.Clean(containerPath), nil
+}
+
+// copyError maps docker cp failures about missing paths or containers to os.ErrNotExist
+func copyError(message string) error {
+	lower := strings.ToLower(message)
+	if strings.Contains(lower, "could not find the file") || strings.Contains(lower, "no such container") {
+		return fmt.Errorf("%w: %s", os.ErrNotExist, message)
+	}
+	return classifyError(fmt.Errorf("docker cp failed: %s", message), message)
+}
+
+// copyReader translates the streaming command's Close error with copyError
+type copyReader struct {
+	io.ReadCloser
+}
+
+func (r *copyReader) Close() error {
+	if err := r.ReadCloser.Close(); err != nil {
+		return copyError(err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package docker
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseContainerDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    []ContainerChange
+		wantErr bool
+	}{
+		{
+			name:   "empty",
+			output: "",
+			want:   []ContainerChange{},
+		},
+		{
+			name:   "mixed changes",
+			output: "C /etc\nA /etc/app.conf\nD /tmp/cache\n",
+			want: []ContainerChange{
+				{Kind: "C", Path: "/etc"},
+				{Kind: "A", Path: "/etc/app.conf"},
+				{Kind: "D", Path: "/tmp/cache"},
+			},
+		},
+		{
+			name:   "path with spaces and CRLF",
+			output: "A /data/my file.txt\r\n",
+			want:   []ContainerChange{{Kind: "A", Path: "/data/my file.txt"}},
+		},
+		{
+			name:    "unknown kind",
+			output:  "X /etc",
+			wantErr: true,
+		},
+		{
+			name:    "missing path",
+			output:  "A",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseContainerDiff(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseContainerDiff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseContainerDiff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainerPathArg(t *testing.T) {
+	tests := []struct {
+		name        string
+		containerID string
+		path        string
+		want        string
+		wantErr     bool
+	}{
+		{name: "file", containerID: "abc123", path: "/etc/nginx/nginx.conf", want: "abc123:/etc/nginx/nginx.conf"},
+		{name: "cleaned", containerID: "web", path: "/var/log/../lib/", want: "web:/var/lib"},
+		{name: "root", containerID: "web", path: "/", want: "web:/"},
+		{name: "relative path", containerID: "web", path: "etc/hosts", wantErr: true},
+		{name: "empty path", containerID: "web", path: "", wantErr: true},
+		{name: "empty container", containerID: "", path: "/etc", wantErr: true},
+		{name: "container with colon", containerID: "web:/etc", path: "/etc", wantErr: true},
+		{name: "container flag", containerID: "-L", path: "/etc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := containerPathArg(tt.containerID, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("containerPathArg() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("containerPathArg() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyError(t *testing.T) {
+	tests := []struct {
+		message  string
+		notExist bool
+	}{
+		{"Error response from daemon: Could not find the file /nope in container web", true},
+		{"Error response from daemon: No such container: web", true},
+		{"Error: No such container:path: web:/nope", true},
+		{"Error response from daemon: permission denied", false},
+	}
+
+	for _, tt := range tests {
+		if err := copyError(tt.message); errors.Is(err, os.ErrNotExist) != tt.notExist {
+			t.Errorf("copyError(%q) = %v, want not-exist %v", tt.message, err, tt.notExist)
+		}
+	}
+}
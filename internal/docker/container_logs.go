@@ -0,0 +1,77 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ContainerLogsOptions bounds a full container log export
+type ContainerLogsOptions struct {
+	Since      string // timestamp or relative duration such as "10m"
+	Until      string
+	Timestamps bool
+}
+
+// StreamContainerLogs streams a container's complete logs, stdout and stderr
+// interleaved as docker writes them. The caller must close the reader.
+func (c *Client) StreamContainerLogs(ctx context.Context, containerID string, opts ContainerLogsOptions) (io.ReadCloser, error) {
+	if containerID == "" {
+		return nil, fmt.Errorf("container ID is required")
+	}
+
+	cmd := c.commandContext(ctx, containerLogsArgs(containerID, opts)...)
+
+	// Containers log to both streams, so both go into the same pipe
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	if err := cmd.Start(); err != nil {
+		reader.Close()
+		writer.Close()
+		return nil, fmt.Errorf("failed to start %s: %w", cmd.Path, err)
+	}
+	writer.Close()
+
+	// Errors from the CLI itself end up in the stream, so stderr stays empty
+	return &commandReader{ReadCloser: reader, cmd: cmd, stderr: &bytes.Buffer{}}, nil
+}
+
+func containerLogsArgs(containerID string, opts ContainerLogsOptions) []string {
+	args := []string{"logs"}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	if opts.Until != "" {
+		args = append(args, "--until", opts.Until)
+	}
+	if opts.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	return append(args, containerID)
+}
+
+// ContainerName returns a container's name without the leading slash
+func (c *Client) ContainerName(ctx context.Context, containerID string) (string, error) {
+	output, err := c.ExecuteCommand(ctx, "inspect", []string{"--type", "container", "--format", "{{.Name}}", containerID})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(strings.TrimSpace(output), "/"), nil
+}
+
+// ContainerLogFileName derives a download file name such as "web-1.log"
+func ContainerLogFileName(name string) string {
+	name = strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "._")
+	if name == "" {
+		name = "container"
+	}
+	return name + ".log"
+}
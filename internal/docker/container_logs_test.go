@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestContainerLogsArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ContainerLogsOptions
+		want []string
+	}{
+		{"full logs", ContainerLogsOptions{}, []string{"logs", "web"}},
+		{"since", ContainerLogsOptions{Since: "2h"}, []string{"logs", "--since", "2h", "web"}},
+		{
+			"window with timestamps",
+			ContainerLogsOptions{Since: "2024-05-01T00:00:00Z", Until: "2024-05-02T00:00:00Z", Timestamps: true},
+			[]string{"logs", "--since", "2024-05-01T00:00:00Z", "--until", "2024-05-02T00:00:00Z", "--timestamps", "web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containerLogsArgs("web", tt.opts); !slices.Equal(got, tt.want) {
+				t.Errorf("containerLogsArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainerLogFileName(t *testing.T) {
+	tests := map[string]string{
+		"web-1":       "web-1.log",
+		"my_app.v2":   "my_app.v2.log",
+		"stack/web 1": "stack_web_1.log",
+		"":            "container.log",
+	}
+
+	for name, want := range tests {
+		if got := ContainerLogFileName(name); got != want {
+			t.Errorf("ContainerLogFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// ContainerProcesses is the process table `docker top` reports for a container
+type ContainerProcesses struct {
+	Titles    []string   `json:"titles"`
+	Processes [][]string `json:"processes"`
+}
+
+// DefaultTopArgs returns the ps options used when none are given. Windows
+// containers do not accept ps options at all.
+func DefaultTopArgs() string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	return "-ef"
+}
+
+// ContainerTop lists the processes running in a container. psArgs are passed
+// to ps, e.g. "aux"; DefaultTopArgs is used when empty.
+func (c *Client) ContainerTop(ctx context.Context, containerID, psArgs string) (*ContainerProcesses, error) {
+	if containerID == "" {
+		return nil, fmt.Errorf("container ID is required")
+	}
+	if psArgs == "" {
+		psArgs = DefaultTopArgs()
+	}
+
+	output, err := c.ExecuteCommand(ctx, "top", append([]string{containerID}, strings.Fields(psArgs)...))
+	if err != nil {
+		return nil, err
+	}
+	return parseContainerTop(output)
+}
+
+// parseContainerTop splits `docker top` output into titles and rows. Only the
+// last column, the command line, may contain spaces.
+func parseContainerTop(output string) (*ContainerProcesses, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	titles := strings.Fields(lines[0])
+	if len(titles) == 0 {
+		return nil, fmt.Errorf("unexpected docker top output: %q", output)
+	}
+
+	processes := [][]string{}
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		processes = append(processes, splitTopRow(line, len(titles)))
+	}
+	return &ContainerProcesses{Titles: titles, Processes: processes}, nil
+}
+
+// splitTopRow splits a row into n columns, leaving the rest of the line,
+// spaces included, in the last one
+func splitTopRow(line string, n int) []string {
+	row := make([]string, 0, n)
+	rest := strings.TrimSpace(line)
+	for len(row) < n-1 && rest != "" {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		row = append(row, rest[:end])
+		rest = strings.TrimLeft(rest[end:], " \t")
+	}
+	row = append(row, rest)
+	for len(row) < n {
+		row = append(row, "")
+	}
+	return row
+}
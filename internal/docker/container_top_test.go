@@ -0,0 +1,42 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseContainerTop(t *testing.T) {
+	output := `UID                 PID                 PPID                C                   STIME               TTY                 TIME                CMD
+root                4021                4000                0                   09:12               ?                   00:00:00            nginx: master process nginx -g daemon off;
+101                 4077                4021                0                   09:12               ?                   00:00:01            nginx: worker process
+`
+	top, err := parseContainerTop(output)
+	if err != nil {
+		t.Fatalf("parseContainerTop() error = %v", err)
+	}
+
+	wantTitles := []string{"UID", "PID", "PPID", "C", "STIME", "TTY", "TIME", "CMD"}
+	if !reflect.DeepEqual(top.Titles, wantTitles) {
+		t.Errorf("Titles = %v, want %v", top.Titles, wantTitles)
+	}
+	wantProcesses := [][]string{
+		{"root", "4021", "4000", "0", "09:12", "?", "00:00:00", "nginx: master process nginx -g daemon off;"},
+		{"101", "4077", "4021", "0", "09:12", "?", "00:00:01", "nginx: worker process"},
+	}
+	if !reflect.DeepEqual(top.Processes, wantProcesses) {
+		t.Errorf("Processes = %q, want %q", top.Processes, wantProcesses)
+	}
+
+	// Rows with fewer fields than titles are padded
+	top, err = parseContainerTop("PID   USER   COMMAND\n1     root\n")
+	if err != nil {
+		t.Fatalf("parseContainerTop() error = %v", err)
+	}
+	if !reflect.DeepEqual(top.Processes, [][]string{{"1", "root", ""}}) {
+		t.Errorf("Processes = %q", top.Processes)
+	}
+
+	if _, err := parseContainerTop(""); err == nil {
+		t.Error("Expected error for empty output")
+	}
+}
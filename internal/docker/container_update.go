@@ -0,0 +1,93 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	memoryLimitPattern   = regexp.MustCompile(`^[0-9]+[bkmgBKMG]?$`)
+	restartPolicyPattern = regexp.MustCompile(`^(no|always|unless-stopped|on-failure(:[0-9]+)?)$`)
+)
+
+// ResourceLimits are the `docker update` settings that can change on a
+// running container. Zero values leave the current setting untouched.
+type ResourceLimits struct {
+	Memory     string  `json:"memory,omitempty"`      // e.g. "512m", "2g"
+	MemorySwap string  `json:"memory_swap,omitempty"` // "-1" for unlimited swap
+	CPUs       float64 `json:"cpus,omitempty"`
+	CPUShares  int     `json:"cpu_shares,omitempty"`
+	PidsLimit  int     `json:"pids_limit,omitempty"`
+	Restart    string  `json:"restart,omitempty"`
+}
+
+// Validate rejects values docker update would refuse or misinterpret
+func (r ResourceLimits) Validate() error {
+	if r.Memory != "" && !memoryLimitPattern.MatchString(r.Memory) {
+		return fmt.Errorf("invalid memory limit %q", r.Memory)
+	}
+	if r.MemorySwap != "" && r.MemorySwap != "-1" && !memoryLimitPattern.MatchString(r.MemorySwap) {
+		return fmt.Errorf("invalid memory swap limit %q", r.MemorySwap)
+	}
+	if r.CPUs < 0 {
+		return fmt.Errorf("cpus must not be negative")
+	}
+	if r.CPUShares < 0 {
+		return fmt.Errorf("cpu_shares must not be negative")
+	}
+	if r.PidsLimit < -1 {
+		return fmt.Errorf("pids_limit must be -1 (unlimited) or positive")
+	}
+	if r.Restart != "" && !restartPolicyPattern.MatchString(r.Restart) {
+		return fmt.Errorf("invalid restart policy %q", r.Restart)
+	}
+	if r.updateArgs() == nil {
+		return fmt.Errorf("no resource limits to update")
+	}
+	return nil
+}
+
+func (r ResourceLimits) updateArgs() []string {
+	var args []string
+	if r.Memory != "" {
+		args = append(args, "--memory", r.Memory)
+	}
+	if r.MemorySwap != "" {
+		args = append(args, "--memory-swap", r.MemorySwap)
+	}
+	if r.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(r.CPUs, 'f', -1, 64))
+	}
+	if r.CPUShares > 0 {
+		args = append(args, "--cpu-shares", strconv.Itoa(r.CPUShares))
+	}
+	if r.PidsLimit != 0 {
+		args = append(args, "--pids-limit", strconv.Itoa(r.PidsLimit))
+	}
+	if r.Restart != "" {
+		args = append(args, "--restart", r.Restart)
+	}
+	return args
+}
+
+// UpdateContainer changes resource limits of a container without recreating it
+func (c *Client) UpdateContainer(ctx context.Context, containerID string, resources ResourceLimits) (interface{}, error) {
+	if err := resources.Validate(); err != nil {
+		return nil, err
+	}
+
+	args := append(resources.updateArgs(), containerID)
+	output, err := c.ExecuteCommand(ctx, "update", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"container_id": containerID,
+		"status":       "updated",
+		"output":       strings.TrimSpace(output),
+	}, nil
+}
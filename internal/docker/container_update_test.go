@@ -0,0 +1,55 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResourceLimitsUpdateArgs(t *testing.T) {
+	limits := ResourceLimits{
+		Memory:     "512m",
+		MemorySwap: "-1",
+		CPUs:       1.5,
+		CPUShares:  512,
+		PidsLimit:  100,
+		Restart:    "on-failure:3",
+	}
+
+	expected := "--memory 512m --memory-swap -1 --cpus 1.5 --cpu-shares 512 --pids-limit 100 --restart on-failure:3"
+	if got := strings.Join(limits.updateArgs(), " "); got != expected {
+		t.Errorf("updateArgs() = %q, want %q", got, expected)
+	}
+
+	if got := strings.Join(ResourceLimits{CPUs: 2}.updateArgs(), " "); got != "--cpus 2" {
+		t.Errorf("updateArgs() = %q, want only --cpus 2", got)
+	}
+}
+
+func TestResourceLimitsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  ResourceLimits
+		wantErr bool
+	}{
+		{"memory", ResourceLimits{Memory: "2g"}, false},
+		{"plain bytes", ResourceLimits{Memory: "1048576"}, false},
+		{"restart policy", ResourceLimits{Restart: "unless-stopped"}, false},
+		{"unlimited pids", ResourceLimits{PidsLimit: -1}, false},
+		{"empty", ResourceLimits{}, true},
+		{"bad memory", ResourceLimits{Memory: "lots"}, true},
+		{"fractional memory", ResourceLimits{Memory: "1.5g"}, true},
+		{"bad swap", ResourceLimits{MemorySwap: "-2"}, true},
+		{"negative cpus", ResourceLimits{CPUs: -1}, true},
+		{"negative shares", ResourceLimits{CPUShares: -5}, true},
+		{"bad pids", ResourceLimits{PidsLimit: -3}, true},
+		{"bad restart", ResourceLimits{Restart: "sometimes"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.limits.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+)
+
+// DockerEvent is a single entry from `docker events --format '{{json .}}'`
+type DockerEvent struct {
+	Type     string     `json:"Type"`
+	Action   string     `json:"Action"`
+	Actor    EventActor `json:"Actor"`
+	Scope    string     `json:"scope,omitempty"`
+	Time     int64      `json:"time"`
+	TimeNano int64      `json:"timeNano,omitempty"`
+}
+
+// EventActor identifies the object an event refers to
+type EventActor struct {
+	ID         string            `json:"ID"`
+	Attributes map[string]string `json:"Attributes,omitempty"`
+}
+
+// StreamEvents runs `docker events` and forwards each event to ch until ctx is
+// cancelled, which also terminates the subprocess. Filters use the same
+// key/value form as `docker events --filter`, e.g. {"type": {"container"}}.
+func (c *Client) StreamEvents(ctx context.Context, filters map[string][]string, ch chan<- DockerEvent) error {
+	args := append([]string{"events", "--format", "{{json .}}"}, buildFilterArgs(filters)...)
+	return streamEvents(ctx, c.commandContext(ctx, args...), ch)
+}
+
+// streamEvents decodes JSON lines from cmd's stdout into ch
+func streamEvents(ctx context.Context, cmd *exec.Cmd, ch chan<- DockerEvent) error {
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start docker events: %w", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var event DockerEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			slog.Debug("Skipping unparseable docker event", "line", line, "error", err)
+			continue
+		}
+
+		select {
+		case ch <- event:
+		case <-ctx.Done():
+			cmd.Wait()
+			return nil
+		}
+	}
+
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		// Cancellation kills the subprocess; that is a normal shutdown
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("docker events failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
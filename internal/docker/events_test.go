@@ -0,0 +1,74 @@
+package docker
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestStreamEvents(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	t.Run("decodes events from producer", func(t *testing.T) {
+		script := `echo '{"Type":"container","Action":"start","Actor":{"ID":"abc","Attributes":{"name":"web"}},"scope":"local","time":1700000000}'
+echo 'garbage'
+echo '{"Type":"network","Action":"connect","Actor":{"ID":"net1"},"time":1700000001}'`
+
+		ctx := context.Background()
+		ch := make(chan DockerEvent, 10)
+		if err := streamEvents(ctx, exec.CommandContext(ctx, "sh", "-c", script), ch); err != nil {
+			t.Fatalf("streamEvents() error = %v", err)
+		}
+		close(ch)
+
+		var events []DockerEvent
+		for event := range ch {
+			events = append(events, event)
+		}
+
+		if len(events) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(events))
+		}
+		if events[0].Type != "container" || events[0].Action != "start" || events[0].Actor.Attributes["name"] != "web" {
+			t.Errorf("unexpected first event: %+v", events[0])
+		}
+		if events[1].Type != "network" || events[1].Time != 1700000001 {
+			t.Errorf("unexpected second event: %+v", events[1])
+		}
+	})
+
+	t.Run("cancellation terminates producer", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		script := `while true; do echo '{"Type":"container","Action":"exec_start","Actor":{"ID":"abc"}}'; sleep 0.01; done`
+
+		ch := make(chan DockerEvent)
+		done := make(chan error, 1)
+		go func() {
+			done <- streamEvents(ctx, exec.CommandContext(ctx, "sh", "-c", script), ch)
+		}()
+
+		<-ch
+		cancel()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("expected nil error on cancellation, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("streamEvents did not return after cancellation")
+		}
+	})
+
+	t.Run("reports producer failure", func(t *testing.T) {
+		ctx := context.Background()
+		ch := make(chan DockerEvent, 1)
+		err := streamEvents(ctx, exec.CommandContext(ctx, "sh", "-c", "echo 'Cannot connect' >&2; exit 1"), ch)
+		if err == nil {
+			t.Error("expected error when producer fails")
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// SaveImage streams `docker save` output for an image as a tar archive. The
+// caller must close the reader; Close reports whether the save succeeded.
+func (c *Client) SaveImage(ctx context.Context, ref string) (io.ReadCloser, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("image reference is required")
+	}
+
+	return startStreamingCommand(c.commandContext(ctx, "save", ref))
+}
+
+// LoadImage feeds a tar archive to `docker load` and returns the loaded image reference
+func (c *Client) LoadImage(ctx context.Context, r io.Reader) (string, error) {
+	cmd := c.commandContext(ctx, "load")
+	cmd.Stdin = r
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("docker load failed: %s", strings.TrimSpace(string(output)))
+	}
+
+	return parseLoadOutput(string(output))
+}
+
+// ImageArchiveName derives a tar filename from an image reference, e.g.
+// "ghcr.io/org/app:1.2" becomes "app_1.2.tar"
+func ImageArchiveName(ref string) string {
+	name := ref
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	name = strings.NewReplacer("@sha256:", "_", ":", "_", "@", "_").Replace(name)
+	name = strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "._")
+	if name == "" {
+		name = "image"
+	}
+	return name + ".tar"
+}
+
+// parseLoadOutput extracts the image reference from `docker load` output
+func parseLoadOutput(output string) (string, error) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"Loaded image:", "Loaded image ID:"} {
+			if strings.HasPrefix(line, prefix) {
+				return strings.TrimSpace(strings.TrimPrefix(line, prefix)), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unexpected docker load output: %s", strings.TrimSpace(output))
+}
+
+// commandReader exposes a running command's stdout and waits for it on Close
+type commandReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+func startStreamingCommand(cmd *exec.Cmd) (io.ReadCloser, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", cmd.Path, err)
+	}
+
+	return &commandReader{ReadCloser: stdout, cmd: cmd, stderr: &stderr}, nil
+}
+
+func (r *commandReader) Close() error {
+	// Drain any unread output so the command is not blocked on a full pipe
+	_, _ = io.Copy(io.Discard, r.ReadCloser)
+	if err := r.cmd.Wait(); err != nil {
+		return fmt.Errorf("%s failed: %s", strings.Join(r.cmd.Args, " "), strings.TrimSpace(r.stderr.String()))
+	}
+	return nil
+}
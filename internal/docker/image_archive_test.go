@@ -0,0 +1,94 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestImageArchiveName(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"nginx", "nginx.tar"},
+		{"nginx:latest", "nginx_latest.tar"},
+		{"ghcr.io/org/app:1.2", "app_1.2.tar"},
+		{"localhost:5000/app", "app.tar"},
+		{"nginx@sha256:abc123", "nginx_abc123.tar"},
+		{"sha256:deadbeef", "sha256_deadbeef.tar"},
+		{"", "image.tar"},
+	}
+
+	for _, tt := range tests {
+		if got := ImageArchiveName(tt.ref); got != tt.want {
+			t.Errorf("ImageArchiveName(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestParseLoadOutput(t *testing.T) {
+	tests := []struct {
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{"Loaded image: nginx:latest\n", "nginx:latest", false},
+		{"Loaded image ID: sha256:abc123\n", "sha256:abc123", false},
+		{"open /dev/stdin: no such file", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseLoadOutput(tt.output)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseLoadOutput(%q) error = %v, wantErr %v", tt.output, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("parseLoadOutput(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestStartStreamingCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	t.Run("streams stdout", func(t *testing.T) {
+		reader, err := startStreamingCommand(exec.CommandContext(context.Background(), "sh", "-c", "printf 'tar-bytes'"))
+		if err != nil {
+			t.Fatalf("startStreamingCommand() error = %v", err)
+		}
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("ReadAll() error = %v", err)
+		}
+		if string(data) != "tar-bytes" {
+			t.Errorf("got %q, want tar-bytes", data)
+		}
+		if err := reader.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+
+	t.Run("reports failure on close", func(t *testing.T) {
+		reader, err := startStreamingCommand(exec.CommandContext(context.Background(), "sh", "-c", "echo 'no such image' >&2; exit 1"))
+		if err != nil {
+			t.Fatalf("startStreamingCommand() error = %v", err)
+		}
+
+		err = reader.Close()
+		if err == nil || !strings.Contains(err.Error(), "no such image") {
+			t.Errorf("Close() error = %v, want stderr in error", err)
+		}
+	})
+}
+
+func TestSaveImageRequiresRef(t *testing.T) {
+	if _, err := NewClient().SaveImage(context.Background(), ""); err == nil {
+		t.Error("expected error for empty image reference")
+	}
+}
@@ -0,0 +1,204 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// Supported image scanners
+const (
+	ScannerTrivy = "trivy"
+	ScannerScout = "scout"
+)
+
+// ImageScanResult summarises the vulnerabilities a scanner found in an image
+type ImageScanResult struct {
+	Scanner         string          `json:"scanner"`
+	Image           string          `json:"image"`
+	SeverityCounts  map[string]int  `json:"severity_counts"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// Vulnerability is a single finding against an installed package
+type Vulnerability struct {
+	ID               string `json:"id"`
+	Severity         string `json:"severity"`
+	Package          string `json:"package"`
+	InstalledVersion string `json:"installed_version,omitempty"`
+	FixedVersion     string `json:"fixed_version,omitempty"`
+	Title            string `json:"title,omitempty"`
+}
+
+// severityLevels are the buckets every scanner's severities are folded into
+var severityLevels = []string{"critical", "high", "medium", "low", "unknown"}
+
+// ScanImage scans an image with the configured scanner, or with trivy and
+// then docker scout when set to auto. Hosts without a scanner get
+// ErrNotImplemented.
+func (c *Client) ScanImage(ctx context.Context, ref string) (*ImageScanResult, error) {
+	scanner, err := c.detectScanner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var cmd *exec.Cmd
+	if scanner == ScannerTrivy {
+		cmd = c.withEnv(exec.CommandContext(ctx, c.trivy, "image", "--format", "json", "--quiet", ref))
+	} else {
+		cmd = c.commandContext(ctx, "scout", "cves", "--format", "gitlab", ref)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, classifyError(fmt.Errorf("%s scan failed: %s", scanner, strings.TrimSpace(stderr.String())), stderr.String())
+	}
+
+	if scanner == ScannerTrivy {
+		return parseTrivyReport(ref, output)
+	}
+	return parseScoutReport(ref, output)
+}
+
+// detectScanner resolves the configured scanner to one present on the host
+func (c *Client) detectScanner(ctx context.Context) (string, error) {
+	switch c.scanner {
+	case "none":
+		return "", fmt.Errorf("%w: image scanning is disabled", errdefs.ErrNotImplemented)
+	case ScannerTrivy:
+		if c.hasTrivy() {
+			return ScannerTrivy, nil
+		}
+	case ScannerScout:
+		if c.hasScout(ctx) {
+			return ScannerScout, nil
+		}
+	default:
+		if c.hasTrivy() {
+			return ScannerTrivy, nil
+		}
+		if c.hasScout(ctx) {
+			return ScannerScout, nil
+		}
+	}
+	return "", fmt.Errorf("%w: no image scanner available (install trivy or docker scout)", errdefs.ErrNotImplemented)
+}
+
+func (c *Client) hasTrivy() bool {
+	_, err := exec.LookPath(c.trivy)
+	return err == nil
+}
+
+func (c *Client) hasScout(ctx context.Context) bool {
+	return c.commandContext(ctx, "scout", "version").Run() == nil
+}
+
+// parseTrivyReport reads `trivy image --format json` output
+func parseTrivyReport(ref string, data []byte) (*ImageScanResult, error) {
+	var report struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID  string
+				PkgName          string
+				InstalledVersion string
+				FixedVersion     string
+				Severity         string
+				Title            string
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse trivy report: %w", err)
+	}
+
+	result := newImageScanResult(ScannerTrivy, ref)
+	for _, target := range report.Results {
+		for _, v := range target.Vulnerabilities {
+			result.add(Vulnerability{
+				ID:               v.VulnerabilityID,
+				Severity:         v.Severity,
+				Package:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				Title:            v.Title,
+			})
+		}
+	}
+	return result, nil
+}
+
+// parseScoutReport reads `docker scout cves --format gitlab` output. The
+// CVE identifier is preferred over scout's own advisory id.
+func parseScoutReport(ref string, data []byte) (*ImageScanResult, error) {
+	var report struct {
+		Vulnerabilities []struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Severity    string `json:"severity"`
+			Location    struct {
+				Dependency struct {
+					Package struct {
+						Name string `json:"name"`
+					} `json:"package"`
+					Version string `json:"version"`
+				} `json:"dependency"`
+			} `json:"location"`
+			Identifiers []struct {
+				Type  string `json:"type"`
+				Value string `json:"value"`
+			} `json:"identifiers"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse docker scout report: %w", err)
+	}
+
+	result := newImageScanResult(ScannerScout, ref)
+	for _, v := range report.Vulnerabilities {
+		id := v.ID
+		for _, identifier := range v.Identifiers {
+			if strings.EqualFold(identifier.Type, "cve") {
+				id = identifier.Value
+				break
+			}
+		}
+		title := v.Name
+		if title == "" {
+			title = v.Description
+		}
+		result.add(Vulnerability{
+			ID:               id,
+			Severity:         v.Severity,
+			Package:          v.Location.Dependency.Package.Name,
+			InstalledVersion: v.Location.Dependency.Version,
+			Title:            title,
+		})
+	}
+	return result, nil
+}
+
+func newImageScanResult(scanner, ref string) *ImageScanResult {
+	counts := make(map[string]int, len(severityLevels))
+	for _, level := range severityLevels {
+		counts[level] = 0
+	}
+	return &ImageScanResult{Scanner: scanner, Image: ref, SeverityCounts: counts, Vulnerabilities: []Vulnerability{}}
+}
+
+// add records a finding, folding its severity into one of severityLevels
+func (r *ImageScanResult) add(v Vulnerability) {
+	v.Severity = strings.ToLower(v.Severity)
+	if _, ok := r.SeverityCounts[v.Severity]; !ok {
+		v.Severity = "unknown"
+	}
+	r.SeverityCounts[v.Severity]++
+	r.Vulnerabilities = append(r.Vulnerabilities, v)
+}
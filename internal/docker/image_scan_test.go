@@ -0,0 +1,111 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+const sampleTrivyReport = `{
+  "SchemaVersion": 2,
+  "ArtifactName": "nginx:1.25",
+  "Results": [
+    {
+      "Target": "nginx:1.25 (debian 12.4)",
+      "Class": "os-pkgs",
+      "Vulnerabilities": [
+        {"VulnerabilityID": "CVE-2023-45853", "PkgName": "zlib1g", "InstalledVersion": "1:1.2.13.dfsg-1", "Severity": "CRITICAL", "Title": "zlib: integer overflow in MiniZip"},
+        {"VulnerabilityID": "CVE-2024-2511", "PkgName": "libssl3", "InstalledVersion": "3.0.11-1", "FixedVersion": "3.0.13-1", "Severity": "LOW", "Title": "openssl: unbounded memory growth"}
+      ]
+    },
+    {"Target": "usr/local/bin/app", "Class": "lang-pkgs"},
+    {
+      "Target": "app/package-lock.json",
+      "Class": "lang-pkgs",
+      "Vulnerabilities": [
+        {"VulnerabilityID": "CVE-2024-4068", "PkgName": "braces", "InstalledVersion": "3.0.2", "FixedVersion": "3.0.3", "Severity": "HIGH"}
+      ]
+    }
+  ]
+}`
+
+const sampleScoutReport = `{
+  "version": "15.0.6",
+  "vulnerabilities": [
+    {
+      "id": "GHSA-grv7-fg5c-xmjg",
+      "name": "Uncontrolled resource consumption in braces",
+      "severity": "High",
+      "location": {"dependency": {"package": {"name": "braces"}, "version": "3.0.2"}},
+      "identifiers": [{"type": "ghsa", "value": "GHSA-grv7-fg5c-xmjg"}, {"type": "cve", "value": "CVE-2024-4068"}]
+    },
+    {
+      "id": "CVE-2023-45853",
+      "description": "MiniZip integer overflow",
+      "severity": "Unspecified",
+      "location": {"dependency": {"package": {"name": "zlib"}, "version": "1:1.2.13.dfsg-1"}},
+      "identifiers": []
+    }
+  ]
+}`
+
+func TestParseTrivyReport(t *testing.T) {
+	result, err := parseTrivyReport("nginx:1.25", []byte(sampleTrivyReport))
+	if err != nil {
+		t.Fatalf("parseTrivyReport() error = %v", err)
+	}
+
+	wantCounts := map[string]int{"critical": 1, "high": 1, "medium": 0, "low": 1, "unknown": 0}
+	if !reflect.DeepEqual(result.SeverityCounts, wantCounts) {
+		t.Errorf("SeverityCounts = %v, want %v", result.SeverityCounts, wantCounts)
+	}
+	if result.Scanner != ScannerTrivy || result.Image != "nginx:1.25" {
+		t.Errorf("Unexpected scanner/image %q %q", result.Scanner, result.Image)
+	}
+	if len(result.Vulnerabilities) != 3 {
+		t.Fatalf("Expected 3 vulnerabilities, got %d", len(result.Vulnerabilities))
+	}
+	want := Vulnerability{ID: "CVE-2024-2511", Severity: "low", Package: "libssl3", InstalledVersion: "3.0.11-1", FixedVersion: "3.0.13-1", Title: "openssl: unbounded memory growth"}
+	if result.Vulnerabilities[1] != want {
+		t.Errorf("Vulnerabilities[1] = %+v, want %+v", result.Vulnerabilities[1], want)
+	}
+
+	if _, err := parseTrivyReport("nginx", []byte("not json")); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestParseScoutReport(t *testing.T) {
+	result, err := parseScoutReport("app:latest", []byte(sampleScoutReport))
+	if err != nil {
+		t.Fatalf("parseScoutReport() error = %v", err)
+	}
+
+	wantCounts := map[string]int{"critical": 0, "high": 1, "medium": 0, "low": 0, "unknown": 1}
+	if !reflect.DeepEqual(result.SeverityCounts, wantCounts) {
+		t.Errorf("SeverityCounts = %v, want %v", result.SeverityCounts, wantCounts)
+	}
+	want := []Vulnerability{
+		{ID: "CVE-2024-4068", Severity: "high", Package: "braces", InstalledVersion: "3.0.2", Title: "Uncontrolled resource consumption in braces"},
+		{ID: "CVE-2023-45853", Severity: "unknown", Package: "zlib", InstalledVersion: "1:1.2.13.dfsg-1", Title: "MiniZip integer overflow"},
+	}
+	if !reflect.DeepEqual(result.Vulnerabilities, want) {
+		t.Errorf("Vulnerabilities = %+v, want %+v", result.Vulnerabilities, want)
+	}
+}
+
+func TestScanImageWithoutScanner(t *testing.T) {
+	client := NewClientWithOptions(ClientOptions{Scanner: "none"})
+	if _, err := client.ScanImage(context.Background(), "nginx"); !errors.Is(err, errdefs.ErrNotImplemented) {
+		t.Errorf("Expected ErrNotImplemented with scanning disabled, got %v", err)
+	}
+
+	client = NewClientWithOptions(ClientOptions{Binary: "/nonexistent/docker", Scanner: ScannerTrivy})
+	client.trivy = "/nonexistent/trivy"
+	if _, err := client.ScanImage(context.Background(), "nginx"); !errors.Is(err, errdefs.ErrNotImplemented) {
+		t.Errorf("Expected ErrNotImplemented without trivy, got %v", err)
+	}
+}
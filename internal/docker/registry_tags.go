@@ -0,0 +1,245 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// Docker Hub serves the registry API from a different host than its name
+const (
+	dockerHubRegistry = "registry-1.docker.io"
+	dockerHubIndex    = "docker.io"
+)
+
+// maxTagPages bounds how many paginated responses ListRemoteTags follows
+const maxTagPages = 50
+
+// registryHTTPClient is used for registry API calls
+var registryHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// RegistryAuth holds optional registry credentials
+type RegistryAuth struct {
+	Username string
+	Password string
+}
+
+// ListRemoteTags lists the tags of a repository through the registry v2 API,
+// e.g. "nginx", "ghcr.io/org/app" or "registry.local:5000/team/app". Docker
+// Hub's token flow and basic auth registries are both handled.
+func (c *Client) ListRemoteTags(ctx context.Context, repository string, auth *RegistryAuth) ([]string, error) {
+	host, path, err := parseRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	next := fmt.Sprintf("%s://%s/v2/%s/tags/list", registryScheme(host), host, path)
+	tags := []string{}
+	authorization := ""
+	for page := 0; next != "" && page < maxTagPages; page++ {
+		resp, err := registryGet(ctx, next, authorization)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && authorization == "" {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if authorization, err = registryAuthorization(ctx, challenge, auth); err != nil {
+				return nil, err
+			}
+			if resp, err = registryGet(ctx, next, authorization); err != nil {
+				return nil, err
+			}
+		}
+
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		err = decodeRegistryResponse(resp, repository, &body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, body.Tags...)
+
+		next, err = nextPage(next, resp.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// parseRepository splits a repository into the registry host and the path
+// used in API URLs, applying Docker Hub's defaults
+func parseRepository(repository string) (string, string, error) {
+	repository = strings.TrimSpace(repository)
+	if repository == "" || strings.ContainsAny(repository, "@ ") {
+		return "", "", fmt.Errorf("%w: invalid repository %q", errdefs.ErrInvalidInput, repository)
+	}
+	if last := repository[strings.LastIndex(repository, "/")+1:]; strings.Contains(last, ":") {
+		return "", "", fmt.Errorf("%w: repository %q must not include a tag", errdefs.ErrInvalidInput, repository)
+	}
+
+	host, path := dockerHubRegistry, repository
+	if first, rest, found := strings.Cut(repository, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		host, path = first, rest
+	}
+	if host == dockerHubIndex || host == "index."+dockerHubIndex {
+		host = dockerHubRegistry
+	}
+	if host == dockerHubRegistry && !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+	return host, path, nil
+}
+
+// registryScheme uses plain HTTP only for loopback registries, which docker
+// also treats as insecure by default
+func registryScheme(host string) string {
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	if hostname == "localhost" {
+		return "http"
+	}
+	if ip := net.ParseIP(hostname); ip != nil && ip.IsLoopback() {
+		return "http"
+	}
+	return "https"
+}
+
+func registryGet(ctx context.Context, rawURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := registryHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("registry request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// registryAuthorization answers a WWW-Authenticate challenge: basic auth with
+// the given credentials, or a bearer token from the challenge's realm
+func registryAuthorization(ctx context.Context, challenge string, auth *RegistryAuth) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if auth == nil || auth.Username == "" {
+			return "", fmt.Errorf("%w: registry requires credentials", errdefs.ErrInvalidInput)
+		}
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(auth.Username, auth.Password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported registry auth challenge %q", challenge)
+	}
+
+	values := parseChallengeParams(params)
+	realm, err := url.Parse(values["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid registry auth realm %q", values["realm"])
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if values[key] != "" {
+			query.Set(key, values[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if auth != nil && auth.Username != "" {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+	resp, err := registryHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("registry token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := decodeRegistryResponse(resp, realm.Host, &token); err != nil {
+		return "", err
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return "", fmt.Errorf("registry token response from %s has no token", realm.Host)
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// parseChallengeParams reads the comma separated key="value" pairs of a
+// WWW-Authenticate header
+func parseChallengeParams(params string) map[string]string {
+	values := map[string]string{}
+	for params != "" {
+		var key, value string
+		key, params, _ = strings.Cut(strings.TrimLeft(params, ", "), "=")
+		if strings.HasPrefix(params, `"`) {
+			value, params, _ = strings.Cut(params[1:], `"`)
+		} else {
+			value, params, _ = strings.Cut(params, ",")
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return values
+}
+
+func decodeRegistryResponse(resp *http.Response, subject string, v interface{}) error {
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: repository %s", errdefs.ErrNotFound, subject)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: access to %s denied (HTTP %d)", errdefs.ErrInvalidInput, subject, resp.StatusCode)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("registry returned HTTP %d for %s: %s", resp.StatusCode, subject, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse registry response: %w", err)
+	}
+	return nil
+}
+
+// nextPage resolves the rel="next" target of a Link header against the
+// current URL, returning "" on the last page
+func nextPage(current, link string) (string, error) {
+	target, rest, found := strings.Cut(link, ";")
+	if !found || !strings.Contains(rest, `rel="next"`) {
+		return "", nil
+	}
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+	if err != nil {
+		return "", fmt.Errorf("invalid registry Link header %q", link)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+func TestParseRepository(t *testing.T) {
+	tests := []struct {
+		repository string
+		host       string
+		path       string
+		wantErr    bool
+	}{
+		{"nginx", "registry-1.docker.io", "library/nginx", false},
+		{"grafana/grafana", "registry-1.docker.io", "grafana/grafana", false},
+		{"docker.io/library/redis", "registry-1.docker.io", "library/redis", false},
+		{"docker.io/redis", "registry-1.docker.io", "library/redis", false},
+		{"ghcr.io/org/app", "ghcr.io", "org/app", false},
+		{"registry.local:5000/team/app", "registry.local:5000", "team/app", false},
+		{"localhost/app", "localhost", "app", false},
+		{"nginx:1.27", "", "", true},
+		{"registry.local:5000/app:1.0", "", "", true},
+		{"nginx@sha256:abc", "", "", true},
+		{"", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repository, func(t *testing.T) {
+			host, path, err := parseRepository(tt.repository)
+			if tt.wantErr {
+				if !errors.Is(err, errdefs.ErrInvalidInput) {
+					t.Errorf("Expected invalid input, got %v", err)
+				}
+				return
+			}
+			if err != nil || host != tt.host || path != tt.path {
+				t.Errorf("parseRepository() = %q, %q, %v, want %q, %q", host, path, err, tt.host, tt.path)
+			}
+		})
+	}
+}
+
+func TestRegistryScheme(t *testing.T) {
+	for host, want := range map[string]string{
+		"localhost:5000":  "http",
+		"127.0.0.1:41234": "http",
+		"ghcr.io":         "https",
+		"10.0.0.5:5000":   "https",
+	} {
+		if got := registryScheme(host); got != want {
+			t.Errorf("registryScheme(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestListRemoteTags(t *testing.T) {
+	// A fake registry behind a bearer token service, paginating two tags
+	// per page
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			user, pass, _ := r.BasicAuth()
+			if r.URL.Query().Get("scope") != "repository:team/app:pull" || user != "bot" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"token": "abc123"})
+		case "/v2/team/app/tags/list":
+			if r.Header.Get("Authorization") != "Bearer abc123" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry.test",scope="repository:team/app:pull"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/team/app/tags/list?n=2&last=1.1>; rel="next"`)
+				json.NewEncoder(w).Encode(map[string]interface{}{"name": "team/app", "tags": []string{"1.0", "1.1"}})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "team/app", "tags": []string{"latest"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	repository := strings.TrimPrefix(server.URL, "http://") + "/team/app"
+
+	tags, err := client.ListRemoteTags(context.Background(), repository, &RegistryAuth{Username: "bot", Password: "secret"})
+	if err != nil {
+		t.Fatalf("ListRemoteTags() error = %v", err)
+	}
+	if want := []string{"1.0", "1.1", "latest"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("ListRemoteTags() = %v, want %v", tags, want)
+	}
+
+	if _, err := client.ListRemoteTags(context.Background(), repository, nil); !errors.Is(err, errdefs.ErrInvalidInput) {
+		t.Errorf("Expected access denied without credentials, got %v", err)
+	}
+
+	missing := strings.TrimPrefix(server.URL, "http://") + "/team/missing"
+	if _, err := client.ListRemoteTags(context.Background(), missing, nil); !errors.Is(err, errdefs.ErrNotFound) {
+		t.Errorf("Expected not found for an unknown repository, got %v", err)
+	}
+}
+
+func TestParseChallengeParams(t *testing.T) {
+	got := parseChallengeParams(`realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`)
+	want := map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/nginx:pull",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseChallengeParams() = %v, want %v", got, want)
+	}
+}
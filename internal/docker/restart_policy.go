@@ -0,0 +1,81 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ContainerRestartPolicy is the restart policy a compose container runs with
+type ContainerRestartPolicy struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Service string `json:"service"`
+	Policy  string `json:"policy"`
+}
+
+// ProjectRestartPolicies returns the restart policy of every container, running
+// or not, that belongs to the compose project
+func (c *Client) ProjectRestartPolicies(ctx context.Context, projectName string) ([]ContainerRestartPolicy, error) {
+	ids, err := c.ExecuteCommand(ctx, "ps", []string{"-a", "-q", "--filter", "label=com.docker.compose.project=" + ComposeProjectName(ctx, projectName)})
+	if err != nil {
+		return nil, err
+	}
+	if ids == "" {
+		return []ContainerRestartPolicy{}, nil
+	}
+
+	output, err := c.ExecuteCommand(ctx, "container", append([]string{"inspect"}, strings.Fields(ids)...))
+	if err != nil {
+		return nil, err
+	}
+	return parseRestartPolicies(output)
+}
+
+// parseRestartPolicies reads restart policies from `docker container inspect`
+// JSON. Policies are reported in compose syntax, e.g. "on-failure:3".
+func parseRestartPolicies(output string) ([]ContainerRestartPolicy, error) {
+	var containers []struct {
+		ID     string
+		Name   string
+		Config struct {
+			Labels map[string]string
+		}
+		HostConfig struct {
+			RestartPolicy struct {
+				Name              string
+				MaximumRetryCount int
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(output), &containers); err != nil {
+		return nil, fmt.Errorf("failed to parse container inspect output: %w", err)
+	}
+
+	policies := make([]ContainerRestartPolicy, 0, len(containers))
+	for _, container := range containers {
+		policy := container.HostConfig.RestartPolicy
+		name := policy.Name
+		if name == "" {
+			name = "no"
+		}
+		if name == "on-failure" && policy.MaximumRetryCount > 0 {
+			name = fmt.Sprintf("on-failure:%d", policy.MaximumRetryCount)
+		}
+
+		policies = append(policies, ContainerRestartPolicy{
+			ID:      container.ID,
+			Name:    strings.TrimPrefix(container.Name, "/"),
+			Service: container.Config.Labels["com.docker.compose.service"],
+			Policy:  name,
+		})
+	}
+	return policies, nil
+}
+
+// UpdateRestartPolicy changes a container's restart policy in place
+func (c *Client) UpdateRestartPolicy(ctx context.Context, containerID, policy string) error {
+	_, err := c.ExecuteCommand(ctx, "update", []string{"--restart", policy, containerID})
+	return err
+}
@@ -0,0 +1,34 @@
+package docker
+
+import "testing"
+
+func TestParseRestartPolicies(t *testing.T) {
+	output := `[
+  {"Id": "a1", "Name": "/shop-web-1", "Config": {"Labels": {"com.docker.compose.service": "web"}}, "HostConfig": {"RestartPolicy": {"Name": "unless-stopped", "MaximumRetryCount": 0}}},
+  {"Id": "b1", "Name": "/shop-worker-1", "Config": {"Labels": {"com.docker.compose.service": "worker"}}, "HostConfig": {"RestartPolicy": {"Name": "on-failure", "MaximumRetryCount": 5}}},
+  {"Id": "c1", "Name": "/shop-cron-1", "Config": {"Labels": {"com.docker.compose.service": "cron"}}, "HostConfig": {"RestartPolicy": {"Name": "", "MaximumRetryCount": 0}}}
+]`
+
+	policies, err := parseRestartPolicies(output)
+	if err != nil {
+		t.Fatalf("parseRestartPolicies() error = %v", err)
+	}
+
+	want := []ContainerRestartPolicy{
+		{ID: "a1", Name: "shop-web-1", Service: "web", Policy: "unless-stopped"},
+		{ID: "b1", Name: "shop-worker-1", Service: "worker", Policy: "on-failure:5"},
+		{ID: "c1", Name: "shop-cron-1", Service: "cron", Policy: "no"},
+	}
+	if len(policies) != len(want) {
+		t.Fatalf("got %d policies, want %d", len(policies), len(want))
+	}
+	for i := range want {
+		if policies[i] != want[i] {
+			t.Errorf("policy %d = %+v, want %+v", i, policies[i], want[i])
+		}
+	}
+
+	if _, err := parseRestartPolicies("not json"); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
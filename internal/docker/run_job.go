@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runFailedExitCode is the status docker run exits with when the container
+// could not be started at all, as opposed to the job itself failing
+const runFailedExitCode = 125
+
+// RunJobOptions describes a short-lived job container
+type RunJobOptions struct {
+	Image   string
+	Command []string
+	Env     map[string]string
+	Timeout time.Duration
+}
+
+// RunJobResult is the outcome of a job container
+type RunJobResult struct {
+	Name       string `json:"name"`
+	Image      string `json:"image"`
+	ExitCode   int    `json:"exit_code"`
+	Output     string `json:"output"`
+	TimedOut   bool   `json:"timed_out"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// RunJob runs a container to completion with `docker run --rm` and captures
+// its combined output and exit code. A job still running at the timeout is
+// force-removed and reported with TimedOut set and exit code -1.
+func (c *Client) RunJob(ctx context.Context, opts RunJobOptions) (*RunJobResult, error) {
+	if opts.Image == "" {
+		return nil, fmt.Errorf("image is required")
+	}
+	if err := ValidateImage(opts.Image); err != nil {
+		return nil, err
+	}
+
+	name, err := jobName()
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	cmd := c.commandContext(runCtx, runJobArgs(name, opts)...)
+	// Do not wait forever on output pipes once the CLI has been killed
+	cmd.WaitDelay = 5 * time.Second
+	output, err := cmd.CombinedOutput()
+
+	result := &RunJobResult{
+		Name:       name,
+		Image:      opts.Image,
+		Output:     string(output),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		// Killing the CLI leaves the container running, so remove it too
+		if rmErr := c.command("rm", "-f", name).Run(); rmErr != nil {
+			return nil, fmt.Errorf("job %s timed out and could not be removed: %w", name, rmErr)
+		}
+		result.TimedOut = true
+		result.ExitCode = -1
+		return result, nil
+	}
+	if err := ctx.Err(); err != nil {
+		// Cancelled by the caller: the container is not wanted any more
+		if rmErr := c.command("rm", "-f", name).Run(); rmErr != nil {
+			return nil, fmt.Errorf("job %s was cancelled and could not be removed: %w", name, rmErr)
+		}
+		return nil, err
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() != runFailedExitCode:
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		return nil, classifyError(fmt.Errorf("docker run failed: %s", strings.TrimSpace(string(output))), string(output))
+	}
+	return result, nil
+}
+
+func runJobArgs(name string, opts RunJobOptions) []string {
+	args := []string{"run", "--rm", "--name", name}
+
+	keys := make([]string, 0, len(opts.Env))
+	for key := range opts.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-e", key+"="+opts.Env[key])
+	}
+
+	args = append(args, opts.Image)
+	return append(args, opts.Command...)
+}
+
+// jobName returns a unique container name so a timed-out job can be removed
+func jobName() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job name: %w", err)
+	}
+	return "arcane-job-" + hex.EncodeToString(b), nil
+}
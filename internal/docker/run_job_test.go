@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunJobArgs(t *testing.T) {
+	got := runJobArgs("arcane-job-1", RunJobOptions{
+		Image:   "alpine:3",
+		Command: []string{"sh", "-c", "echo $GREETING"},
+		Env:     map[string]string{"GREETING": "hi", "A": "1"},
+	})
+	want := []string{"run", "--rm", "--name", "arcane-job-1", "-e", "A=1", "-e", "GREETING=hi", "alpine:3", "sh", "-c", "echo $GREETING"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("runJobArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker is a shell script")
+	}
+
+	dir := t.TempDir()
+	removed := filepath.Join(dir, "removed")
+	bin := filepath.Join(dir, "docker")
+	script := `#!/bin/sh
+if [ "$1" = "rm" ]; then
+  echo "$@" >> ` + removed + `
+  exit 0
+fi
+case "$*" in
+*slow*) exec sleep 5 ;;
+*fail*) echo "boom"; exit 3 ;;
+*missing*) echo "Unable to find image 'missing:latest' locally" >&2; exit 125 ;;
+esac
+echo "hello"
+`
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker: %v", err)
+	}
+	client := NewClientWithOptions(ClientOptions{Binary: bin})
+	ctx := context.Background()
+
+	result, err := client.RunJob(ctx, RunJobOptions{Image: "ok", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("RunJob() error = %v", err)
+	}
+	if result.ExitCode != 0 || result.Output != "hello\n" || result.TimedOut {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	result, err = client.RunJob(ctx, RunJobOptions{Image: "fail", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Expected a failing job to be a result, got error %v", err)
+	}
+	if result.ExitCode != 3 || result.Output != "boom\n" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	if _, err := client.RunJob(ctx, RunJobOptions{Image: "missing", Timeout: 5 * time.Second}); err == nil {
+		t.Error("Expected error when docker run cannot start the container")
+	}
+
+	start := time.Now()
+	result, err = client.RunJob(ctx, RunJobOptions{Image: "slow", Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("RunJob() error = %v", err)
+	}
+	if !result.TimedOut || result.ExitCode != -1 {
+		t.Errorf("Expected timed out job, got %+v", result)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected the job to be stopped at the timeout, took %v", elapsed)
+	}
+	log, _ := os.ReadFile(removed)
+	if !strings.Contains(string(log), "rm -f "+result.Name) {
+		t.Errorf("Expected timed out container %s to be force-removed, got %q", result.Name, log)
+	}
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// ComposeRemoved lists what `docker compose down` reported removing
+type ComposeRemoved struct {
+	Containers []string `json:"containers"`
+	Networks   []string `json:"networks"`
+}
+
+// ComposeDownRemoveOrphans takes a project down, including containers of
+// services that are no longer declared in its compose files
+func (c *Client) ComposeDownRemoveOrphans(ctx context.Context, composeFile, projectName string) (*ComposeRemoved, error) {
+	cmd := c.composeCommand(ctx, composeDownArgs(composeFile, ComposeProjectName(ctx, projectName), ComposeProfiles(ctx), true)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, newComposeError("down", ComposeProjectName(ctx, projectName), string(output))
+	}
+	return parseComposeRemoved(string(output)), nil
+}
+
+func composeDownArgs(composeFile, projectName string, profiles []string, removeOrphans bool) []string {
+	args := composeArgs(composeFile, projectName, profiles, "down")
+	if removeOrphans {
+		args = append(args, "--remove-orphans")
+	}
+	return args
+}
+
+// parseComposeRemoved picks the removed containers and networks out of
+// compose progress lines such as "Container shop-web-1  Removed"
+func parseComposeRemoved(output string) *ComposeRemoved {
+	removed := &ComposeRemoved{Containers: []string{}, Networks: []string{}}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[len(fields)-1] != "Removed" {
+			continue
+		}
+		switch fields[0] {
+		case "Container":
+			removed.Containers = append(removed.Containers, fields[1])
+		case "Network":
+			removed.Networks = append(removed.Networks, fields[1])
+		}
+	}
+	return removed
+}
+
+// ProjectImages returns the images used by the project's containers, running
+// or not, including containers of services that were since removed
+func (c *Client) ProjectImages(ctx context.Context, projectName string) ([]string, error) {
+	output, err := c.ExecuteCommand(ctx, "ps", []string{"-a", "--filter", "label=com.docker.compose.project=" + ComposeProjectName(ctx, projectName), "--format", "{{.Image}}"})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	images := []string{}
+	for _, image := range strings.Fields(output) {
+		if !seen[image] {
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+	sort.Strings(images)
+	return images, nil
+}
@@ -0,0 +1,43 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComposeDownArgs(t *testing.T) {
+	got := composeDownArgs("/stacks/shop/compose.yml", "shop", nil, true)
+	want := []string{"-f", "/stacks/shop/compose.yml", "-p", "shop", "down", "--remove-orphans"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("composeDownArgs() = %v, want %v", got, want)
+	}
+
+	got = composeDownArgs("/stacks/shop/compose.yml", "shop", nil, false)
+	for _, arg := range got {
+		if arg == "--remove-orphans" {
+			t.Errorf("Expected no --remove-orphans, got %v", got)
+		}
+	}
+}
+
+func TestParseComposeRemoved(t *testing.T) {
+	output := ` Container shop-web-1  Stopping
+ Container shop-web-1  Stopped
+ Container shop-web-1  Removed
+ Container shop-legacy-1  Removed
+ Network shop_default  Removing
+ Network shop_default  Removed
+`
+	removed := parseComposeRemoved(output)
+	if !reflect.DeepEqual(removed.Containers, []string{"shop-web-1", "shop-legacy-1"}) {
+		t.Errorf("Containers = %v", removed.Containers)
+	}
+	if !reflect.DeepEqual(removed.Networks, []string{"shop_default"}) {
+		t.Errorf("Networks = %v", removed.Networks)
+	}
+
+	empty := parseComposeRemoved("")
+	if empty.Containers == nil || empty.Networks == nil {
+		t.Error("Expected empty lists rather than nil")
+	}
+}
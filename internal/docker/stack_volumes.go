@@ -0,0 +1,46 @@
+package docker
+
+import (
+	"context"
+	"strings"
+)
+
+// StackVolume is a volume created by compose for a project
+type StackVolume struct {
+	Name       string `json:"name"`
+	Declared   string `json:"declared,omitempty"`
+	Driver     string `json:"driver"`
+	Mountpoint string `json:"mountpoint"`
+	Size       int64  `json:"size"`
+}
+
+// ProjectVolumes lists the volumes carrying the project's compose label.
+// Declared is the volume's key in the compose file, which compose prefixes
+// with the project name to build Name. Size is left for the caller to fill in.
+func (c *Client) ProjectVolumes(ctx context.Context, projectName string) ([]StackVolume, error) {
+	output, err := c.ExecuteCommand(ctx, "volume", []string{
+		"ls",
+		"--filter", "label=com.docker.compose.project=" + ComposeProjectName(ctx, projectName),
+		"--format", `{{.Name}}\t{{.Driver}}\t{{.Mountpoint}}\t{{.Label "com.docker.compose.volume"}}`,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseStackVolumes(output), nil
+}
+
+func parseStackVolumes(output string) []StackVolume {
+	volumes := []StackVolume{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		volume := StackVolume{Name: fields[0], Driver: fields[1], Mountpoint: fields[2]}
+		if len(fields) > 3 {
+			volume.Declared = fields[3]
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes
+}
@@ -0,0 +1,54 @@
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var sizeUnits = map[string]float64{
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+// parseHumanSize converts sizes printed by the docker CLI ("1.2GB", "512kB",
+// "10MiB") into bytes
+func parseHumanSize(size string) (int64, error) {
+	size = strings.TrimSpace(size)
+	if size == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	idx := strings.IndexFunc(size, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if idx == 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+
+	number, unit := size, "b"
+	if idx > 0 {
+		number, unit = size[:idx], strings.ToLower(strings.TrimSpace(size[idx:]))
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", size, err)
+	}
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit %q", unit)
+	}
+
+	return int64(value * multiplier), nil
+}
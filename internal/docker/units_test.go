@@ -0,0 +1,39 @@
+package docker
+
+import "testing"
+
+func TestParseHumanSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{"0B", 0, false},
+		{"512", 512, false},
+		{"1.5kB", 1500, false},
+		{"2.4GB", 2400000000, false},
+		{"10MiB", 10 * 1024 * 1024, false},
+		{" 1GiB ", 1 << 30, false},
+		{"", 0, true},
+		{"GB", 0, true},
+		{"12XB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseHumanSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("parseHumanSize(%q) = %d, expected %d", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
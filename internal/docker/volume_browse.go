@@ -0,0 +1,105 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// volumeBrowseImage is the throwaway image used to read volume contents
+const volumeBrowseImage = "busybox:latest"
+
+// volumeMountPoint is where the volume is mounted inside the browse container
+const volumeMountPoint = "/volume"
+
+// volumeNamePattern matches docker's own volume name rules. Anything else,
+// notably absolute paths, would turn the -v flag into a host bind mount.
+var volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// VolumeEntry is a single file or directory inside a volume
+type VolumeEntry struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	Mode  string `json:"mode"`
+	IsDir bool   `json:"isDir"`
+}
+
+// ListVolumeContents lists one directory of a volume by mounting it read-only
+// into a throwaway container. subPath is relative to the volume root.
+func (c *Client) ListVolumeContents(ctx context.Context, volumeName, subPath string) ([]VolumeEntry, error) {
+	args, err := volumeBrowseArgs(volumeName, subPath)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := c.commandContext(ctx, args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volume %s: %s", volumeName, strings.TrimSpace(string(output)))
+	}
+
+	return parseVolumeListing(string(output))
+}
+
+// volumeBrowseArgs builds the docker run arguments for ListVolumeContents
+func volumeBrowseArgs(volumeName, subPath string) ([]string, error) {
+	if !volumeNamePattern.MatchString(volumeName) {
+		return nil, fmt.Errorf("invalid volume name %q", volumeName)
+	}
+
+	if hasDotDot(subPath) {
+		return nil, fmt.Errorf("invalid volume path %q", subPath)
+	}
+	// Container paths are always slash separated, regardless of the agent's OS
+	cleaned := path.Clean("/" + subPath)
+
+	return []string{
+		"run", "--rm",
+		"--network", "none",
+		"-v", volumeName + ":" + volumeMountPoint + ":ro",
+		volumeBrowseImage,
+		"find", path.Join(volumeMountPoint, cleaned),
+		"-mindepth", "1", "-maxdepth", "1",
+		"-exec", "stat", "-c", "%s %A %n", "{}", "+",
+	}, nil
+}
+
+// hasDotDot reports whether any element of a slash separated path is ".."
+func hasDotDot(p string) bool {
+	for _, part := range strings.Split(strings.ReplaceAll(p, "\\", "/"), "/") {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVolumeListing parses `stat -c "%s %A %n"` lines
+func parseVolumeListing(output string) ([]VolumeEntry, error) {
+	entries := []VolumeEntry{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected volume listing line: %q", line)
+		}
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected volume listing line: %q", line)
+		}
+
+		entries = append(entries, VolumeEntry{
+			Name:  path.Base(fields[2]),
+			Size:  size,
+			Mode:  fields[1],
+			IsDir: strings.HasPrefix(fields[1], "d"),
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,73 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVolumeBrowseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		volume  string
+		subPath string
+		dir     string
+		wantErr bool
+	}{
+		{name: "root", volume: "data", subPath: "", dir: "/volume"},
+		{name: "slash", volume: "data", subPath: "/", dir: "/volume"},
+		{name: "nested", volume: "app_data", subPath: "logs/2024", dir: "/volume/logs/2024"},
+		{name: "leading slash", volume: "app.data", subPath: "/logs/", dir: "/volume/logs"},
+		{name: "parent", volume: "data", subPath: "..", wantErr: true},
+		{name: "escaping", volume: "data", subPath: "logs/../../etc", wantErr: true},
+		{name: "absolute escape", volume: "data", subPath: "/../etc", wantErr: true},
+		{name: "backslash escape", volume: "data", subPath: `logs\..\..`, wantErr: true},
+		{name: "host path volume", volume: "/etc", wantErr: true},
+		{name: "volume with colon", volume: "data:/host", wantErr: true},
+		{name: "empty volume", volume: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := volumeBrowseArgs(tt.volume, tt.subPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("volumeBrowseArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			want := []string{
+				"run", "--rm",
+				"--network", "none",
+				"-v", tt.volume + ":/volume:ro",
+				"busybox:latest",
+				"find", tt.dir,
+				"-mindepth", "1", "-maxdepth", "1",
+				"-exec", "stat", "-c", "%s %A %n", "{}", "+",
+			}
+			if !reflect.DeepEqual(args, want) {
+				t.Errorf("volumeBrowseArgs() = %v, want %v", args, want)
+			}
+		})
+	}
+}
+
+func TestParseVolumeListing(t *testing.T) {
+	output := "4096 drwxr-xr-x /volume/logs\n12 -rw-r--r-- /volume/my notes.txt\n"
+	want := []VolumeEntry{
+		{Name: "logs", Size: 4096, Mode: "drwxr-xr-x", IsDir: true},
+		{Name: "my notes.txt", Size: 12, Mode: "-rw-r--r--"},
+	}
+
+	got, err := parseVolumeListing(output)
+	if err != nil {
+		t.Fatalf("parseVolumeListing() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseVolumeListing() = %v, want %v", got, want)
+	}
+
+	if _, err := parseVolumeListing("garbage\n"); err == nil {
+		t.Error("parseVolumeListing() expected error for malformed line")
+	}
+}
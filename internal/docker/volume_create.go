@@ -0,0 +1,113 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// localVolumeOpts are the driver options the local volume driver accepts
+var localVolumeOpts = []string{"device", "o", "type"}
+
+// volumeSizePattern matches tmpfs sizes such as "512m" or "2g"
+var volumeSizePattern = regexp.MustCompile(`^[0-9]+[kmgKMG]?$`)
+
+// VolumeCreateOptions describes a volume to create
+type VolumeCreateOptions struct {
+	Name       string
+	Driver     string
+	DriverOpts map[string]string
+	Labels     map[string]string
+	// Size caps a local tmpfs volume, added to the driver's "o" option as
+	// size=<Size>
+	Size string
+}
+
+// BindDevice reports the host path a local volume bind-mounts, i.e. its
+// "device" option when the "o" option includes bind or rbind
+func (o VolumeCreateOptions) BindDevice() (string, bool) {
+	if o.Driver != "" && o.Driver != "local" {
+		return "", false
+	}
+	for _, option := range strings.Split(o.DriverOpts["o"], ",") {
+		if option = strings.TrimSpace(option); option == "bind" || option == "rbind" {
+			return o.DriverOpts["device"], true
+		}
+	}
+	return "", false
+}
+
+// CreateVolume creates a volume and returns its name, which docker generates
+// when none is given
+func (c *Client) CreateVolume(ctx context.Context, opts VolumeCreateOptions) (string, error) {
+	args, err := volumeCreateArgs(opts)
+	if err != nil {
+		return "", err
+	}
+	return c.ExecuteCommand(ctx, "volume", args)
+}
+
+// volumeCreateArgs assembles `docker volume create` arguments, rejecting
+// options the local driver would otherwise fail on with a less helpful error
+func volumeCreateArgs(opts VolumeCreateOptions) ([]string, error) {
+	driver := opts.Driver
+	if driver == "" {
+		driver = "local"
+	}
+
+	driverOpts := make(map[string]string, len(opts.DriverOpts)+1)
+	for key, value := range opts.DriverOpts {
+		driverOpts[key] = value
+	}
+
+	if driver == "local" {
+		for key := range driverOpts {
+			if !slices.Contains(localVolumeOpts, key) {
+				return nil, fmt.Errorf("%w: the local driver does not support option %q (supported: %s)", errdefs.ErrInvalidInput, key, strings.Join(localVolumeOpts, ", "))
+			}
+		}
+	}
+
+	if opts.Size != "" {
+		if driver != "local" {
+			return nil, fmt.Errorf("%w: size is only supported by the local driver, not %s", errdefs.ErrInvalidInput, driver)
+		}
+		if driverOpts["type"] != "tmpfs" {
+			return nil, fmt.Errorf("%w: size is only supported for local volumes of type tmpfs", errdefs.ErrInvalidInput)
+		}
+		if !volumeSizePattern.MatchString(opts.Size) {
+			return nil, fmt.Errorf("%w: invalid size %q, expected a number with an optional k, m or g suffix", errdefs.ErrInvalidInput, opts.Size)
+		}
+		if o := driverOpts["o"]; o != "" {
+			driverOpts["o"] = o + ",size=" + opts.Size
+		} else {
+			driverOpts["o"] = "size=" + opts.Size
+		}
+	}
+
+	args := []string{"create", "--driver", driver}
+	for _, key := range sortedKeys(driverOpts) {
+		args = append(args, "--opt", key+"="+driverOpts[key])
+	}
+	for _, key := range sortedKeys(opts.Labels) {
+		args = append(args, "--label", key+"="+opts.Labels[key])
+	}
+	if opts.Name != "" {
+		args = append(args, opts.Name)
+	}
+	return args, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
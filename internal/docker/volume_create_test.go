@@ -0,0 +1,112 @@
+package docker
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+func TestVolumeCreateArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    VolumeCreateOptions
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "defaults to the local driver",
+			opts: VolumeCreateOptions{Name: "data"},
+			want: []string{"create", "--driver", "local", "data"},
+		},
+		{
+			name: "tmpfs with size and labels",
+			opts: VolumeCreateOptions{
+				Name:       "scratch",
+				DriverOpts: map[string]string{"type": "tmpfs", "device": "tmpfs"},
+				Labels:     map[string]string{"team": "web"},
+				Size:       "512m",
+			},
+			want: []string{"create", "--driver", "local", "--opt", "device=tmpfs", "--opt", "o=size=512m", "--opt", "type=tmpfs", "--label", "team=web", "scratch"},
+		},
+		{
+			name: "size appended to existing mount options",
+			opts: VolumeCreateOptions{
+				DriverOpts: map[string]string{"type": "tmpfs", "device": "tmpfs", "o": "uid=1000"},
+				Size:       "1g",
+			},
+			want: []string{"create", "--driver", "local", "--opt", "device=tmpfs", "--opt", "o=uid=1000,size=1g", "--opt", "type=tmpfs"},
+		},
+		{
+			name: "other drivers pass options through",
+			opts: VolumeCreateOptions{Name: "shared", Driver: "rexray", DriverOpts: map[string]string{"size": "10"}},
+			want: []string{"create", "--driver", "rexray", "--opt", "size=10", "shared"},
+		},
+		{
+			name:    "unsupported local option",
+			opts:    VolumeCreateOptions{DriverOpts: map[string]string{"size": "1g"}},
+			wantErr: `the local driver does not support option "size"`,
+		},
+		{
+			name:    "size without tmpfs",
+			opts:    VolumeCreateOptions{Size: "1g"},
+			wantErr: "size is only supported for local volumes of type tmpfs",
+		},
+		{
+			name:    "size with another driver",
+			opts:    VolumeCreateOptions{Driver: "rexray", Size: "1g"},
+			wantErr: "size is only supported by the local driver",
+		},
+		{
+			name:    "invalid size",
+			opts:    VolumeCreateOptions{DriverOpts: map[string]string{"type": "tmpfs"}, Size: "lots"},
+			wantErr: `invalid size "lots"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := volumeCreateArgs(tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if !errors.Is(err, errdefs.ErrInvalidInput) {
+					t.Errorf("Expected invalid input error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("volumeCreateArgs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("volumeCreateArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVolumeCreateOptionsBindDevice(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       VolumeCreateOptions
+		wantDevice string
+		wantBind   bool
+	}{
+		{"bind", VolumeCreateOptions{DriverOpts: map[string]string{"type": "none", "o": "bind", "device": "/etc"}}, "/etc", true},
+		{"rbind among options", VolumeCreateOptions{DriverOpts: map[string]string{"o": "ro, rbind", "device": "/srv"}}, "/srv", true},
+		{"tmpfs", VolumeCreateOptions{DriverOpts: map[string]string{"type": "tmpfs", "o": "size=64m", "device": "tmpfs"}}, "", false},
+		{"other driver", VolumeCreateOptions{Driver: "nfs", DriverOpts: map[string]string{"o": "bind", "device": "/etc"}}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device, isBind := tt.opts.BindDevice()
+			if device != tt.wantDevice || isBind != tt.wantBind {
+				t.Errorf("BindDevice() = %q, %v, want %q, %v", device, isBind, tt.wantDevice, tt.wantBind)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// VolumeUsage is the disk usage of one volume as reported by `docker system df -v`
+type VolumeUsage struct {
+	Name  string `json:"name"`
+	Links int    `json:"links"`
+	Size  int64  `json:"size"`
+}
+
+// VolumeSizes reports the bytes used by every local volume. Docker walks each
+// volume to compute this, so it can be slow on large volumes.
+func (c *Client) VolumeSizes(ctx context.Context) ([]VolumeUsage, error) {
+	output, err := c.ExecuteCommand(ctx, "system", []string{"df", "-v"})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseVolumeDf(output), nil
+}
+
+// parseVolumeDf extracts the "Local Volumes space usage" table from
+// `docker system df -v` output
+func parseVolumeDf(output string) []VolumeUsage {
+	volumes := []VolumeUsage{}
+	inSection, inTable := false, false
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "Local Volumes space usage"):
+			inSection = true
+			continue
+		case !inSection:
+			continue
+		case strings.HasPrefix(trimmed, "VOLUME NAME"):
+			inTable = true
+			continue
+		case trimmed == "":
+			// The blank line before the header is part of the section
+			if inTable {
+				return volumes
+			}
+			continue
+		}
+
+		fields := strings.Fields(trimmed)
+		if !inTable || len(fields) < 3 {
+			return volumes
+		}
+
+		links, _ := strconv.Atoi(fields[len(fields)-2])
+		size, _ := parseHumanSize(fields[len(fields)-1])
+		volumes = append(volumes, VolumeUsage{
+			Name:  fields[0],
+			Links: links,
+			Size:  size,
+		})
+	}
+
+	return volumes
+}
@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVolumeDf(t *testing.T) {
+	output := `Images space usage:
+
+REPOSITORY   TAG       IMAGE ID       CREATED       SIZE      SHARED SIZE   UNIQUE SIZE   CONTAINERS
+postgres     16        4d2f8a1c9b3e   2 weeks ago   432MB     0B            432MB         1
+
+Containers space usage:
+
+CONTAINER ID   IMAGE         COMMAND                  LOCAL VOLUMES   SIZE      CREATED       STATUS       NAMES
+9f1c2e3d4b5a   postgres:16   "docker-entrypoint.s…"   1               63B       2 weeks ago   Up 3 hours   db
+
+Local Volumes space usage:
+
+VOLUME NAME                                                        LINKS     SIZE
+0d6f8c2b1a9e7d5c3b1a0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c   0         0B
+pgdata                                                             1         48.2MB
+media_cache                                                        2         1.5GB
+
+Build cache usage: 0B
+
+CACHE ID   CACHE TYPE   SIZE      CREATED   LAST USED   USAGE     SHARED
+`
+
+	want := []VolumeUsage{
+		{Name: "0d6f8c2b1a9e7d5c3b1a0f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c", Links: 0, Size: 0},
+		{Name: "pgdata", Links: 1, Size: 48200000},
+		{Name: "media_cache", Links: 2, Size: 1500000000},
+	}
+
+	if got := parseVolumeDf(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseVolumeDf() = %+v, want %+v", got, want)
+	}
+
+	empty := "Local Volumes space usage:\n\nVOLUME NAME   LINKS     SIZE\n\nBuild cache usage: 0B\n"
+	if got := parseVolumeDf(empty); len(got) != 0 {
+		t.Errorf("parseVolumeDf() with no volumes = %+v", got)
+	}
+}
@@ -0,0 +1,92 @@
+// Package errdefs defines the error categories the agent reports to the
+// server, so failures can be told apart without matching on message text.
+package errdefs
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+)
+
+var (
+	// ErrNotFound means the referenced stack, container, image or file does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the target is in a state that prevents the operation,
+	// such as a stack locked by another task
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidInput means the task payload was rejected before running
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrDockerUnavailable means the docker daemon could not be reached
+	ErrDockerUnavailable = errors.New("docker unavailable")
+	// ErrNotImplemented means the host lacks an optional tool the task needs,
+	// such as an image scanner
+	ErrNotImplemented = errors.New("not implemented")
+	// ErrCancelled means the task was cancelled by a task_cancel request
+	ErrCancelled = errors.New("cancelled")
+	// ErrMaintenance means the agent is in maintenance mode and refuses tasks
+	// that change the host
+	ErrMaintenance = errors.New("maintenance mode")
+)
+
+// Error codes reported in task results
+const (
+	CodeNotFound          = "not_found"
+	CodeConflict          = "conflict"
+	CodeInvalidInput      = "invalid_input"
+	CodeDockerUnavailable = "docker_unavailable"
+	CodeNotImplemented    = "not_implemented"
+	CodeCancelled         = "cancelled"
+	CodeMaintenance       = "maintenance"
+	CodeInternal          = "internal"
+)
+
+// Code returns the category of err. Errors wrapping fs.ErrNotExist count as
+// not found; anything unclassified is internal.
+func Code(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return CodeNotFound
+	case errors.Is(err, ErrConflict):
+		return CodeConflict
+	case errors.Is(err, ErrInvalidInput):
+		return CodeInvalidInput
+	case errors.Is(err, ErrDockerUnavailable):
+		return CodeDockerUnavailable
+	case errors.Is(err, ErrNotImplemented):
+		return CodeNotImplemented
+	case errors.Is(err, ErrCancelled):
+		return CodeCancelled
+	case errors.Is(err, ErrMaintenance):
+		return CodeMaintenance
+	default:
+		return CodeInternal
+	}
+}
+
+// statusClientClosedRequest is the non-standard status nginx uses for a
+// request abandoned before it completed
+const statusClientClosedRequest = 499
+
+// HTTPStatus maps err to the status code a server should answer with
+func HTTPStatus(err error) int {
+	switch Code(err) {
+	case "":
+		return http.StatusOK
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeConflict:
+		return http.StatusConflict
+	case CodeInvalidInput:
+		return http.StatusBadRequest
+	case CodeDockerUnavailable, CodeMaintenance:
+		return http.StatusServiceUnavailable
+	case CodeNotImplemented:
+		return http.StatusNotImplemented
+	case CodeCancelled:
+		return statusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
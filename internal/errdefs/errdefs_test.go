@@ -0,0 +1,41 @@
+package errdefs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestCodeAndHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   string
+		status int
+	}{
+		{"nil", nil, "", http.StatusOK},
+		{"not found", ErrNotFound, CodeNotFound, http.StatusNotFound},
+		{"wrapped not found", fmt.Errorf("project web: %w", ErrNotFound), CodeNotFound, http.StatusNotFound},
+		{"missing file", fmt.Errorf("read: %w", os.ErrNotExist), CodeNotFound, http.StatusNotFound},
+		{"conflict", fmt.Errorf("%w: stack is busy", ErrConflict), CodeConflict, http.StatusConflict},
+		{"invalid input", fmt.Errorf("%w: missing container_id", ErrInvalidInput), CodeInvalidInput, http.StatusBadRequest},
+		{"docker unavailable", ErrDockerUnavailable, CodeDockerUnavailable, http.StatusServiceUnavailable},
+		{"not implemented", fmt.Errorf("%w: no image scanner available", ErrNotImplemented), CodeNotImplemented, http.StatusNotImplemented},
+		{"cancelled", fmt.Errorf("%w: task t1 was cancelled", ErrCancelled), CodeCancelled, 499},
+		{"maintenance", fmt.Errorf("%w: compose_up is not allowed", ErrMaintenance), CodeMaintenance, http.StatusServiceUnavailable},
+		{"other", errors.New("boom"), CodeInternal, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Code(tt.err); got != tt.code {
+				t.Errorf("Code() = %q, want %q", got, tt.code)
+			}
+			if got := HTTPStatus(tt.err); got != tt.status {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.status)
+			}
+		})
+	}
+}
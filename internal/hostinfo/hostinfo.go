@@ -0,0 +1,259 @@
+// Package hostinfo reports disk and memory usage of the host the agent runs on
+package hostinfo
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Usage is a total/used/free breakdown in bytes
+type Usage struct {
+	Total       int64   `json:"total"`
+	Used        int64   `json:"used"`
+	Free        int64   `json:"free"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+// Host groups the usage figures sent with each heartbeat. Fields are nil when
+// the platform tool is unavailable.
+type Host struct {
+	Disk   *Usage `json:"disk,omitempty"`
+	Memory *Usage `json:"memory,omitempty"`
+}
+
+// Collect gathers disk usage for the filesystem holding path, plus memory usage
+func Collect(path string) Host {
+	diskFn, memoryFn := unixDisk, unixMemory
+	if runtime.GOOS == "windows" {
+		diskFn, memoryFn = windowsDisk, windowsMemory
+	}
+
+	// Fall back to the working directory's filesystem until path is created
+	if _, err := os.Stat(path); err != nil {
+		path = "."
+	}
+
+	var host Host
+	if disk, err := diskFn(path); err == nil {
+		host.Disk = disk
+	}
+	if memory, err := memoryFn(); err == nil {
+		host.Memory = memory
+	}
+	return host
+}
+
+func newUsage(total, free int64) *Usage {
+	usage := &Usage{Total: total, Free: free, Used: total - free}
+	if total > 0 {
+		usage.UsedPercent = float64(usage.Used) / float64(total) * 100
+	}
+	return usage
+}
+
+func run(name string, args ...string) (string, error) {
+	output, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+	return string(output), nil
+}
+
+func unixDisk(path string) (*Usage, error) {
+	output, err := run("df", "-P", "-k", path)
+	if err != nil {
+		return nil, err
+	}
+	return parseDF(output, 1024)
+}
+
+func unixMemory() (*Usage, error) {
+	output, err := run("free", "-b")
+	if err != nil {
+		return nil, err
+	}
+	return parseFree(output, 1)
+}
+
+func windowsDisk(path string) (*Usage, error) {
+	drive := "C:"
+	if len(path) >= 2 && path[1] == ':' {
+		drive = strings.ToUpper(path[:2])
+	}
+	output, err := run("wmic", "logicaldisk", "where", fmt.Sprintf("DeviceID='%s'", drive), "get", "FreeSpace,Size", "/Value")
+	if err != nil {
+		return nil, err
+	}
+	return parseWmicDisk(output)
+}
+
+func windowsMemory() (*Usage, error) {
+	output, err := run("wmic", "OS", "get", "FreePhysicalMemory,TotalVisibleMemorySize", "/Value")
+	if err != nil {
+		return nil, err
+	}
+	return parseWmicMemory(output)
+}
+
+// parseDF parses `df -P -k` or `df -h` output, using the last filesystem row.
+// unit is the byte multiplier for plain numbers (1024 for -k).
+func parseDF(output string, unit int64) (*Usage, error) {
+	lines := nonEmptyLines(output)
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("unexpected df output: %q", output)
+	}
+
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) == 5 {
+		// df -h wraps long device names onto their own line
+		fields = append([]string{""}, fields...)
+	}
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("unexpected df row: %q", lines[len(lines)-1])
+	}
+
+	total, err := parseSize(fields[1], unit)
+	if err != nil {
+		return nil, err
+	}
+	used, err := parseSize(fields[2], unit)
+	if err != nil {
+		return nil, err
+	}
+	free, err := parseSize(fields[3], unit)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{Total: total, Used: used, Free: free}
+	// Match df's own percentage, which excludes reserved blocks
+	if used+free > 0 {
+		usage.UsedPercent = float64(used) / float64(used+free) * 100
+	}
+	return usage, nil
+}
+
+// parseFree parses the Mem row of `free -b` or `free -h` output. Available
+// memory is reported as free, since buffers and cache can be reclaimed.
+func parseFree(output string, unit int64) (*Usage, error) {
+	var header []string
+	for _, line := range nonEmptyLines(output) {
+		fields := strings.Fields(line)
+		if header == nil {
+			header = fields
+			continue
+		}
+		if fields[0] != "Mem:" {
+			continue
+		}
+
+		values := map[string]string{}
+		for i, name := range header {
+			if i+1 < len(fields) {
+				values[name] = fields[i+1]
+			}
+		}
+
+		total, err := parseSize(values["total"], unit)
+		if err != nil {
+			return nil, err
+		}
+		free, err := parseSize(values["available"], unit)
+		if err != nil {
+			// Older procps has no available column
+			if free, err = parseSize(values["free"], unit); err != nil {
+				return nil, err
+			}
+		}
+		return newUsage(total, free), nil
+	}
+
+	return nil, fmt.Errorf("no Mem row in free output")
+}
+
+// parseWmicDisk parses `wmic logicaldisk get FreeSpace,Size /Value`, which reports bytes
+func parseWmicDisk(output string) (*Usage, error) {
+	values := parseWmicValues(output)
+	total, err := strconv.ParseInt(values["Size"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wmic Size: %w", err)
+	}
+	free, err := strconv.ParseInt(values["FreeSpace"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wmic FreeSpace: %w", err)
+	}
+	return newUsage(total, free), nil
+}
+
+// parseWmicMemory parses `wmic OS get FreePhysicalMemory,TotalVisibleMemorySize /Value`,
+// which reports kilobytes
+func parseWmicMemory(output string) (*Usage, error) {
+	values := parseWmicValues(output)
+	total, err := strconv.ParseInt(values["TotalVisibleMemorySize"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wmic TotalVisibleMemorySize: %w", err)
+	}
+	free, err := strconv.ParseInt(values["FreePhysicalMemory"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wmic FreePhysicalMemory: %w", err)
+	}
+	return newUsage(total*1024, free*1024), nil
+}
+
+func parseWmicValues(output string) map[string]string {
+	values := map[string]string{}
+	for _, line := range nonEmptyLines(output) {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+// parseSize parses plain numbers (multiplied by unit) and the binary suffixed
+// sizes printed by `df -h` and `free -h`, such as "20G", "1.5Gi" or "512B"
+func parseSize(value string, unit int64) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n * unit, nil
+	}
+
+	number := strings.TrimRight(value, "BKMGTPEi")
+	suffix := strings.TrimSuffix(strings.TrimSuffix(value[len(number):], "B"), "i")
+	n, err := strconv.ParseFloat(strings.Replace(number, ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	multipliers := map[string]float64{
+		"":  1,
+		"K": 1 << 10,
+		"M": 1 << 20,
+		"G": 1 << 30,
+		"T": 1 << 40,
+		"P": 1 << 50,
+		"E": 1 << 60,
+	}
+	multiplier, ok := multipliers[suffix]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(n * multiplier), nil
+}
+
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r", ""), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
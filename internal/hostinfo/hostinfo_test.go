@@ -0,0 +1,163 @@
+package hostinfo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseDF(t *testing.T) {
+	t.Run("posix kilobytes", func(t *testing.T) {
+		output := `Filesystem     1024-blocks     Used Available Capacity Mounted on
+/dev/sda1         41152736 20576368  18461036      53% /
+`
+		usage, err := parseDF(output, 1024)
+		if err != nil {
+			t.Fatalf("parseDF() error = %v", err)
+		}
+		if usage.Total != 41152736*1024 || usage.Used != 20576368*1024 || usage.Free != 18461036*1024 {
+			t.Errorf("unexpected usage: %+v", usage)
+		}
+		if math.Round(usage.UsedPercent) != 53 {
+			t.Errorf("expected ~53%% used, got %.2f", usage.UsedPercent)
+		}
+	})
+
+	t.Run("human readable", func(t *testing.T) {
+		output := `Filesystem      Size  Used Avail Use% Mounted on
+overlay          59G   21G   36G  37% /
+`
+		usage, err := parseDF(output, 1024)
+		if err != nil {
+			t.Fatalf("parseDF() error = %v", err)
+		}
+		if usage.Total != 59<<30 || usage.Used != 21<<30 || usage.Free != 36<<30 {
+			t.Errorf("unexpected usage: %+v", usage)
+		}
+	})
+
+	t.Run("wrapped long device name", func(t *testing.T) {
+		output := `Filesystem                        Size  Used Avail Use% Mounted on
+/dev/mapper/ubuntu--vg-ubuntu--lv
+                                   98G   40G   54G  43% /
+`
+		usage, err := parseDF(output, 1024)
+		if err != nil {
+			t.Fatalf("parseDF() error = %v", err)
+		}
+		if usage.Total != 98<<30 || usage.Used != 40<<30 || usage.Free != 54<<30 {
+			t.Errorf("unexpected usage: %+v", usage)
+		}
+	})
+
+	if _, err := parseDF("Filesystem Size\n", 1024); err == nil {
+		t.Error("expected error for output without rows")
+	}
+}
+
+func TestParseFree(t *testing.T) {
+	t.Run("bytes", func(t *testing.T) {
+		output := `               total        used        free      shared  buff/cache   available
+Mem:     16691519488  5368709120  2147483648   104857600  9175040000 10737418240
+Swap:     2147479552           0  2147479552
+`
+		usage, err := parseFree(output, 1)
+		if err != nil {
+			t.Fatalf("parseFree() error = %v", err)
+		}
+		if usage.Total != 16691519488 || usage.Free != 10737418240 {
+			t.Errorf("unexpected usage: %+v", usage)
+		}
+		if usage.Used != usage.Total-usage.Free {
+			t.Errorf("expected used = total - available, got %+v", usage)
+		}
+	})
+
+	t.Run("human readable", func(t *testing.T) {
+		output := `               total        used        free      shared  buff/cache   available
+Mem:            15Gi       5.0Gi       2.0Gi       100Mi       8.5Gi        10Gi
+Swap:          2.0Gi          0B       2.0Gi
+`
+		usage, err := parseFree(output, 1)
+		if err != nil {
+			t.Fatalf("parseFree() error = %v", err)
+		}
+		if usage.Total != 15<<30 || usage.Free != 10<<30 {
+			t.Errorf("unexpected usage: %+v", usage)
+		}
+		if math.Round(usage.UsedPercent) != 33 {
+			t.Errorf("expected ~33%% used, got %.2f", usage.UsedPercent)
+		}
+	})
+
+	t.Run("without available column", func(t *testing.T) {
+		output := `             total       used       free     shared    buffers     cached
+Mem:          1024        768        256          0         10         20
+`
+		usage, err := parseFree(output, 1)
+		if err != nil {
+			t.Fatalf("parseFree() error = %v", err)
+		}
+		if usage.Free != 256 {
+			t.Errorf("expected free column fallback, got %+v", usage)
+		}
+	})
+
+	if _, err := parseFree("total used free\n", 1); err == nil {
+		t.Error("expected error without Mem row")
+	}
+}
+
+func TestParseWmic(t *testing.T) {
+	disk, err := parseWmicDisk("\r\n\r\nFreeSpace=107374182400\r\nSize=536870912000\r\n\r\n")
+	if err != nil {
+		t.Fatalf("parseWmicDisk() error = %v", err)
+	}
+	if disk.Total != 536870912000 || disk.Free != 107374182400 || disk.UsedPercent != 80 {
+		t.Errorf("unexpected disk usage: %+v", disk)
+	}
+
+	memory, err := parseWmicMemory("\r\nFreePhysicalMemory=4194304\r\nTotalVisibleMemorySize=16777216\r\n")
+	if err != nil {
+		t.Fatalf("parseWmicMemory() error = %v", err)
+	}
+	if memory.Total != 16<<30 || memory.Free != 4<<30 || memory.UsedPercent != 75 {
+		t.Errorf("unexpected memory usage: %+v", memory)
+	}
+
+	if _, err := parseWmicDisk("Size=\r\n"); err == nil {
+		t.Error("expected error for missing values")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value string
+		unit  int64
+		want  int64
+	}{
+		{"1024", 1024, 1 << 20},
+		{"512B", 1, 512},
+		{"0B", 1, 0},
+		{"20G", 1, 20 << 30},
+		{"1.5Gi", 1, 3 << 29},
+		{"100Mi", 1, 100 << 20},
+		{"2,5K", 1, 2560},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSize(tt.value, tt.unit)
+		if err != nil {
+			t.Errorf("parseSize(%q) error = %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSize(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "abc", "10X"} {
+		if _, err := parseSize(bad, 1); err == nil {
+			t.Errorf("parseSize(%q) expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{"warning", slog.LevelWarn},
+		{"error", slog.LevelError},
+		{"", slog.LevelInfo},
+		{"verbose", slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := ParseLevel(tt.input); got != tt.expected {
+				t.Errorf("ParseLevel(%q) = %v, expected %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, "warn", "text")
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.Warn("warn message")
+	logger.Error("error message")
+
+	output := buf.String()
+	for _, suppressed := range []string{"debug message", "info message"} {
+		if strings.Contains(output, suppressed) {
+			t.Errorf("Expected %q to be suppressed, got:\n%s", suppressed, output)
+		}
+	}
+	for _, emitted := range []string{"warn message", "error message"} {
+		if !strings.Contains(output, emitted) {
+			t.Errorf("Expected %q to be emitted, got:\n%s", emitted, output)
+		}
+	}
+}
+
+func TestNewLoggerJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, "debug", "json")
+
+	logger.Debug("task started", "task_id", "task-123")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected JSON log record, got %q: %v", buf.String(), err)
+	}
+
+	if record["msg"] != "task started" {
+		t.Errorf("Expected msg 'task started', got %v", record["msg"])
+	}
+
+	if record["task_id"] != "task-123" {
+		t.Errorf("Expected task_id 'task-123', got %v", record["task_id"])
+	}
+}
+
+func TestLoggerRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, "info", "json")
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	logger.With("component", "agent").InfoContext(ctx, "handled")
+	logger.Info("untagged")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %q", buf.String())
+	}
+
+	var tagged, untagged map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &tagged); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &untagged); err != nil {
+		t.Fatal(err)
+	}
+
+	if tagged[RequestIDKey] != "req-42" || tagged["component"] != "agent" {
+		t.Errorf("Expected request_id and component attributes, got %v", tagged)
+	}
+	if _, ok := untagged[RequestIDKey]; ok {
+		t.Errorf("Expected no request_id without a context ID, got %v", untagged)
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	a, b := NewRequestID(), NewRequestID()
+	if len(a) != 32 || a == b {
+		t.Errorf("Expected distinct 32-char IDs, got %q and %q", a, b)
+	}
+	if RequestID(WithRequestID(context.Background(), "")) != "" {
+		t.Error("Expected an empty ID to leave the context untouched")
+	}
+}
@@ -0,0 +1,200 @@
+package tasks
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/compose"
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+// stackUpdater abstracts the docker operations the auto-updater relies on
+type stackUpdater interface {
+	// imageIDs maps each image referenced by the project to its local image ID
+	imageIDs(ctx context.Context, composePath, projectName string) (map[string]string, error)
+	pull(ctx context.Context, composePath, projectName string) error
+	up(ctx context.Context, composePath, projectName string) error
+}
+
+type dockerStackUpdater struct {
+	client *docker.Client
+}
+
+func (d *dockerStackUpdater) imageIDs(ctx context.Context, composePath, projectName string) (map[string]string, error) {
+	output, err := d.client.ComposeConfig(ctx, composePath, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := compose.ParseProjectSpec([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[string]string{}
+	for _, image := range spec.ImageRefs() {
+		// Images that are not present locally yet are recorded with an empty ID
+		if details, err := d.client.InspectImage(ctx, image); err == nil {
+			ids[image], _ = details["Id"].(string)
+		} else {
+			ids[image] = ""
+		}
+	}
+	return ids, nil
+}
+
+func (d *dockerStackUpdater) pull(ctx context.Context, composePath, projectName string) error {
+	_, err := d.client.ComposePull(ctx, composePath, projectName, nil)
+	return err
+}
+
+func (d *dockerStackUpdater) up(ctx context.Context, composePath, projectName string) error {
+	_, err := d.client.ComposeUpWithProject(ctx, composePath, projectName)
+	return err
+}
+
+// autoUpdateEvents buffers update reports until the next heartbeat picks them up
+type autoUpdateEvents struct {
+	mu     sync.Mutex
+	events []map[string]interface{}
+}
+
+func (e *autoUpdateEvents) add(event map[string]interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+}
+
+func (e *autoUpdateEvents) drain() []map[string]interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	events := e.events
+	e.events = nil
+	return events
+}
+
+// DrainAutoUpdateEvents returns and clears the auto-update reports recorded
+// since the last call
+func (m *Manager) DrainAutoUpdateEvents() []map[string]interface{} {
+	return m.autoUpdates.drain()
+}
+
+// RunAutoUpdater periodically pulls images for stacks with auto-update enabled
+// and redeploys those whose images changed. It returns immediately unless
+// AUTO_UPDATE_ENABLED is set.
+func (m *Manager) RunAutoUpdater(ctx context.Context) {
+	if !m.config.AutoUpdateEnabled || m.config.AutoUpdateInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(m.config.AutoUpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.runAutoUpdateCycle(ctx)
+		}
+	}
+}
+
+// runAutoUpdateCycle checks every auto-update stack once and returns the names
+// of the stacks that were redeployed
+func (m *Manager) runAutoUpdateCycle(ctx context.Context) []string {
+	if m.MaintenanceMode() {
+		slog.Debug("Skipping auto-update in maintenance mode")
+		return nil
+	}
+
+	projects, err := m.composeManager.ListProjects()
+	if err != nil {
+		slog.Warn("Auto-update failed to list projects", "error", err)
+		return nil
+	}
+
+	updated := []string{}
+	for _, project := range projects {
+		projectName, _ := project["name"].(string)
+
+		meta, err := m.composeManager.LoadMetadata(projectName)
+		if err != nil || !meta.AutoUpdate {
+			continue
+		}
+
+		changed, err := m.autoUpdateStack(ctx, projectName)
+		if err != nil {
+			slog.Warn("Auto-update failed", "project", projectName, "error", err)
+			m.autoUpdates.add(map[string]interface{}{
+				"stack":     projectName,
+				"status":    "failed",
+				"error":     err.Error(),
+				"timestamp": time.Now().Unix(),
+			})
+			continue
+		}
+
+		if changed {
+			slog.Info("Auto-updated stack", "project", projectName)
+			updated = append(updated, projectName)
+			m.autoUpdates.add(map[string]interface{}{
+				"stack":     projectName,
+				"status":    "updated",
+				"timestamp": time.Now().Unix(),
+			})
+		}
+	}
+
+	return updated
+}
+
+// autoUpdateStack pulls a stack's images and redeploys only if an image changed
+func (m *Manager) autoUpdateStack(ctx context.Context, projectName string) (bool, error) {
+	release, err := m.locks.acquire(ctx, projectName, stackLockTimeout)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+	defer m.statusCache.invalidate()
+
+	_, composePath, err := m.getComposeProjectPath(map[string]interface{}{
+		"project_name": projectName,
+	})
+	if err != nil {
+		return false, err
+	}
+	ctx = m.composeContext(ctx, projectName, nil)
+
+	before, err := m.updater.imageIDs(ctx, composePath, projectName)
+	if err != nil {
+		return false, err
+	}
+
+	if err := m.updater.pull(ctx, composePath, projectName); err != nil {
+		return false, err
+	}
+
+	after, err := m.updater.imageIDs(ctx, composePath, projectName)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for image, id := range after {
+		if before[image] != id {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if err := m.updater.up(ctx, composePath, projectName); err != nil {
+		return false, err
+	}
+	return true, nil
+}
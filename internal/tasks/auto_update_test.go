@@ -0,0 +1,85 @@
+package tasks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/compose"
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+type stubUpdater struct {
+	before map[string]map[string]string
+	after  map[string]map[string]string
+	pulled map[string]bool
+	upped  []string
+}
+
+func (s *stubUpdater) imageIDs(ctx context.Context, composePath, projectName string) (map[string]string, error) {
+	if s.pulled[projectName] {
+		return s.after[projectName], nil
+	}
+	return s.before[projectName], nil
+}
+
+func (s *stubUpdater) pull(ctx context.Context, composePath, projectName string) error {
+	s.pulled[projectName] = true
+	return nil
+}
+
+func (s *stubUpdater) up(ctx context.Context, composePath, projectName string) error {
+	s.upped = append(s.upped, projectName)
+	return nil
+}
+
+func TestRunAutoUpdateCycle(t *testing.T) {
+	cfg := &config.Config{ComposeBasePath: t.TempDir()}
+	manager := NewManager(docker.NewClient(), cfg)
+
+	content := "services:\n  web:\n    image: nginx:latest\n"
+	enabled := true
+	for _, project := range []compose.ProjectConfig{
+		{Name: "changed", Content: content, AutoUpdate: &enabled},
+		{Name: "unchanged", Content: content, AutoUpdate: &enabled},
+		{Name: "optedout", Content: content},
+	} {
+		if err := manager.composeManager.CreateProject(project); err != nil {
+			t.Fatalf("CreateProject(%s) error = %v", project.Name, err)
+		}
+	}
+
+	stub := &stubUpdater{
+		before: map[string]map[string]string{
+			"changed":   {"nginx:latest": "sha256:old"},
+			"unchanged": {"nginx:latest": "sha256:same"},
+			"optedout":  {"nginx:latest": "sha256:old"},
+		},
+		after: map[string]map[string]string{
+			"changed":   {"nginx:latest": "sha256:new"},
+			"unchanged": {"nginx:latest": "sha256:same"},
+			"optedout":  {"nginx:latest": "sha256:new"},
+		},
+		pulled: map[string]bool{},
+	}
+	manager.updater = stub
+
+	updated := manager.runAutoUpdateCycle(context.Background())
+	if len(updated) != 1 || updated[0] != "changed" {
+		t.Errorf("runAutoUpdateCycle() = %v, want [changed]", updated)
+	}
+	if len(stub.upped) != 1 || stub.upped[0] != "changed" {
+		t.Errorf("redeployed %v, want [changed]", stub.upped)
+	}
+	if stub.pulled["optedout"] {
+		t.Error("expected stack without auto_update to be skipped")
+	}
+
+	events := manager.DrainAutoUpdateEvents()
+	if len(events) != 1 || events[0]["stack"] != "changed" {
+		t.Errorf("DrainAutoUpdateEvents() = %v, want one event for changed", events)
+	}
+	if remaining := manager.DrainAutoUpdateEvents(); len(remaining) != 0 {
+		t.Errorf("expected events to be cleared, got %v", remaining)
+	}
+}
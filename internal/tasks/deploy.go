@@ -0,0 +1,26 @@
+package tasks
+
+import (
+	"context"
+
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+// stackDeployer abstracts the compose operations used by compose_deploy
+type stackDeployer interface {
+	down(ctx context.Context, composePath, projectName string) error
+	up(ctx context.Context, composePath, projectName string, opts docker.ComposeUpOptions) (interface{}, error)
+}
+
+type dockerStackDeployer struct {
+	client *docker.Client
+}
+
+func (d *dockerStackDeployer) down(ctx context.Context, composePath, projectName string) error {
+	_, err := d.client.ComposeDownWithProject(ctx, composePath, projectName)
+	return err
+}
+
+func (d *dockerStackDeployer) up(ctx context.Context, composePath, projectName string, opts docker.ComposeUpOptions) (interface{}, error) {
+	return d.client.ComposeUpWithOptions(ctx, composePath, projectName, opts)
+}
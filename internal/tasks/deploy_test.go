@@ -0,0 +1,165 @@
+package tasks
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/compose"
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+type stubDeployer struct {
+	calls    []string
+	profiles [][]string
+	projects []string
+}
+
+func (s *stubDeployer) down(ctx context.Context, composePath, projectName string) error {
+	s.calls = append(s.calls, "down")
+	s.profiles = append(s.profiles, docker.ComposeProfiles(ctx))
+	s.projects = append(s.projects, docker.ComposeProjectName(ctx, projectName))
+	return nil
+}
+
+func (s *stubDeployer) up(ctx context.Context, composePath, projectName string, opts docker.ComposeUpOptions) (interface{}, error) {
+	s.calls = append(s.calls, "up")
+	s.profiles = append(s.profiles, docker.ComposeProfiles(ctx))
+	s.projects = append(s.projects, docker.ComposeProjectName(ctx, projectName))
+	return map[string]interface{}{"project_name": projectName}, nil
+}
+
+func TestExecuteComposeDeploy(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		want    []string
+	}{
+		{"default reconciles in place", map[string]interface{}{"project_name": "web"}, []string{"up"}},
+		{"recreate false", map[string]interface{}{"project_name": "web", "recreate": false}, []string{"up"}},
+		{"recreate", map[string]interface{}{"project_name": "web", "recreate": true}, []string{"down", "up"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+			if err := manager.composeManager.CreateProject(compose.ProjectConfig{
+				Name:    "web",
+				Content: "services:\n  web:\n    image: nginx:latest\n",
+			}); err != nil {
+				t.Fatalf("CreateProject() error = %v", err)
+			}
+
+			stub := &stubDeployer{}
+			manager.deployer = stub
+
+			if _, err := manager.ExecuteTask("compose_deploy", tt.payload); err != nil {
+				t.Fatalf("ExecuteTask() error = %v", err)
+			}
+			if len(stub.calls) != len(tt.want) {
+				t.Fatalf("calls = %v, want %v", stub.calls, tt.want)
+			}
+			for i := range tt.want {
+				if stub.calls[i] != tt.want[i] {
+					t.Errorf("calls = %v, want %v", stub.calls, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestComposeDeployProfiles(t *testing.T) {
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+	if err := manager.composeManager.CreateProject(compose.ProjectConfig{
+		Name:    "web",
+		Content: "services:\n  web:\n    image: nginx:latest\n",
+	}); err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+	stub := &stubDeployer{}
+	manager.deployer = stub
+
+	// Deploying with profiles persists them for the stack
+	if _, err := manager.ExecuteTask("compose_deploy", map[string]interface{}{
+		"project_name": "web",
+		"profiles":     []interface{}{"debug"},
+	}); err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+	meta, err := manager.composeManager.LoadMetadata("web")
+	if err != nil {
+		t.Fatalf("LoadMetadata() error = %v", err)
+	}
+	if !slices.Equal(meta.Profiles, []string{"debug"}) {
+		t.Errorf("persisted profiles = %v, want [debug]", meta.Profiles)
+	}
+
+	// A later recreate without profiles tears down with the persisted ones
+	if _, err := manager.ExecuteTask("compose_deploy", map[string]interface{}{
+		"project_name": "web",
+		"recreate":     true,
+	}); err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+	for i, profiles := range stub.profiles {
+		if !slices.Equal(profiles, []string{"debug"}) {
+			t.Errorf("call %d (%s) profiles = %v, want [debug]", i, stub.calls[i], profiles)
+		}
+	}
+
+	// An explicit empty list clears them
+	if _, err := manager.ExecuteTask("compose_deploy", map[string]interface{}{
+		"project_name": "web",
+		"profiles":     []interface{}{},
+	}); err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+	if ctx := manager.composeContext(context.Background(), "web", nil); len(docker.ComposeProfiles(ctx)) != 0 {
+		t.Errorf("profiles after clearing = %v", docker.ComposeProfiles(ctx))
+	}
+}
+
+func TestComposeDeployProjectName(t *testing.T) {
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+	stub := &stubDeployer{}
+	manager.deployer = stub
+
+	if _, err := manager.ExecuteTask("compose_create_project", map[string]interface{}{
+		"project_name":         "web",
+		"compose_content":      "services:\n  web:\n    image: nginx:latest\n",
+		"compose_project_name": "Bad Name",
+	}); err == nil {
+		t.Error("Expected error for an invalid compose project name")
+	}
+
+	// Without an override the folder name is the compose project
+	if _, err := manager.ExecuteTask("compose_create_project", map[string]interface{}{
+		"project_name":    "web",
+		"compose_content": "services:\n  web:\n    image: nginx:latest\n",
+	}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	if _, err := manager.ExecuteTask("compose_deploy", map[string]interface{}{"project_name": "web"}); err != nil {
+		t.Fatalf("deploy error = %v", err)
+	}
+
+	// The override recorded in metadata is used from then on, and an update
+	// that leaves it out keeps it
+	for _, payload := range []map[string]interface{}{
+		{"project_name": "web", "compose_content": "services:\n  web:\n    image: nginx:latest\n", "compose_project_name": "shared-infra"},
+		{"project_name": "web", "compose_content": "services:\n  web:\n    image: nginx:1.27\n"},
+	} {
+		if _, err := manager.ExecuteTask("compose_update_project", payload); err != nil {
+			t.Fatalf("update error = %v", err)
+		}
+		if _, err := manager.ExecuteTask("compose_deploy", map[string]interface{}{"project_name": "web", "recreate": true}); err != nil {
+			t.Fatalf("deploy error = %v", err)
+		}
+	}
+
+	want := []string{"web", "shared-infra", "shared-infra", "shared-infra", "shared-infra"}
+	if !slices.Equal(stub.projects, want) {
+		t.Errorf("compose projects = %v, want %v", stub.projects, want)
+	}
+}
@@ -0,0 +1,114 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"github.com/ofkm/arcane-agent/internal/config"
+)
+
+// Sources of an effective environment variable, lowest precedence first
+const (
+	envSourceFile     = "env_file"
+	envSourceOS       = "os"
+	envSourceOverride = "override"
+)
+
+// agentSecretEnvPatterns are the agent's own credentials and connection
+// settings. They are redacted whatever SECRET_ENV_PATTERNS says, since they
+// sit in the process environment of every stack's effective env.
+var agentSecretEnvPatterns = []string{"AGENT_TOKEN", "DOCKER_HOST", "*_PROXY"}
+
+// executeComposeEffectiveEnv returns the environment compose interpolates the
+// stack's files with: the project's .env, overridden by the agent's own
+// environment, overridden by env_overrides. Values of keys matching
+// SECRET_ENV_PATTERNS or the agent's own settings are redacted, and
+// STACK_ENV_ACCESS applies on top.
+func (m *Manager) executeComposeEffectiveEnv(payload map[string]interface{}) (interface{}, error) {
+	projectName, ok := payload["project_name"].(string)
+	if !ok || projectName == "" {
+		return nil, fmt.Errorf("project_name is required")
+	}
+	if m.envAccess() == config.StackEnvNone {
+		return nil, ErrEnvAccessDisabled
+	}
+
+	fileEnv := map[string]string{}
+	content, err := readStackFile(projectName, filepath.Join(m.composeManager.GetComposeDir(projectName), ".env"))
+	switch {
+	case err == nil:
+		if fileEnv, err = godotenv.Unmarshal(content); err != nil {
+			return nil, fmt.Errorf("failed to parse .env for project %s: %w", projectName, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+
+	env, sources := mergeEffectiveEnv(fileEnv, os.Environ(), getStringMap(payload, "env_overrides"))
+	redacted := redactSecretEnv(env, m.config.SecretEnvPatterns, m.envAccess() == config.StackEnvMasked)
+
+	return map[string]interface{}{
+		"project_name": projectName,
+		"environment":  env,
+		"sources":      sources,
+		"redacted":     redacted,
+	}, nil
+}
+
+// mergeEffectiveEnv layers the .env values, the process environment and the
+// overrides, recording where each final value came from
+func mergeEffectiveEnv(fileEnv map[string]string, environ []string, overrides map[string]string) (map[string]string, map[string]string) {
+	env := map[string]string{}
+	sources := map[string]string{}
+	set := func(key, value, source string) {
+		env[key] = value
+		sources[key] = source
+	}
+
+	for key, value := range fileEnv {
+		set(key, value, envSourceFile)
+	}
+	for _, entry := range environ {
+		if key, value, ok := strings.Cut(entry, "="); ok && key != "" {
+			set(key, value, envSourceOS)
+		}
+	}
+	for key, value := range overrides {
+		set(key, value, envSourceOverride)
+	}
+	return env, sources
+}
+
+// redactSecretEnv masks the values of keys matching one of the patterns or
+// agentSecretEnvPatterns, or of every key when maskAll is set, and returns the
+// masked keys sorted
+func redactSecretEnv(env map[string]string, patterns []string, maskAll bool) []string {
+	redacted := []string{}
+	for key := range env {
+		if maskAll || isSecretEnvKey(key, agentSecretEnvPatterns) || isSecretEnvKey(key, patterns) {
+			env[key] = maskedEnvValue
+			redacted = append(redacted, key)
+		}
+	}
+	sort.Strings(redacted)
+	return redacted
+}
+
+// isSecretEnvKey matches key against glob patterns such as "*PASSWORD*",
+// ignoring case
+func isSecretEnvKey(key string, patterns []string) bool {
+	key = strings.ToUpper(key)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToUpper(pattern), key); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/config"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// executeComposeEnvPreview renders the compose config with the project's
+// current .env and with proposed .env content, and returns a unified diff of
+// the two so a user can see which fields an edit changes before saving
+func (m *Manager) executeComposeEnvPreview(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+	env, ok := payload["env"].(string)
+	if !ok {
+		return nil, fmt.Errorf("env is required")
+	}
+	if m.envAccess() == config.StackEnvNone {
+		return nil, ErrEnvAccessDisabled
+	}
+
+	current, err := m.dockerClient.RenderComposeConfig(ctx, composePath, projectName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	envFile, err := os.CreateTemp("", "arcane-env-preview-*.env")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create env file: %w", err)
+	}
+	defer os.Remove(envFile.Name())
+	if _, err := envFile.WriteString(env); err != nil {
+		envFile.Close()
+		return nil, fmt.Errorf("failed to write env file: %w", err)
+	}
+	if err := envFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write env file: %w", err)
+	}
+
+	proposed, err := m.dockerClient.RenderComposeConfigWithEnvFile(ctx, composePath, projectName, envFile.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	diff := unifiedDiff("current", "proposed", current, proposed)
+	return map[string]interface{}{
+		"project_name": projectName,
+		"changed":      diff != "",
+		"diff":         diff,
+	}, nil
+}
+
+// unifiedDiff returns a line diff of a and b in unified format, or "" when
+// they are equal. Rendered compose files are small, so a plain LCS table is
+// fast enough.
+func unifiedDiff(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	x, y := splitLines(a), splitLines(b)
+
+	// lcs[i][j] is the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type edit struct {
+		op   byte
+		line string
+		i, j int // positions in x and y before this edit
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			edits = append(edits, edit{' ', x[i], i, j})
+			i++
+			j++
+		case j < len(y) && (i == len(x) || lcs[i][j+1] > lcs[i+1][j]):
+			edits = append(edits, edit{'+', y[j], i, j})
+			j++
+		default:
+			edits = append(edits, edit{'-', x[i], i, j})
+			i++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(edits); {
+		// Find the next change and the run of edits belonging to its hunk
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		first := max(start-diffContext, 0)
+		end, unchanged := start, 0
+		for end < len(edits) && unchanged <= 2*diffContext {
+			if edits[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+			end++
+		}
+		last := min(end-unchanged+diffContext, len(edits))
+
+		oldCount, newCount := 0, 0
+		for _, e := range edits[first:last] {
+			if e.op != '+' {
+				oldCount++
+			}
+			if e.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(edits[first].i, oldCount), hunkRange(edits[first].j, newCount))
+		for _, e := range edits[first:last] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			out.WriteByte('\n')
+		}
+		start = last
+	}
+	return out.String()
+}
+
+// hunkRange formats a hunk's 0-based start and length the way diff -u does
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
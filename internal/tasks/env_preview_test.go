@@ -0,0 +1,116 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/compose"
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	if got := unifiedDiff("a", "b", "same\n", "same\n"); got != "" {
+		t.Errorf("Expected no diff for equal input, got %q", got)
+	}
+
+	before := "services:\n  web:\n    image: nginx:1.25\n    ports:\n      - 80:80\n"
+	after := "services:\n  web:\n    image: nginx:1.27\n    ports:\n      - 80:80\n"
+	want := "--- current\n+++ proposed\n@@ -1,5 +1,5 @@\n services:\n   web:\n-    image: nginx:1.25\n+    image: nginx:1.27\n     ports:\n       - 80:80\n"
+	if got := unifiedDiff("current", "proposed", before, after); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Changes far apart land in separate hunks
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	a := strings.Join(lines, "\n")
+	lines[0], lines[19] = "first", "last"
+	b := strings.Join(lines, "\n")
+	diff := unifiedDiff("a", "b", a, b)
+	if strings.Count(diff, "@@ -") != 2 {
+		t.Errorf("Expected two hunks, got:\n%s", diff)
+	}
+	if !strings.Contains(diff, "@@ -1,4 +1,4 @@") || !strings.Contains(diff, "@@ -17,4 +17,4 @@") {
+		t.Errorf("Unexpected hunk headers:\n%s", diff)
+	}
+}
+
+func TestExecuteComposeEnvPreview(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker-compose is a shell script")
+	}
+
+	// The fake interpolates TAG from --env-file, or from the project .env
+	bin := t.TempDir()
+	script := `#!/bin/sh
+compose="" env_file=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    -f) compose="$2"; shift ;;
+    --env-file) env_file="$2"; shift ;;
+  esac
+  shift
+done
+[ -z "$env_file" ] && env_file="$(dirname "$compose")/.env"
+. "$env_file"
+printf 'services:\n  web:\n    image: nginx:%s\n    restart: always\n' "$TAG"
+`
+	if err := os.WriteFile(filepath.Join(bin, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker-compose: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := &config.Config{ComposeBasePath: t.TempDir()}
+	manager := NewManager(docker.NewClient(), cfg)
+	err := manager.composeManager.CreateProject(compose.ProjectConfig{
+		Name:    "web",
+		Content: "services:\n  web:\n    image: nginx:${TAG}\n",
+		EnvVars: map[string]string{"TAG": "1.25"},
+	})
+	if err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+
+	if _, err := manager.ExecuteTask("compose_env_preview", map[string]interface{}{"project_name": "web"}); err == nil {
+		t.Error("Expected error for missing env")
+	}
+
+	result, err := manager.ExecuteTask("compose_env_preview", map[string]interface{}{
+		"project_name": "web",
+		"env":          "TAG=1.27\n",
+	})
+	if err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+	preview := result.(map[string]interface{})
+	diff := preview["diff"].(string)
+	if preview["changed"] != true || !strings.Contains(diff, "-    image: nginx:1.25\n+    image: nginx:1.27\n") {
+		t.Errorf("Expected image tag change in diff, got %v", preview)
+	}
+	if strings.Contains(diff, "-    restart") || strings.Contains(diff, "+    restart") {
+		t.Errorf("Expected unaffected fields to be context only, got:\n%s", diff)
+	}
+
+	result, err = manager.ExecuteTask("compose_env_preview", map[string]interface{}{
+		"project_name": "web",
+		"env":          "TAG=1.25\n",
+	})
+	if err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+	if preview := result.(map[string]interface{}); preview["changed"] != false || preview["diff"] != "" {
+		t.Errorf("Expected no change for identical env, got %v", preview)
+	}
+
+	cfg.StackEnvAccess = config.StackEnvNone
+	if _, err := manager.ExecuteTask("compose_env_preview", map[string]interface{}{"project_name": "web", "env": ""}); err != ErrEnvAccessDisabled {
+		t.Errorf("Expected ErrEnvAccessDisabled, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package tasks
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/compose"
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+// digestSource resolves the local and registry digests of an image reference
+type digestSource interface {
+	// localDigests returns the repo digests of the locally stored image
+	localDigests(ctx context.Context, image string) ([]string, error)
+	// remoteDigest returns the digest the reference currently resolves to in its registry
+	remoteDigest(ctx context.Context, image string) (string, error)
+}
+
+type dockerDigestSource struct {
+	client *docker.Client
+}
+
+func (d *dockerDigestSource) localDigests(ctx context.Context, image string) ([]string, error) {
+	details, err := d.client.InspectImage(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := []string{}
+	if repoDigests, ok := details["RepoDigests"].([]interface{}); ok {
+		for _, entry := range repoDigests {
+			if ref, ok := entry.(string); ok {
+				// RepoDigests entries look like "nginx@sha256:..."
+				if idx := strings.LastIndex(ref, "@"); idx >= 0 {
+					digests = append(digests, ref[idx+1:])
+				}
+			}
+		}
+	}
+	return digests, nil
+}
+
+func (d *dockerDigestSource) remoteDigest(ctx context.Context, image string) (string, error) {
+	return d.client.RemoteImageDigest(ctx, image)
+}
+
+// executeComposeCheckUpdates reports which services of a stack have newer
+// images in their registry, without pulling anything
+func (m *Manager) executeComposeCheckUpdates(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := m.dockerClient.ComposeConfig(ctx, composePath, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := compose.ParseProjectSpec([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+
+	services := checkServiceUpdates(ctx, spec, m.digests)
+
+	updatesAvailable := 0
+	for _, service := range services {
+		if available, _ := service["update_available"].(bool); available {
+			updatesAvailable++
+		}
+	}
+
+	return map[string]interface{}{
+		"project_name":      projectName,
+		"services":          services,
+		"updates_available": updatesAvailable,
+	}, nil
+}
+
+// checkServiceUpdates compares local and registry digests for every service
+// with an image. Services whose digests cannot be resolved report an error
+// instead of failing the whole check.
+func checkServiceUpdates(ctx context.Context, spec *compose.ProjectSpec, source digestSource) []map[string]interface{} {
+	// Several services often share an image, so resolve each reference once
+	remote := map[string]string{}
+	remoteErrs := map[string]error{}
+
+	services := make([]map[string]interface{}, 0, len(spec.Services))
+	for _, name := range spec.ServiceNames() {
+		image := spec.Services[name].Image
+		if image == "" {
+			continue
+		}
+
+		entry := map[string]interface{}{
+			"service":          name,
+			"image":            image,
+			"update_available": false,
+		}
+		services = append(services, entry)
+
+		if _, seen := remote[image]; !seen && remoteErrs[image] == nil {
+			digest, err := source.remoteDigest(ctx, image)
+			if err != nil {
+				remoteErrs[image] = err
+			} else {
+				remote[image] = digest
+			}
+		}
+		if err := remoteErrs[image]; err != nil {
+			entry["error"] = err.Error()
+			continue
+		}
+		available := remote[image]
+		entry["available_digest"] = available
+
+		local, err := source.localDigests(ctx, image)
+		if err != nil {
+			// An image that was never pulled is always out of date
+			entry["update_available"] = true
+			continue
+		}
+		if len(local) > 0 {
+			entry["current_digest"] = local[0]
+		}
+
+		entry["update_available"] = true
+		for _, digest := range local {
+			if digest == available {
+				entry["current_digest"] = digest
+				entry["update_available"] = false
+				break
+			}
+		}
+	}
+
+	return services
+}
@@ -0,0 +1,98 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/compose"
+)
+
+type fakeDigestSource struct {
+	local  map[string][]string
+	remote map[string]string
+	calls  map[string]int
+}
+
+func (f *fakeDigestSource) localDigests(ctx context.Context, image string) ([]string, error) {
+	digests, ok := f.local[image]
+	if !ok {
+		return nil, errors.New("no such image")
+	}
+	return digests, nil
+}
+
+func (f *fakeDigestSource) remoteDigest(ctx context.Context, image string) (string, error) {
+	f.calls[image]++
+	digest, ok := f.remote[image]
+	if !ok {
+		return "", errors.New("manifest unknown")
+	}
+	return digest, nil
+}
+
+func TestCheckServiceUpdates(t *testing.T) {
+	spec := &compose.ProjectSpec{
+		Services: map[string]compose.ServiceSpec{
+			"web":    {Image: "nginx:latest"},
+			"worker": {Image: "nginx:latest"},
+			"db":     {Image: "postgres:16"},
+			"cache":  {Image: "redis:7"},
+			"local":  {Image: "myapp:dev"},
+			"built":  {},
+		},
+	}
+	source := &fakeDigestSource{
+		local: map[string][]string{
+			"nginx:latest": {"sha256:old"},
+			"postgres:16":  {"sha256:mirror", "sha256:pg"},
+		},
+		remote: map[string]string{
+			"nginx:latest": "sha256:new",
+			"postgres:16":  "sha256:pg",
+			"redis:7":      "sha256:redis",
+		},
+		calls: map[string]int{},
+	}
+
+	results := checkServiceUpdates(context.Background(), spec, source)
+
+	byService := map[string]map[string]interface{}{}
+	for _, result := range results {
+		byService[result["service"].(string)] = result
+	}
+
+	if _, ok := byService["built"]; ok {
+		t.Error("expected service without image to be skipped")
+	}
+
+	tests := []struct {
+		service string
+		want    bool
+		current interface{}
+	}{
+		{"web", true, "sha256:old"},
+		{"worker", true, "sha256:old"},
+		{"db", false, "sha256:pg"},
+		{"cache", true, nil},
+	}
+	for _, tt := range tests {
+		result := byService[tt.service]
+		if result["update_available"] != tt.want {
+			t.Errorf("%s update_available = %v, want %v", tt.service, result["update_available"], tt.want)
+		}
+		if result["current_digest"] != tt.current {
+			t.Errorf("%s current_digest = %v, want %v", tt.service, result["current_digest"], tt.current)
+		}
+	}
+
+	if byService["web"]["available_digest"] != "sha256:new" {
+		t.Errorf("web available_digest = %v, want sha256:new", byService["web"]["available_digest"])
+	}
+	if _, ok := byService["local"]["error"]; !ok {
+		t.Error("expected error for image missing from the registry")
+	}
+	if source.calls["nginx:latest"] != 1 {
+		t.Errorf("expected shared image to be resolved once, got %d calls", source.calls["nginx:latest"])
+	}
+}
@@ -0,0 +1,84 @@
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// parseLogGrep compiles the optional grep pattern used to filter log lines
+// on the agent before they are sent. It returns nil when no pattern is set.
+func parseLogGrep(payload map[string]interface{}) (*regexp.Regexp, error) {
+	pattern, _ := payload["grep"].(string)
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid grep pattern: %v", errdefs.ErrInvalidInput, err)
+	}
+	return re, nil
+}
+
+// grepLogs keeps the "logs" lines of a docker logs result that match re
+func grepLogs(result interface{}, re *regexp.Regexp) interface{} {
+	if re == nil {
+		return result
+	}
+	if resultMap, ok := result.(map[string]interface{}); ok {
+		if logs, ok := resultMap["logs"].(string); ok {
+			resultMap["logs"] = filterLogLines(logs, re)
+		}
+	}
+	return result
+}
+
+// filterLogLines returns the lines of logs that match re
+func filterLogLines(logs string, re *regexp.Regexp) string {
+	var matched []string
+	for _, line := range strings.Split(logs, "\n") {
+		if re.MatchString(line) {
+			matched = append(matched, line)
+		}
+	}
+	return strings.Join(matched, "\n")
+}
+
+// grepReader streams only the lines of the underlying reader that match re
+type grepReader struct {
+	io.Closer
+	lines   *bufio.Reader
+	re      *regexp.Regexp
+	pending []byte
+	err     error
+}
+
+func newGrepReader(r io.ReadCloser, re *regexp.Regexp) io.ReadCloser {
+	if re == nil {
+		return r
+	}
+	return &grepReader{Closer: r, lines: bufio.NewReader(r), re: re}
+}
+
+func (g *grepReader) Read(p []byte) (int, error) {
+	for len(g.pending) == 0 {
+		if g.err != nil {
+			return 0, g.err
+		}
+		line, err := g.lines.ReadBytes('\n')
+		if len(line) > 0 && g.re.Match(bytes.TrimSuffix(line, []byte("\n"))) {
+			g.pending = line
+		}
+		if err != nil {
+			g.err = err
+		}
+	}
+	n := copy(p, g.pending)
+	g.pending = g.pending[n:]
+	return n, nil
+}
@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+const sampleLogs = "GET /health 200\nPOST /login 500 upstream timeout\nGET /api/items 200\nPOST /checkout 502 bad gateway"
+
+func TestParseLogGrep(t *testing.T) {
+	if re, err := parseLogGrep(map[string]interface{}{}); re != nil || err != nil {
+		t.Errorf("Expected no filter without grep, got %v, %v", re, err)
+	}
+
+	_, err := parseLogGrep(map[string]interface{}{"grep": "(unclosed"})
+	if !errors.Is(err, errdefs.ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for invalid pattern, got %v", err)
+	}
+}
+
+func TestFilterLogLines(t *testing.T) {
+	re, err := parseLogGrep(map[string]interface{}{"grep": ` 5\d\d `})
+	if err != nil {
+		t.Fatalf("parseLogGrep() error = %v", err)
+	}
+
+	want := "POST /login 500 upstream timeout\nPOST /checkout 502 bad gateway"
+	if got := filterLogLines(sampleLogs, re); got != want {
+		t.Errorf("filterLogLines() = %q, want %q", got, want)
+	}
+
+	result := grepLogs(map[string]interface{}{"container_id": "web", "logs": sampleLogs}, re)
+	if logs := result.(map[string]interface{})["logs"]; logs != want {
+		t.Errorf("grepLogs() logs = %q, want %q", logs, want)
+	}
+}
+
+func TestGrepReader(t *testing.T) {
+	re, err := parseLogGrep(map[string]interface{}{"grep": "^POST.*(timeout|gateway)$"})
+	if err != nil {
+		t.Fatalf("parseLogGrep() error = %v", err)
+	}
+
+	reader := newGrepReader(io.NopCloser(strings.NewReader(sampleLogs)), re)
+	// A small buffer exercises lines split across reads
+	var out strings.Builder
+	buf := make([]byte, 7)
+	for {
+		n, err := reader.Read(buf)
+		out.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+	}
+
+	want := "POST /login 500 upstream timeout\nPOST /checkout 502 bad gateway"
+	if out.String() != want {
+		t.Errorf("grepReader emitted %q, want %q", out.String(), want)
+	}
+}
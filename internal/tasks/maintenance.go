@@ -0,0 +1,84 @@
+package tasks
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// readOnlyTasks lists the task types still allowed in maintenance mode. Any
+// other task type, including ones added later, is treated as mutating. Tasks
+// that can write an archive or log file to the host are left out too.
+var readOnlyTasks = map[string]bool{
+	"task_list":             true,
+	"task_get":              true,
+	"task_cancel":           true,
+	"maintenance_mode":      true,
+	"container_list":        true,
+	"container_inspect":     true,
+	"container_diff":        true,
+	"container_logs":        true,
+	"container_ports":       true,
+	"container_top":         true,
+	"container_stats":       true,
+	"image_list":            true,
+	"image_inspect":         true,
+	"image_history":         true,
+	"image_scan":            true,
+	"image_tags":            true,
+	"system_info":           true,
+	"system_facts":          true,
+	"docker_info":           true,
+	"metrics":               true,
+	"docker_events":         true,
+	"system_df":             true,
+	"volume_browse":         true,
+	"volume_size":           true,
+	"compose_ps":            true,
+	"compose_logs":          true,
+	"compose_config":        true,
+	"compose_validate":      true,
+	"compose_env_preview":   true,
+	"compose_spec":          true,
+	"stack_audit":           true,
+	"compose_topology":      true,
+	"compose_declared":      true,
+	"compose_check_updates": true,
+	"compose_list_projects": true,
+	"compose_get_file":      true,
+	"compose_get_env":       true,
+	"compose_effective_env": true,
+	"stack_list":            true,
+	"stack_services":        true,
+	"stack_volumes":         true,
+}
+
+// MaintenanceMode reports whether mutating tasks are currently refused
+func (m *Manager) MaintenanceMode() bool {
+	return m.maintenance.Load()
+}
+
+// SetMaintenanceMode turns maintenance mode on or off
+func (m *Manager) SetMaintenanceMode(enabled bool) {
+	if m.maintenance.Swap(enabled) != enabled {
+		slog.Info("Maintenance mode changed", "enabled", enabled)
+	}
+}
+
+// checkMaintenance refuses mutating task types while in maintenance mode
+func (m *Manager) checkMaintenance(taskType string) error {
+	if m.maintenance.Load() && !readOnlyTasks[taskType] {
+		return fmt.Errorf("%w: %s is not allowed while the agent is in maintenance mode", errdefs.ErrMaintenance, taskType)
+	}
+	return nil
+}
+
+// executeMaintenanceMode reports maintenance mode, switching it first when
+// the payload sets enabled
+func (m *Manager) executeMaintenanceMode(payload map[string]interface{}) (interface{}, error) {
+	if enabled, ok := payload["enabled"].(bool); ok {
+		m.SetMaintenanceMode(enabled)
+	}
+	return map[string]interface{}{"maintenance_mode": m.MaintenanceMode()}, nil
+}
@@ -0,0 +1,74 @@
+package tasks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+func TestMaintenanceModeGatesMutatingTasks(t *testing.T) {
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir(), MaintenanceMode: true})
+
+	tests := []struct {
+		taskType string
+		blocked  bool
+	}{
+		{"compose_deploy", true},
+		{"compose_down", true},
+		{"container_stop", true},
+		{"container_remove", true},
+		{"image_pull", true},
+		{"docker_command", true},
+		{"container_export", true},
+		{"some_future_task", true},
+		{"container_list", false},
+		{"compose_ps", false},
+		{"stack_list", false},
+		{"task_get", false},
+		{"maintenance_mode", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.taskType, func(t *testing.T) {
+			err := manager.checkMaintenance(tt.taskType)
+			if blocked := errors.Is(err, errdefs.ErrMaintenance); blocked != tt.blocked {
+				t.Errorf("checkMaintenance(%s) = %v, want blocked %v", tt.taskType, err, tt.blocked)
+			}
+		})
+	}
+
+	if _, err := manager.ExecuteTask("container_stop", map[string]interface{}{"container_id": "web"}); errdefs.HTTPStatus(err) != 503 {
+		t.Errorf("Expected 503 for a mutating task, got %v", err)
+	}
+}
+
+func TestMaintenanceModeTask(t *testing.T) {
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+
+	result, err := manager.ExecuteTask("maintenance_mode", map[string]interface{}{"enabled": true})
+	if err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+	if result.(map[string]interface{})["maintenance_mode"] != true || !manager.MaintenanceMode() {
+		t.Fatalf("Expected maintenance mode enabled, got %v", result)
+	}
+	if err := manager.checkMaintenance("compose_up"); err == nil {
+		t.Error("Expected compose_up to be refused in maintenance mode")
+	}
+
+	// Without enabled the task only reports the current state
+	result, _ = manager.ExecuteTask("maintenance_mode", map[string]interface{}{})
+	if result.(map[string]interface{})["maintenance_mode"] != true {
+		t.Errorf("Expected maintenance mode to stay enabled, got %v", result)
+	}
+
+	if _, err := manager.ExecuteTask("maintenance_mode", map[string]interface{}{"enabled": false}); err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+	if err := manager.checkMaintenance("compose_up"); err != nil {
+		t.Errorf("Expected compose_up to be allowed again, got %v", err)
+	}
+}
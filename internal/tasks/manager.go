@@ -2,22 +2,48 @@
 package tasks
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"github.com/ofkm/arcane-agent/internal/compose"
 	"github.com/ofkm/arcane-agent/internal/config"
 	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/errdefs"
 )
 
 type Manager struct {
 	dockerClient   *docker.Client
 	composeManager *compose.Manager
 	config         *config.Config
+	statusCache    *stackStatusCache
+	updater        stackUpdater
+	deployer       stackDeployer
+	autoUpdates    *autoUpdateEvents
+	digests        digestSource
+	locks          *stackLocks
+	volumeSizes    *volumeSizeCache
+	metricsSource  metricsSource
+	metricsCache   *metricsCache
+	history        *taskHistory
+	running        *runningTasks
+	maintenance    atomic.Bool
+	startedAt      time.Time
 }
 
 func NewManager(dockerClient *docker.Client, cfg *config.Config) *Manager {
@@ -26,22 +52,78 @@ func NewManager(dockerClient *docker.Client, cfg *config.Config) *Manager {
 	// Ensure base directory exists
 	if err := composeManager.EnsureBaseDirectory(); err != nil {
 		// Log error but don't fail initialization
-		fmt.Printf("Warning: failed to create compose base directory: %v\n", err)
+		slog.Warn("Failed to create compose base directory", "error", err)
 	}
 
-	return &Manager{
+	m := &Manager{
 		dockerClient:   dockerClient,
 		composeManager: composeManager,
 		config:         cfg,
+		statusCache:    &stackStatusCache{},
+		updater:        &dockerStackUpdater{client: dockerClient},
+		deployer:       &dockerStackDeployer{client: dockerClient},
+		autoUpdates:    &autoUpdateEvents{},
+		digests:        &dockerDigestSource{client: dockerClient},
+		locks:          newStackLocks(),
+		volumeSizes:    &volumeSizeCache{},
+		metricsSource:  &dockerMetricsSource{client: dockerClient, basePath: cfg.ComposeBasePath},
+		metricsCache:   &metricsCache{},
+		history:        newTaskHistory(cfg.TaskHistorySize),
+		running:        newRunningTasks(),
+		startedAt:      time.Now(),
 	}
+	m.maintenance.Store(cfg.MaintenanceMode)
+	return m
 }
 
 func (m *Manager) ExecuteTask(taskType string, payload map[string]interface{}) (interface{}, error) {
-	ctx := context.Background()
+	return m.ExecuteTaskContext(context.Background(), taskType, payload)
+}
+
+// ExecuteTaskContext runs a task with ctx, which carries the task's
+// correlation ID into the log lines written while it executes
+func (m *Manager) ExecuteTaskContext(ctx context.Context, taskType string, payload map[string]interface{}) (interface{}, error) {
+	if err := m.checkMaintenance(taskType); err != nil {
+		return nil, err
+	}
+
+	// Project names become directory names under the compose base path
+	for _, key := range []string{"project_name", "stack_name"} {
+		if projectName, ok := payload[key].(string); ok && projectName != "" {
+			if err := compose.ValidateProjectName(projectName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if mutatingStackTasks[taskType] {
+		if projectName, ok := payload["project_name"].(string); ok && projectName != "" {
+			release, err := m.locks.acquire(ctx, projectName, stackLockTimeout)
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+		}
+		// Runs before the lock is released. Failed tasks may have changed
+		// the stack part way, so the cache is dropped either way.
+		defer m.statusCache.invalidate()
+	}
+
+	if projectName, ok := payload["project_name"].(string); ok && projectName != "" {
+		ctx = m.composeContext(ctx, projectName, payload)
+	}
 
 	switch taskType {
+	case "task_list":
+		return m.history.list(), nil
+	case "task_get":
+		return m.executeTaskGet(payload)
+	case "task_cancel":
+		return m.executeTaskCancel(payload)
+	case "maintenance_mode":
+		return m.executeMaintenanceMode(payload)
 	case "docker_command":
-		return m.executeDockerCommand(payload)
+		return m.executeDockerCommand(ctx, payload)
 	case "container_start":
 		return m.executeContainerStart(ctx, payload)
 	case "container_stop":
@@ -49,33 +131,130 @@ func (m *Manager) ExecuteTask(taskType string, payload map[string]interface{}) (
 	case "container_restart":
 		return m.executeContainerRestart(ctx, payload)
 	case "container_list":
-		return m.dockerClient.ListContainers(ctx)
+		return m.executeContainerList(ctx, payload)
+	case "container_pause":
+		return m.executeContainerPause(ctx, payload)
+	case "container_unpause":
+		return m.executeContainerUnpause(ctx, payload)
 	case "container_remove":
 		return m.executeContainerRemove(ctx, payload)
+	case "container_inspect":
+		return m.executeContainerInspect(ctx, payload)
+	case "container_update":
+		return m.executeContainerUpdate(ctx, payload)
+	case "container_diff":
+		return m.executeContainerDiff(ctx, payload)
+	case "container_export":
+		return m.executeContainerExport(ctx, payload)
+	case "container_copy_to":
+		return m.executeContainerCopyTo(ctx, payload)
+	case "container_copy_from":
+		return m.executeContainerCopyFrom(ctx, payload)
+	case "container_rename":
+		return m.executeContainerRename(ctx, payload)
 	case "container_logs":
 		return m.executeContainerLogs(ctx, payload)
+	case "container_logs_download":
+		return m.executeContainerLogsDownload(ctx, payload)
+	case "container_ports":
+		return m.executeContainerPorts(ctx, payload)
+	case "run_job":
+		return m.executeRunJob(ctx, payload)
+	case "container_commit":
+		return m.executeContainerCommit(ctx, payload)
+	case "container_top":
+		return m.executeContainerTop(ctx, payload)
+	case "container_stats":
+		containerID, _ := payload["container_id"].(string)
+		return m.dockerClient.GetContainerStats(ctx, containerID)
 	case "image_pull":
 		return m.executeImagePull(ctx, payload)
 	case "image_list":
-		return m.dockerClient.ListImages(ctx)
+		return m.executeImageList(ctx, payload)
+	case "image_prune":
+		return m.executeImagePrune(ctx, payload)
+	case "image_inspect":
+		return m.executeImageInspect(ctx, payload)
+	case "image_history":
+		return m.executeImageHistory(ctx, payload)
+	case "image_save":
+		return m.executeImageSave(ctx, payload)
+	case "image_delete":
+		return m.executeImageDelete(ctx, payload)
+	case "image_scan":
+		return m.executeImageScan(ctx, payload)
+	case "image_tags":
+		return m.executeImageTags(ctx, payload)
+	case "image_load":
+		return m.executeImageLoad(ctx, payload)
+	case "container_prune":
+		return m.dockerClient.PruneContainers(ctx, getFilters(payload))
+	case "builder_prune":
+		return m.dockerClient.PruneBuildCache(ctx, getFilters(payload))
+	case "system_prune":
+		return m.executeSystemPrune(ctx, payload)
 	case "system_info":
 		return m.dockerClient.GetSystemInfo(ctx)
+	case "system_facts":
+		return m.executeSystemFacts(ctx)
+	case "docker_info":
+		return m.dockerClient.GetDockerInfo(ctx)
 	case "metrics":
 		return m.dockerClient.GetMetrics(ctx)
+	case "docker_events":
+		return m.executeDockerEvents(ctx, payload)
+	case "system_df":
+		return m.dockerClient.GetDiskUsage(ctx)
+	case "volume_create":
+		return m.executeVolumeCreate(ctx, payload)
+	case "volume_browse":
+		return m.executeVolumeBrowse(ctx, payload)
+	case "volume_size":
+		return m.executeVolumeSize(ctx, payload)
 
 	// Compose operations
 	case "compose_up":
 		return m.executeComposeUp(ctx, payload)
 	case "compose_down":
 		return m.executeComposeDown(ctx, payload)
+	case "compose_create":
+		return m.executeComposeCreate(ctx, payload)
+	case "compose_start":
+		return m.executeComposeStart(ctx, payload)
+	case "stack_prune":
+		return m.executeStackPrune(ctx, payload)
 	case "compose_ps":
 		return m.executeComposePs(ctx, payload)
+	case "compose_restart_service":
+		return m.executeComposeRestartService(ctx, payload)
+	case "compose_pause":
+		return m.executeComposePause(ctx, payload)
+	case "compose_unpause":
+		return m.executeComposeUnpause(ctx, payload)
 	case "compose_logs":
 		return m.executeComposeLogs(ctx, payload)
 	case "compose_deploy":
 		return m.executeComposeDeploy(ctx, payload)
 	case "compose_remove":
 		return m.executeComposeRemove(ctx, payload)
+	case "compose_config":
+		return m.executeComposeConfig(ctx, payload)
+	case "compose_validate":
+		return m.executeComposeValidate(ctx, payload)
+	case "compose_env_preview":
+		return m.executeComposeEnvPreview(ctx, payload)
+	case "compose_spec":
+		return m.executeComposeSpec(ctx, payload)
+	case "stack_audit":
+		return m.executeStackAudit(ctx, payload)
+	case "compose_topology":
+		return m.executeComposeTopology(ctx, payload)
+	case "compose_declared":
+		return m.executeComposeDeclared(ctx, payload)
+	case "compose_pull":
+		return m.executeComposePull(ctx, payload)
+	case "compose_check_updates":
+		return m.executeComposeCheckUpdates(ctx, payload)
 
 	// Compose project management
 	case "compose_create_project":
@@ -86,18 +265,36 @@ func (m *Manager) ExecuteTask(taskType string, payload map[string]interface{}) (
 		return m.executeComposeDeleteProject(payload)
 	case "compose_list_projects":
 		return m.executeComposeListProjects()
+	case "compose_create_from_git":
+		return m.executeComposeCreateFromGit(ctx, payload)
+	case "compose_git_pull":
+		return m.executeComposeGitPull(ctx, payload)
+	case "compose_import_archive":
+		return m.executeComposeImportArchive(payload)
+	case "compose_export_archive":
+		return m.executeComposeExportArchive(payload)
+	case "compose_get_file":
+		return m.executeComposeGetFile(payload)
+	case "compose_get_env":
+		return m.executeComposeGetEnv(payload)
+	case "compose_effective_env":
+		return m.executeComposeEffectiveEnv(payload)
 
 	case "stack_list":
-		return m.executeStackList(ctx)
+		return m.executeStackList(ctx, payload)
+	case "stack_volumes":
+		return m.executeStackVolumes(ctx, payload)
 	case "stack_services":
 		return m.executeStackServices(ctx, payload)
+	case "stack_batch":
+		return m.executeStackBatch(ctx, payload)
 
 	default:
-		return nil, fmt.Errorf("unknown task type: %s", taskType)
+		return nil, fmt.Errorf("%w: unknown task type: %s", errdefs.ErrInvalidInput, taskType)
 	}
 }
 
-func (m *Manager) executeDockerCommand(payload map[string]interface{}) (interface{}, error) {
+func (m *Manager) executeDockerCommand(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
 	command, ok := payload["command"].(string)
 	if !ok {
 		return nil, fmt.Errorf("missing command")
@@ -114,7 +311,11 @@ func (m *Manager) executeDockerCommand(payload map[string]interface{}) (interfac
 		}
 	}
 
-	output, err := m.dockerClient.ExecuteCommand(command, args)
+	if err := validateDockerCommand(command, args, m.config.AllowedBindPaths); err != nil {
+		return nil, err
+	}
+
+	output, err := m.dockerClient.ExecuteCommand(ctx, command, args)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +341,27 @@ func (m *Manager) executeContainerStop(ctx context.Context, payload map[string]i
 		return nil, fmt.Errorf("missing container_id")
 	}
 
-	return m.dockerClient.StopContainer(ctx, containerID)
+	timeout, err := parseStopTimeout(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dockerClient.StopContainerWithTimeout(ctx, containerID, timeout)
+}
+
+// parseStopTimeout reads the optional "timeout" payload field in whole seconds
+func parseStopTimeout(payload map[string]interface{}) (*int, error) {
+	value, exists := payload["timeout"]
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	seconds, ok := value.(float64)
+	if !ok || seconds < 0 || seconds != float64(int(seconds)) {
+		return nil, fmt.Errorf("timeout must be a non-negative number of seconds")
+	}
+	timeout := int(seconds)
+	return &timeout, nil
 }
 
 func (m *Manager) executeContainerRestart(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
@@ -152,6 +373,24 @@ func (m *Manager) executeContainerRestart(ctx context.Context, payload map[strin
 	return m.dockerClient.RestartContainer(ctx, containerID)
 }
 
+func (m *Manager) executeContainerPause(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing container_id")
+	}
+
+	return m.dockerClient.PauseContainer(ctx, containerID)
+}
+
+func (m *Manager) executeContainerUnpause(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing container_id")
+	}
+
+	return m.dockerClient.UnpauseContainer(ctx, containerID)
+}
+
 func (m *Manager) executeContainerRemove(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
 	containerID, ok := payload["container_id"].(string)
 	if !ok {
@@ -166,220 +405,1614 @@ func (m *Manager) executeContainerRemove(ctx context.Context, payload map[string
 	return m.dockerClient.RemoveContainer(ctx, containerID, force)
 }
 
-func (m *Manager) executeContainerLogs(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
-	containerID, ok := payload["container_id"].(string)
+// executeContainerList lists containers, narrowed by the status, label and
+// name shorthands or raw filters, and paged with limit/offset
+func (m *Manager) executeContainerList(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	result, err := m.dockerClient.ListContainersWithFilters(ctx, containerListFilters(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateResult(result, "containers", payload), nil
+}
+
+// executeImageList lists images, narrowed by the dangling and reference
+// shorthands or raw filters, and paged with limit/offset
+func (m *Manager) executeImageList(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	result, err := m.dockerClient.ListImagesWithFilters(ctx, imageListFilters(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateResult(result, "images", payload), nil
+}
+
+func containerListFilters(payload map[string]interface{}) map[string][]string {
+	filters := getFilters(payload)
+	for _, key := range []string{"status", "label", "name"} {
+		filters[key] = append(filters[key], getStringValues(payload, key)...)
+	}
+	return filters
+}
+
+func imageListFilters(payload map[string]interface{}) map[string][]string {
+	filters := getFilters(payload)
+	if dangling, ok := payload["dangling"].(bool); ok {
+		filters["dangling"] = append(filters["dangling"], strconv.FormatBool(dangling))
+	}
+	filters["reference"] = append(filters["reference"], getStringValues(payload, "reference")...)
+	return filters
+}
+
+// paginateResult slices the list stored under key in a docker list result
+// using the payload's limit and offset, and reports the unpaged total
+func paginateResult(result interface{}, key string, payload map[string]interface{}) interface{} {
+	resultMap, ok := result.(map[string]interface{})
 	if !ok {
+		return result
+	}
+	items, ok := resultMap[key].([]interface{})
+	if !ok {
+		return result
+	}
+
+	// Clamp while still a float: huge values overflow when converted to int
+	total := len(items)
+	offset := 0
+	if o, ok := payload["offset"].(float64); ok && o > 0 {
+		offset = total
+		if o < float64(total) {
+			offset = int(o)
+		}
+	}
+	end := total
+	if l, ok := payload["limit"].(float64); ok && l > 0 && l < float64(total-offset) {
+		end = offset + int(l)
+	}
+
+	resultMap[key] = items[offset:end]
+	resultMap["total"] = total
+	resultMap["offset"] = offset
+	return resultMap
+}
+
+func (m *Manager) executeContainerInspect(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok || containerID == "" {
 		return nil, fmt.Errorf("missing container_id")
 	}
 
-	tail := 100
-	if t, ok := payload["tail"].(float64); ok {
-		tail = int(t)
+	return m.dockerClient.InspectContainer(ctx, containerID)
+}
+
+func (m *Manager) executeContainerDiff(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok || containerID == "" {
+		return nil, fmt.Errorf("missing container_id")
+	}
+
+	changes, err := m.dockerClient.ContainerDiff(ctx, containerID)
+	if err != nil {
+		return nil, err
 	}
 
-	return m.dockerClient.GetContainerLogs(ctx, containerID, tail)
+	return map[string]interface{}{
+		"container_id": containerID,
+		"changes":      changes,
+	}, nil
 }
 
-func (m *Manager) executeImagePull(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
-	var image string
-	var ok bool
+// executeContainerExport writes a container's filesystem tar to a host path.
+// When output_path is a directory the archive is named after the container.
+func (m *Manager) executeContainerExport(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok || containerID == "" {
+		return nil, fmt.Errorf("missing container_id")
+	}
 
-	if image, ok = payload["imageName"].(string); !ok {
-		if image, ok = payload["image"].(string); !ok {
-			return nil, fmt.Errorf("missing imageName or image")
-		}
+	outputPath, ok := payload["output_path"].(string)
+	if !ok || !filepath.IsAbs(outputPath) {
+		return nil, fmt.Errorf("output_path must be an absolute path")
 	}
 
-	result, err := m.dockerClient.PullImage(ctx, image)
+	if info, err := os.Stat(outputPath); err == nil && info.IsDir() {
+		outputPath = filepath.Join(outputPath, docker.ImageArchiveName(containerID))
+	}
+
+	reader, err := m.dockerClient.ContainerExport(ctx, containerID)
 	if err != nil {
-		return map[string]interface{}{
-			"status": "failed",
-			"error":  fmt.Sprintf("Failed to pull image %s: %v", image, err),
-		}, nil
+		return nil, err
 	}
 
-	var output string
-	if resultMap, ok := result.(map[string]interface{}); ok {
-		if outputStr, exists := resultMap["output"]; exists {
-			output = fmt.Sprintf("%v", outputStr)
-		}
+	size, err := writeStreamToFile(outputPath, reader)
+	if err != nil {
+		return nil, err
 	}
 
 	return map[string]interface{}{
-		"status": "completed",
-		"result": map[string]interface{}{
-			"output": output,
-			"image":  image,
-		},
+		"container_id": containerID,
+		"path":         outputPath,
+		"size":         size,
+		"status":       "exported",
 	}, nil
 }
 
-// New Compose methods with project-based paths
-func (m *Manager) executeComposeUp(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
-	projectName, composePath, err := m.getComposeProjectPath(payload)
+// executeContainerCopyTo extracts a tar archive, given base64-encoded as
+// archive or as an absolute archive_path on the host, into path inside a container
+func (m *Manager) executeContainerCopyTo(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok || containerID == "" {
+		return nil, fmt.Errorf("missing container_id")
+	}
+	destPath, ok := payload["path"].(string)
+	if !ok || destPath == "" {
+		return nil, fmt.Errorf("missing path")
+	}
+
+	var archive io.Reader
+	if encoded, ok := payload["archive"].(string); ok && encoded != "" {
+		archive = base64.NewDecoder(base64.StdEncoding, strings.NewReader(encoded))
+	} else if archivePath, ok := payload["archive_path"].(string); ok && filepath.IsAbs(archivePath) {
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", archivePath, err)
+		}
+		defer file.Close()
+		archive = file
+	} else {
+		return nil, fmt.Errorf("archive or an absolute archive_path is required")
+	}
+
+	return m.dockerClient.CopyToContainer(ctx, containerID, archive, destPath)
+}
+
+// maxInlineSize caps archives and logs returned inline in a task result;
+// larger ones must be written to output_path
+const maxInlineSize = 100 << 20
+
+// errTooLarge is returned by readLimited when the input exceeds its limit
+var errTooLarge = errors.New("input too large")
+
+// readLimited reads r to the end, or fails with errTooLarge once more than
+// limit bytes have been read
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err == nil && int64(len(data)) > limit {
+		return nil, errTooLarge
+	}
+	return data, err
+}
+
+// limitedWriter passes writes through to w until more than limit bytes have
+// been written, then fails with errTooLarge
+type limitedWriter struct {
+	w     io.Writer
+	limit int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.limit {
+		return 0, errTooLarge
+	}
+	l.limit -= int64(len(p))
+	return l.w.Write(p)
+}
+
+// executeContainerCopyFrom archives path from a container as a tar. The tar is
+// written to output_path when given (a directory gets a name derived from
+// path), otherwise it is returned base64-encoded up to maxInlineSize.
+func (m *Manager) executeContainerCopyFrom(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok || containerID == "" {
+		return nil, fmt.Errorf("missing container_id")
+	}
+	srcPath, ok := payload["path"].(string)
+	if !ok || srcPath == "" {
+		return nil, fmt.Errorf("missing path")
+	}
+
+	outputPath, _ := payload["output_path"].(string)
+	if outputPath != "" && !filepath.IsAbs(outputPath) {
+		return nil, fmt.Errorf("output_path must be an absolute path")
+	}
+
+	// Cancelled when an inline copy grows too large, so docker cp stops
+	// instead of being drained to the end
+	copyCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reader, err := m.dockerClient.CopyFromContainer(copyCtx, containerID, srcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	filename := docker.ImageArchiveName(srcPath)
+	result := map[string]interface{}{
+		"container_id": containerID,
+		"path":         srcPath,
+		"filename":     filename,
+	}
+
+	if outputPath == "" {
+		data, readErr := readLimited(reader, maxInlineSize)
+		if errors.Is(readErr, errTooLarge) {
+			cancel()
+			reader.Close()
+			return nil, fmt.Errorf("%w: the archive of %s exceeds %d MiB, pass output_path to write it to disk", errdefs.ErrInvalidInput, srcPath, maxInlineSize>>20)
+		}
+		if err := reader.Close(); err != nil {
+			return nil, err
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read copy output: %w", readErr)
+		}
+		result["archive"] = base64.StdEncoding.EncodeToString(data)
+		return result, nil
+	}
+
+	if info, err := os.Stat(outputPath); err == nil && info.IsDir() {
+		outputPath = filepath.Join(outputPath, filename)
+	}
+	size, err := writeStreamToFile(outputPath, reader)
 	if err != nil {
 		return nil, err
 	}
 
-	return m.dockerClient.ComposeUpWithProject(ctx, composePath, projectName)
+	result["output_path"] = outputPath
+	result["size"] = size
+	return result, nil
 }
 
-func (m *Manager) executeComposeDown(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
-	projectName, composePath, err := m.getComposeProjectPath(payload)
+func (m *Manager) executeContainerUpdate(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok || containerID == "" {
+		return nil, fmt.Errorf("missing container_id")
+	}
+
+	resources, err := parseResourceLimits(payload)
 	if err != nil {
 		return nil, err
 	}
+	if err := resources.Validate(); err != nil {
+		return nil, err
+	}
 
-	return m.dockerClient.ComposeDownWithProject(ctx, composePath, projectName)
+	return m.dockerClient.UpdateContainer(ctx, containerID, resources)
 }
 
-func (m *Manager) executeComposePs(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
-	projectName, composePath, err := m.getComposeProjectPath(payload)
+// parseResourceLimits decodes the "resources" payload field, rejecting keys
+// docker.ResourceLimits does not know so typos are not silently ignored
+func parseResourceLimits(payload map[string]interface{}) (docker.ResourceLimits, error) {
+	var resources docker.ResourceLimits
+
+	raw, ok := payload["resources"].(map[string]interface{})
+	if !ok {
+		return resources, fmt.Errorf("missing resources")
+	}
+
+	data, err := json.Marshal(raw)
 	if err != nil {
+		return resources, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&resources); err != nil {
+		return resources, fmt.Errorf("invalid resources: %w", err)
+	}
+	return resources, nil
+}
+
+func (m *Manager) executeContainerRename(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing container_id")
+	}
+
+	newName, ok := payload["new_name"].(string)
+	if !ok || newName == "" {
+		return nil, fmt.Errorf("missing new_name")
+	}
+
+	if err := docker.ValidateContainerName(newName); err != nil {
 		return nil, err
 	}
 
-	return m.dockerClient.ComposePs(ctx, composePath, projectName)
+	return m.dockerClient.RenameContainer(ctx, containerID, newName)
 }
 
-func (m *Manager) executeComposeLogs(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
-	projectName, composePath, err := m.getComposeProjectPath(payload)
+// executeContainerCommit snapshots a container into a new image
+func (m *Manager) executeContainerCommit(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok || containerID == "" {
+		return nil, fmt.Errorf("missing container_id")
+	}
+	repository, ok := payload["repository"].(string)
+	if !ok || repository == "" {
+		return nil, fmt.Errorf("missing repository")
+	}
+	tag, _ := payload["tag"].(string)
+	message, _ := payload["message"].(string)
+
+	imageID, err := m.dockerClient.CommitContainer(ctx, containerID, repository, tag, message)
 	if err != nil {
 		return nil, err
 	}
 
-	serviceName := ""
-	tail := 100
+	if tag == "" {
+		tag = "latest"
+	}
+	return map[string]interface{}{
+		"container_id": containerID,
+		"image_id":     imageID,
+		"image":        repository + ":" + tag,
+	}, nil
+}
 
-	if service, ok := payload["service_name"].(string); ok {
-		serviceName = service
+func (m *Manager) executeContainerLogs(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing container_id")
 	}
+
+	tail := 100
 	if t, ok := payload["tail"].(float64); ok {
 		tail = int(t)
 	}
+	grep, err := parseLogGrep(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.dockerClient.GetContainerLogs(ctx, containerID, tail)
+	if err != nil {
+		return nil, err
+	}
+	return grepLogs(result, grep), nil
+}
+
+// executeContainerLogsDownload exports a container's complete logs, optionally
+// bounded by since/until. The logs are written to output_path when given (a
+// directory gets "<container-name>.log"), otherwise they are returned inline
+// up to maxInlineSize.
+func (m *Manager) executeContainerLogsDownload(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok || containerID == "" {
+		return nil, fmt.Errorf("missing container_id")
+	}
+
+	outputPath, _ := payload["output_path"].(string)
+	if outputPath != "" && !filepath.IsAbs(outputPath) {
+		return nil, fmt.Errorf("output_path must be an absolute path")
+	}
+
+	since, until, err := parseLogTimeRange(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	grep, err := parseLogGrep(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := docker.ContainerLogsOptions{Since: since, Until: until}
+	opts.Timestamps, _ = payload["timestamps"].(bool)
+
+	// Resolving the name first also fails fast for unknown containers
+	name, err := m.dockerClient.ContainerName(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cancelled when inline logs grow too large, so docker logs stops instead
+	// of being drained to the end
+	logsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reader, err := m.dockerClient.StreamContainerLogs(logsCtx, containerID, opts)
+	if err != nil {
+		return nil, err
+	}
+	reader = newGrepReader(reader, grep)
+
+	filename := docker.ContainerLogFileName(name)
+	result := map[string]interface{}{
+		"container_id": containerID,
+		"filename":     filename,
+		"content_type": "text/plain",
+	}
+
+	if outputPath == "" {
+		logs, readErr := readLimited(reader, maxInlineSize)
+		if errors.Is(readErr, errTooLarge) {
+			cancel()
+			reader.Close()
+			return nil, fmt.Errorf("%w: the logs of %s exceed %d MiB, pass output_path to write them to disk", errdefs.ErrInvalidInput, containerID, maxInlineSize>>20)
+		}
+		if err := reader.Close(); err != nil {
+			return nil, err
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read logs: %w", readErr)
+		}
+		result["logs"] = string(logs)
+		return result, nil
+	}
+
+	if info, err := os.Stat(outputPath); err == nil && info.IsDir() {
+		outputPath = filepath.Join(outputPath, filename)
+	}
+	size, err := writeStreamToFile(outputPath, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	result["output_path"] = outputPath
+	result["size"] = size
+	return result, nil
+}
+
+// executeContainerTop lists a container's processes; ps_args defaults to
+// docker.DefaultTopArgs
+func (m *Manager) executeContainerTop(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok || containerID == "" {
+		return nil, fmt.Errorf("missing container_id")
+	}
+	psArgs, _ := payload["ps_args"].(string)
 
-	return m.dockerClient.ComposeLogs(ctx, composePath, projectName, serviceName, tail)
+	return m.dockerClient.ContainerTop(ctx, containerID, psArgs)
+}
+
+func (m *Manager) executeContainerPorts(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	containerID, ok := payload["container_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing container_id")
+	}
+
+	return m.dockerClient.CheckContainerPorts(ctx, containerID)
+}
+
+func (m *Manager) executeImagePull(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	var image string
+	var ok bool
+
+	if image, ok = payload["imageName"].(string); !ok {
+		if image, ok = payload["image"].(string); !ok {
+			return nil, fmt.Errorf("missing imageName or image")
+		}
+	}
+
+	result, err := m.dockerClient.PullImage(ctx, image)
+	if err != nil {
+		return map[string]interface{}{
+			"status": "failed",
+			"error":  fmt.Sprintf("Failed to pull image %s: %v", image, err),
+		}, nil
+	}
+
+	var output string
+	if resultMap, ok := result.(map[string]interface{}); ok {
+		if outputStr, exists := resultMap["output"]; exists {
+			output = fmt.Sprintf("%v", outputStr)
+		}
+	}
+
+	return map[string]interface{}{
+		"status": "completed",
+		"result": map[string]interface{}{
+			"output": output,
+			"image":  image,
+		},
+	}, nil
+}
+
+func (m *Manager) executeImagePrune(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	dangling := true
+	if d, ok := payload["dangling"].(bool); ok {
+		dangling = d
+	}
+
+	return m.dockerClient.PruneImages(ctx, dangling, getFilters(payload))
+}
+
+// executeSystemPrune reclaims space across containers, networks, images and
+// build cache. It is destructive, so the payload must set confirm to true.
+func (m *Manager) executeSystemPrune(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	if confirm, _ := payload["confirm"].(bool); !confirm {
+		return nil, fmt.Errorf("system_prune requires confirm: true")
+	}
+
+	all, _ := payload["all"].(bool)
+	volumes, _ := payload["volumes"].(bool)
+	return m.dockerClient.SystemPrune(ctx, all, volumes)
+}
+
+func (m *Manager) executeImageInspect(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	image, ok := payload["image"].(string)
+	if !ok || image == "" {
+		return nil, fmt.Errorf("missing image")
+	}
+
+	return m.dockerClient.InspectImage(ctx, image)
+}
+
+func (m *Manager) executeImageScan(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	image, ok := payload["image"].(string)
+	if !ok || image == "" {
+		return nil, fmt.Errorf("missing image")
+	}
+
+	return m.dockerClient.ScanImage(ctx, image)
+}
+
+func (m *Manager) executeImageHistory(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	image, ok := payload["image"].(string)
+	if !ok || image == "" {
+		return nil, fmt.Errorf("missing image")
+	}
+
+	return m.dockerClient.ImageHistory(ctx, image)
+}
+
+// executeImageTags lists the tags a registry has for a repository.
+// username and password are only needed for private repositories.
+func (m *Manager) executeImageTags(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	repository, ok := payload["repository"].(string)
+	if !ok || repository == "" {
+		return nil, fmt.Errorf("missing repository")
+	}
+
+	var auth *docker.RegistryAuth
+	if username, _ := payload["username"].(string); username != "" {
+		password, _ := payload["password"].(string)
+		auth = &docker.RegistryAuth{Username: username, Password: password}
+	}
+
+	tags, err := m.dockerClient.ListRemoteTags(ctx, repository, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"repository": repository,
+		"tags":       tags,
+	}, nil
+}
+
+// executeVolumeCreate creates a volume. size caps a local tmpfs volume
+// without spelling out the "o" driver option. Local bind volumes are held to
+// ALLOWED_BIND_PATHS.
+func (m *Manager) executeVolumeCreate(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	name, _ := payload["name"].(string)
+	driver, _ := payload["driver"].(string)
+	size, _ := payload["size"].(string)
+
+	opts := docker.VolumeCreateOptions{
+		Name:       name,
+		Driver:     driver,
+		DriverOpts: getStringMap(payload, "driver_opts"),
+		Labels:     getStringMap(payload, "labels"),
+		Size:       size,
+	}
+	// A local bind volume mounts a host path just like a bind mount does
+	if device, isBind := opts.BindDevice(); isBind && !compose.IsPathAllowed(device, m.config.AllowedBindPaths) {
+		return nil, fmt.Errorf("%w: bind device %s is not in the allowed host paths", errdefs.ErrInvalidInput, device)
+	}
+
+	created, err := m.dockerClient.CreateVolume(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"name": created}, nil
+}
+
+// executeVolumeBrowse lists one directory of a volume
+func (m *Manager) executeVolumeBrowse(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	volumeName, ok := payload["volume_name"].(string)
+	if !ok || volumeName == "" {
+		return nil, fmt.Errorf("missing volume_name")
+	}
+	subPath, _ := payload["path"].(string)
+
+	entries, err := m.dockerClient.ListVolumeContents(ctx, volumeName, subPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"volume_name": volumeName,
+		"path":        subPath,
+		"entries":     entries,
+	}, nil
+}
+
+// executeImageSave writes an image tar to a host path. When output_path is a
+// directory the archive name is derived from the image reference.
+func (m *Manager) executeImageSave(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	image, ok := payload["image"].(string)
+	if !ok || image == "" {
+		return nil, fmt.Errorf("missing image")
+	}
+
+	outputPath, ok := payload["output_path"].(string)
+	if !ok || !filepath.IsAbs(outputPath) {
+		return nil, fmt.Errorf("output_path must be an absolute path")
+	}
+
+	if info, err := os.Stat(outputPath); err == nil && info.IsDir() {
+		outputPath = filepath.Join(outputPath, docker.ImageArchiveName(image))
+	}
+
+	reader, err := m.dockerClient.SaveImage(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := writeStreamToFile(outputPath, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"image":  image,
+		"path":   outputPath,
+		"size":   size,
+		"status": "saved",
+	}, nil
+}
+
+// writeStreamToFile copies a command stream to outputPath and closes it,
+// removing the partial file if either side fails
+func writeStreamToFile(outputPath string, reader io.ReadCloser) (int64, error) {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		reader.Close()
+		return 0, fmt.Errorf("failed to create %s: %w", outputPath, err)
+	}
+	defer file.Close()
+
+	size, copyErr := io.Copy(file, reader)
+	if err := reader.Close(); err != nil {
+		os.Remove(outputPath)
+		return 0, err
+	}
+	if copyErr != nil {
+		os.Remove(outputPath)
+		return 0, fmt.Errorf("failed to write %s: %w", outputPath, copyErr)
+	}
+
+	return size, nil
+}
+
+// executeImageLoad loads an image tar from a host path
+func (m *Manager) executeImageLoad(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	inputPath, ok := payload["input_path"].(string)
+	if !ok || !filepath.IsAbs(inputPath) {
+		return nil, fmt.Errorf("input_path must be an absolute path")
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", inputPath, err)
+	}
+	defer file.Close()
+
+	image, err := m.dockerClient.LoadImage(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"image":  image,
+		"path":   inputPath,
+		"status": "loaded",
+	}, nil
+}
+
+const (
+	defaultEventWindow = 5 * time.Second
+	maxEventWindow     = 60 * time.Second
+	maxEventsPerTask   = 1000
+)
+
+// executeDockerEvents collects docker events for a bounded window. The agent
+// has no long-lived connection to stream over, so the caller polls with
+// successive tasks. "type" and "label" are shorthands for the matching filters.
+func (m *Manager) executeDockerEvents(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	window := defaultEventWindow
+	if seconds, ok := payload["duration"].(float64); ok && seconds > 0 {
+		window = time.Duration(seconds * float64(time.Second))
+	}
+	if window > maxEventWindow {
+		window = maxEventWindow
+	}
+
+	filters := getFilters(payload)
+	for _, key := range []string{"type", "label"} {
+		filters[key] = append(filters[key], getStringValues(payload, key)...)
+	}
+	if len(filters["type"]) == 0 {
+		filters["type"] = []string{"container", "image", "network", "volume"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+
+	ch := make(chan docker.DockerEvent)
+	done := make(chan error, 1)
+	go func() {
+		done <- m.dockerClient.StreamEvents(ctx, filters, ch)
+	}()
+
+	events := []docker.DockerEvent{}
+	for {
+		select {
+		case event := <-ch:
+			events = append(events, event)
+			if len(events) >= maxEventsPerTask {
+				cancel()
+			}
+		case err := <-done:
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"events":    events,
+				"truncated": len(events) >= maxEventsPerTask,
+			}, nil
+		}
+	}
+}
+
+// New Compose methods with project-based paths
+func (m *Manager) executeComposeUp(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getSelectedComposePath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	options := parseComposeUpOptions(payload)
+	if err := m.validateProjectBindMounts(ctx, composePath, projectName, options.EnvOverrides); err != nil {
+		return nil, err
+	}
+
+	result, err := m.dockerClient.ComposeUpWithOptions(ctx, composePath, projectName, options)
+	if err != nil {
+		return nil, err
+	}
+
+	m.rememberProfiles(ctx, projectName, payload)
+	return result, nil
+}
+
+// executeComposeCreate provisions a stack's containers without starting
+// them, so a deployment can be staged and started later with compose_start
+func (m *Manager) executeComposeCreate(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getSelectedComposePath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.validateProjectBindMounts(ctx, composePath, projectName, nil); err != nil {
+		return nil, err
+	}
+
+	return m.dockerClient.ComposeCreate(ctx, composePath, projectName, getStringSlice(payload, "services")...)
+}
+
+// executeComposeStart starts a stack's existing containers, or only those of
+// the given services
+func (m *Manager) executeComposeStart(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getSelectedComposePath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dockerClient.ComposeStart(ctx, composePath, projectName, getStringSlice(payload, "services")...)
+}
+
+func (m *Manager) executeComposeDown(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dockerClient.ComposeDownWithProject(ctx, composePath, projectName)
+}
+
+func (m *Manager) executeComposePs(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dockerClient.ComposePs(ctx, composePath, projectName)
+}
+
+func (m *Manager) executeComposePause(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dockerClient.ComposePause(ctx, composePath, projectName)
+}
+
+func (m *Manager) executeComposeUnpause(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.dockerClient.ComposeUnpause(ctx, composePath, projectName)
+}
+
+func (m *Manager) executeComposeLogs(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := docker.ComposeLogsOptions{Tail: 100}
+
+	if service, ok := payload["service_name"].(string); ok {
+		opts.Service = service
+	}
+	if t, ok := payload["tail"].(float64); ok {
+		opts.Tail = int(t)
+	}
+	if opts.Since, opts.Until, err = parseLogTimeRange(payload); err != nil {
+		return nil, err
+	}
+	if timestamps, ok := payload["timestamps"].(bool); ok {
+		opts.Timestamps = timestamps
+	}
+	grep, err := parseLogGrep(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.dockerClient.ComposeLogsWithOptions(ctx, composePath, projectName, opts)
+	if err != nil {
+		return nil, err
+	}
+	return grepLogs(result, grep), nil
+}
+
+// parseLogTimeRange reads the optional since/until log bounds. Each must be
+// an RFC3339 timestamp or a relative duration such as "10m" or "1h30m".
+func parseLogTimeRange(payload map[string]interface{}) (since, until string, err error) {
+	since, _ = payload["since"].(string)
+	until, _ = payload["until"].(string)
+
+	if since != "" && !validLogTime(since) {
+		return "", "", fmt.Errorf("since must be an RFC3339 timestamp or a duration such as 10m")
+	}
+	if until != "" && !validLogTime(until) {
+		return "", "", fmt.Errorf("until must be an RFC3339 timestamp or a duration such as 10m")
+	}
+	return since, until, nil
+}
+
+func validLogTime(value string) bool {
+	if _, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return true
+	}
+	d, err := time.ParseDuration(value)
+	return err == nil && d >= 0
+}
+
+func (m *Manager) executeComposeDeploy(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getSelectedComposePath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	options := parseComposeUpOptions(payload)
+	if err := m.validateProjectBindMounts(ctx, composePath, projectName, options.EnvOverrides); err != nil {
+		return nil, err
+	}
+
+	// Compose reconciles running stacks in place; only tear the stack down
+	// first when a full recreate is requested
+	if recreate, _ := payload["recreate"].(bool); recreate {
+		if err := m.deployer.down(ctx, composePath, projectName); err != nil {
+			// Log but don't fail if down fails (might not exist)
+			slog.DebugContext(ctx, "Compose down before deploy failed", "project", projectName, "error", err)
+		}
+	}
+
+	result, err := m.deployer.up(ctx, composePath, projectName, options)
+	if err != nil {
+		return nil, err
+	}
+
+	m.rememberProfiles(ctx, projectName, payload)
+	return result, nil
+}
+
+// composeContext applies a project's compose settings to the docker calls made
+// with the returned context: its compose project name override and profiles.
+// Profiles in the payload win over the ones persisted by the last deploy.
+func (m *Manager) composeContext(ctx context.Context, projectName string, payload map[string]interface{}) context.Context {
+	meta, err := m.composeManager.LoadMetadata(projectName)
+	if err != nil {
+		meta = &compose.ProjectMetadata{}
+	}
+	if meta.ComposeProjectName != "" {
+		ctx = docker.WithComposeProjectName(ctx, meta.ComposeProjectName)
+	}
+
+	if _, given := payload["profiles"]; given {
+		return docker.WithComposeProfiles(ctx, getStringValues(payload, "profiles"))
+	}
+	if len(meta.Profiles) > 0 {
+		ctx = docker.WithComposeProfiles(ctx, meta.Profiles)
+	}
+	return ctx
+}
+
+// rememberProfiles persists the profiles a deploy was given so later
+// operations on the stack default to them
+func (m *Manager) rememberProfiles(ctx context.Context, projectName string, payload map[string]interface{}) {
+	if _, given := payload["profiles"]; !given {
+		return
+	}
+	if err := m.composeManager.SetProfiles(projectName, getStringValues(payload, "profiles")); err != nil {
+		slog.WarnContext(ctx, "Failed to persist compose profiles", "project", projectName, "error", err)
+	}
+}
+
+// executeComposeConfig renders the resolved compose config without deploying
+func (m *Manager) executeComposeConfig(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := m.dockerClient.RenderComposeConfig(ctx, composePath, projectName, getStringMap(payload, "env_overrides"))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"project_name": projectName,
+		"config":       rendered,
+	}, nil
+}
+
+// executeComposeValidate checks a project without deploying it: its compose
+// files must render, every env_file they reference must exist and bind
+// mounts must stay within the allowed host paths
+func (m *Manager) executeComposeValidate(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := m.dockerClient.ComposeConfig(ctx, composePath, projectName)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := compose.ParseProjectSpec([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+	if err := compose.ValidateBindMounts(spec, m.config.AllowedBindPaths); err != nil {
+		return nil, err
+	}
+	if err := compose.ValidateDependencies(spec); err != nil {
+		return nil, fmt.Errorf("%w: %v", errdefs.ErrInvalidInput, err)
+	}
+
+	return map[string]interface{}{
+		"project_name": projectName,
+		"valid":        true,
+		"services":     spec.ServiceNames(),
+	}, nil
+}
+
+// executeComposeSpec returns a fingerprint of the declared stack state: the
+// normalized config hash, the services and the images they reference
+func (m *Manager) executeComposeSpec(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := m.dockerClient.ComposeConfig(ctx, composePath, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := compose.ParseProjectSpec([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := compose.ConfigHash([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]map[string]interface{}, 0, len(spec.Services))
+	for _, name := range spec.ServiceNames() {
+		services = append(services, map[string]interface{}{
+			"name":  name,
+			"image": spec.Services[name].Image,
+		})
+	}
+
+	return map[string]interface{}{
+		"project_name": projectName,
+		"hash":         hash,
+		"services":     services,
+		"images":       spec.ImageRefs(),
+	}, nil
+}
+
+// executeComposeTopology returns every service declared in the compose files,
+// running or not, with its dependencies, networks, volumes, ports, environment
+// and healthcheck. Environment values follow STACK_ENV_ACCESS.
+func (m *Manager) executeComposeTopology(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := m.dockerClient.ComposeConfig(ctx, composePath, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	topology, err := compose.ParseTopology([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+	m.redactTopologyEnv(topology)
+
+	return map[string]interface{}{
+		"project_name": projectName,
+		"services":     topology.Services,
+		"networks":     topology.Networks,
+		"volumes":      topology.Volumes,
+	}, nil
+}
+
+// executeComposeDeclared lists one kind of declared resource in a stack:
+// "services", "volumes" or "images". Nothing is started or pulled.
+func (m *Manager) executeComposeDeclared(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	kind, _ := payload["kind"].(string)
+	switch kind {
+	case "services", "volumes", "images":
+	default:
+		return nil, fmt.Errorf("%w: kind must be services, volumes or images, got %q", errdefs.ErrInvalidInput, kind)
+	}
+
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := m.dockerClient.ComposeConfig(ctx, composePath, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	topology, err := compose.ParseTopology([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []string
+	switch kind {
+	case "services":
+		items = topology.ServiceNames()
+	case "volumes":
+		items = topology.Volumes
+	case "images":
+		items = topology.Images()
+	}
+
+	return map[string]interface{}{
+		"project_name": projectName,
+		"kind":         kind,
+		"items":        items,
+	}, nil
+}
+
+// executeComposeRestartService restarts a single service without touching the
+// rest of the stack
+func (m *Manager) executeComposeRestartService(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	service, ok := payload["service"].(string)
+	if !ok || service == "" {
+		return nil, fmt.Errorf("service is required")
+	}
+
+	output, err := m.dockerClient.ComposeConfig(ctx, composePath, projectName)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := compose.ParseProjectSpec([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+	if err := requireService(spec, service); err != nil {
+		return nil, err
+	}
+
+	return m.dockerClient.ComposeRestart(ctx, composePath, projectName, service)
+}
+
+// requireService reports an error unless the project declares the service
+func requireService(spec *compose.ProjectSpec, service string) error {
+	if _, ok := spec.Services[service]; !ok {
+		return fmt.Errorf("service %s not found in project (available: %s)", service, strings.Join(spec.ServiceNames(), ", "))
+	}
+	return nil
+}
+
+// executeComposePull pulls images for the selected services and reports what was fetched
+func (m *Manager) executeComposePull(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	services := getStringSlice(payload, "services")
+
+	result, err := m.dockerClient.ComposePull(ctx, composePath, projectName, services)
+	if err != nil {
+		return nil, err
+	}
+
+	output := ""
+	var progress interface{}
+	if resultMap, ok := result.(map[string]interface{}); ok {
+		output, _ = resultMap["output"].(string)
+		progress = resultMap["progress"]
+	}
+
+	pulled := []map[string]interface{}{}
+	if configOutput, err := m.dockerClient.ComposeConfig(ctx, composePath, projectName); err == nil {
+		if spec, err := compose.ParseProjectSpec([]byte(configOutput)); err == nil {
+			pulled = m.describePulledImages(ctx, spec, services)
+		}
+	}
+
+	return map[string]interface{}{
+		"project_name": projectName,
+		"services":     pulled,
+		"progress":     progress,
+		"output":       output,
+	}, nil
+}
+
+// describePulledImages reports image, size and digest for each selected service
+func (m *Manager) describePulledImages(ctx context.Context, spec *compose.ProjectSpec, services []string) []map[string]interface{} {
+	selected := services
+	if len(selected) == 0 {
+		for name := range spec.Services {
+			selected = append(selected, name)
+		}
+		sort.Strings(selected)
+	}
+
+	pulled := make([]map[string]interface{}, 0, len(selected))
+	for _, name := range selected {
+		service, ok := spec.Services[name]
+		if !ok || service.Image == "" {
+			continue
+		}
+
+		entry := map[string]interface{}{
+			"service": name,
+			"image":   service.Image,
+		}
+
+		if details, err := m.dockerClient.InspectImage(ctx, service.Image); err == nil {
+			entry["size"] = details["Size"]
+			if digests, ok := details["RepoDigests"].([]interface{}); ok && len(digests) > 0 {
+				entry["digest"] = digests[0]
+			}
+		} else {
+			entry["error"] = err.Error()
+		}
+
+		pulled = append(pulled, entry)
+	}
+
+	return pulled
+}
+
+// New Compose project management methods
+func (m *Manager) executeComposeCreateProject(payload map[string]interface{}) (interface{}, error) {
+	config, err := m.parseProjectConfig(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.composeManager.CreateProject(config); err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	return map[string]interface{}{
+		"status":       "created",
+		"project":      config.Name,
+		"path":         m.composeManager.GetProjectPath(config.Name),
+		"compose_file": config.ComposeFile,
+	}, nil
+}
+
+func (m *Manager) executeComposeUpdateProject(payload map[string]interface{}) (interface{}, error) {
+	config, err := m.parseProjectConfig(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.composeManager.UpdateProject(config); err != nil {
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return map[string]interface{}{
+		"status":       "updated",
+		"project":      config.Name,
+		"path":         m.composeManager.GetProjectPath(config.Name),
+		"compose_file": config.ComposeFile,
+	}, nil
+}
+
+func (m *Manager) executeComposeDeleteProject(payload map[string]interface{}) (interface{}, error) {
+	projectName, ok := payload["project_name"].(string)
+	if !ok || projectName == "" {
+		return nil, fmt.Errorf("project_name is required")
+	}
+
+	if err := m.composeManager.DeleteProject(projectName); err != nil {
+		return nil, fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	return map[string]interface{}{
+		"status":  "deleted",
+		"project": projectName,
+	}, nil
+}
+
+func (m *Manager) executeComposeListProjects() (interface{}, error) {
+	projects, err := m.composeManager.ListProjects()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	return map[string]interface{}{
+		"projects":  projects,
+		"count":     len(projects),
+		"base_path": m.config.ComposeBasePath,
+	}, nil
+}
+
+func (m *Manager) executeComposeCreateFromGit(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, ok := payload["project_name"].(string)
+	if !ok || projectName == "" {
+		return nil, fmt.Errorf("project_name is required")
+	}
+
+	repoURL, ok := payload["repo_url"].(string)
+	if !ok || repoURL == "" {
+		return nil, fmt.Errorf("repo_url is required")
+	}
+
+	ref, _ := payload["ref"].(string)
+	subPath, _ := payload["sub_path"].(string)
+	token, _ := payload["token"].(string)
+
+	if err := m.composeManager.CreateProjectFromGit(ctx, projectName, repoURL, ref, subPath, token); err != nil {
+		return nil, fmt.Errorf("failed to create project from git: %w", err)
+	}
+
+	return map[string]interface{}{
+		"status":   "created",
+		"project":  projectName,
+		"path":     m.composeManager.GetProjectPath(projectName),
+		"repo_url": repoURL,
+		"ref":      ref,
+		"sub_path": subPath,
+	}, nil
+}
+
+// executeComposeGitPull updates a git-backed project to its configured ref and redeploys it
+func (m *Manager) executeComposeGitPull(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	token, _ := payload["token"].(string)
+	if err := m.composeManager.PullProjectFromGit(ctx, projectName, token); err != nil {
+		return nil, fmt.Errorf("failed to pull project from git: %w", err)
+	}
+
+	options := parseComposeUpOptions(payload)
+	if err := m.validateProjectBindMounts(ctx, composePath, projectName, options.EnvOverrides); err != nil {
+		return nil, err
+	}
+
+	return m.dockerClient.ComposeUpWithOptions(ctx, composePath, projectName, options)
+}
+
+// executeComposeRemove takes a compose project down and removes its files
+// unless remove_files is false
+func (m *Manager) executeComposeRemove(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	// Extract project name from payload
+	projectName, ok := payload["project_name"].(string)
+	if !ok || projectName == "" {
+		return nil, fmt.Errorf("project_name is required")
+	}
+
+	// Check if the project exists before trying to remove it
+	if !m.composeManager.ProjectExists(projectName) {
+		return nil, fmt.Errorf("%w: project %s does not exist", errdefs.ErrNotFound, projectName)
+	}
+
+	// Get project path for logging
+	projectPath := m.composeManager.GetProjectPath(projectName)
+
+	// remove_files false takes the stack down but keeps its files for later
+	removeFiles := true
+	if value, ok := payload["remove_files"].(bool); ok {
+		removeFiles = value
+	}
+
+	// First, try to bring down the compose project if it's running
+	composeFiles := m.composeManager.GetComposeFiles(projectName, "")
+	composePath := docker.JoinComposeFiles(composeFiles)
+	if !removeFiles {
+		if _, err := m.dockerClient.ComposeDownWithProject(ctx, composePath, projectName); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"status":  "stopped",
+			"message": fmt.Sprintf("Stopped project %s and kept its files at %s", projectName, projectPath),
+			"project": map[string]interface{}{
+				"id":   projectName,
+				"name": projectName,
+				"path": projectPath,
+			},
+		}, nil
+	}
+	if _, err := os.Stat(composeFiles[0]); err == nil {
+		// The compose file exists, try to bring it down
+		_, _ = m.dockerClient.ComposeDownWithProject(ctx, composePath, projectName)
+		// We ignore errors from ComposeDown since we want to proceed with deletion regardless
+	}
+
+	// Now delete the project files and directory
+	if err := m.composeManager.DeleteProject(projectName); err != nil {
+		return nil, fmt.Errorf("failed to delete project %s: %w", projectName, err)
+	}
+
+	return map[string]interface{}{
+		"status":  "removed",
+		"message": fmt.Sprintf("Successfully removed project %s at %s", projectName, projectPath),
+		"project": map[string]interface{}{
+			"id":   projectName,
+			"name": projectName,
+			"path": projectPath,
+		},
+	}, nil
 }
 
-func (m *Manager) executeComposeDeploy(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
-	projectName, composePath, err := m.getComposeProjectPath(payload)
+// validateProjectBindMounts rejects compose projects that bind-mount host paths
+// outside the configured allowlist
+func (m *Manager) validateProjectBindMounts(ctx context.Context, composePath, projectName string, envOverrides map[string]string) error {
+	if len(m.config.AllowedBindPaths) == 0 {
+		return nil
+	}
+
+	output, err := m.dockerClient.ComposeConfigWithEnv(ctx, composePath, projectName, envOverrides)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// First bring down existing deployment
-	if _, err := m.dockerClient.ComposeDownWithProject(ctx, composePath, projectName); err != nil {
-		// Log but don't fail if down fails (might not exist)
+	spec, err := compose.ParseProjectSpec([]byte(output))
+	if err != nil {
+		return err
 	}
 
-	// Then bring up new deployment
-	return m.dockerClient.ComposeUpWithProject(ctx, composePath, projectName)
+	return compose.ValidateBindMounts(spec, m.config.AllowedBindPaths)
 }
 
-// New Compose project management methods
-func (m *Manager) executeComposeCreateProject(payload map[string]interface{}) (interface{}, error) {
-	config, err := m.parseProjectConfig(payload)
-	if err != nil {
-		return nil, err
+// unvalidatedMountCommands are docker subcommands that can mount host paths
+// in ways validateCommandBindMounts does not understand
+var unvalidatedMountCommands = map[string]bool{
+	"service create": true,
+	"service update": true,
+	"volume create":  true,
+	"stack deploy":   true,
+	"plugin install": true,
+	"compose":        true,
+}
+
+// validateDockerCommand applies ALLOWED_BIND_PATHS to a raw docker_command.
+// "container run" and "container create" are checked like "run" and "create";
+// global flags and other subcommands that can mount host paths are refused.
+func validateDockerCommand(command string, args []string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	if strings.HasPrefix(command, "-") {
+		return fmt.Errorf("%w: global docker flags are not allowed when ALLOWED_BIND_PATHS is set", errdefs.ErrInvalidInput)
 	}
 
-	if err := m.composeManager.CreateProject(config); err != nil {
-		return nil, fmt.Errorf("failed to create project: %w", err)
+	if command == "container" && len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	subcommand := command
+	if len(args) > 0 {
+		subcommand = command + " " + args[0]
 	}
 
-	return map[string]interface{}{
-		"status":       "created",
-		"project":      config.Name,
-		"path":         m.composeManager.GetProjectPath(config.Name),
-		"compose_file": config.ComposeFile,
-	}, nil
+	switch {
+	case command == "run" || command == "create":
+		return validateCommandBindMounts(args, allowed)
+	case unvalidatedMountCommands[command] || unvalidatedMountCommands[subcommand]:
+		return fmt.Errorf("%w: docker %s can mount host paths and is not allowed when ALLOWED_BIND_PATHS is set", errdefs.ErrInvalidInput, subcommand)
+	}
+	return nil
 }
 
-func (m *Manager) executeComposeUpdateProject(payload map[string]interface{}) (interface{}, error) {
-	config, err := m.parseProjectConfig(payload)
-	if err != nil {
-		return nil, err
+// validateCommandBindMounts checks -v/--volume/--mount arguments of a docker
+// run/create command against the allowed host paths
+func validateCommandBindMounts(args []string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
 	}
 
-	if err := m.composeManager.UpdateProject(config); err != nil {
-		return nil, fmt.Errorf("failed to update project: %w", err)
+	for i := 0; i < len(args); i++ {
+		flag, value := args[i], ""
+		if idx := strings.Index(flag, "="); idx > 0 && strings.HasPrefix(flag, "-") {
+			flag, value = flag[:idx], flag[idx+1:]
+		} else if i+1 < len(args) {
+			value = args[i+1]
+		}
+
+		source := ""
+		switch flag {
+		case "-v", "--volume":
+			// Named volumes have no path separator in the source
+			parts := strings.SplitN(value, ":", 2)
+			if len(parts) == 2 && (strings.HasPrefix(parts[0], "/") || strings.HasPrefix(parts[0], ".") || strings.HasPrefix(parts[0], "~")) {
+				source = parts[0]
+			}
+		case "--mount":
+			isBind := false
+			for _, field := range strings.Split(value, ",") {
+				kv := strings.SplitN(field, "=", 2)
+				if len(kv) != 2 {
+					continue
+				}
+				switch kv[0] {
+				case "type":
+					isBind = kv[1] == "bind"
+				case "source", "src":
+					source = kv[1]
+				}
+			}
+			if !isBind {
+				source = ""
+			}
+		default:
+			continue
+		}
+
+		if source != "" && !compose.IsPathAllowed(source, allowed) {
+			return fmt.Errorf("bind mount source %s is not in the allowed host paths", source)
+		}
 	}
 
-	return map[string]interface{}{
-		"status":       "updated",
-		"project":      config.Name,
-		"path":         m.composeManager.GetProjectPath(config.Name),
-		"compose_file": config.ComposeFile,
-	}, nil
+	return nil
 }
 
-func (m *Manager) executeComposeDeleteProject(payload map[string]interface{}) (interface{}, error) {
+// executeComposeImportArchive creates a project from a .tar.gz, given either
+// base64-encoded in "archive" or as an absolute host path in "archive_path"
+func (m *Manager) executeComposeImportArchive(payload map[string]interface{}) (interface{}, error) {
 	projectName, ok := payload["project_name"].(string)
 	if !ok || projectName == "" {
 		return nil, fmt.Errorf("project_name is required")
 	}
 
-	if err := m.composeManager.DeleteProject(projectName); err != nil {
-		return nil, fmt.Errorf("failed to delete project: %w", err)
+	var archive io.Reader
+	if encoded, ok := payload["archive"].(string); ok && encoded != "" {
+		archive = base64.NewDecoder(base64.StdEncoding, strings.NewReader(encoded))
+	} else if archivePath, ok := payload["archive_path"].(string); ok && filepath.IsAbs(archivePath) {
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", archivePath, err)
+		}
+		defer file.Close()
+		archive = file
+	} else {
+		return nil, fmt.Errorf("archive or an absolute archive_path is required")
 	}
 
-	return map[string]interface{}{
-		"status":  "deleted",
-		"project": projectName,
-	}, nil
-}
-
-func (m *Manager) executeComposeListProjects() (interface{}, error) {
-	projects, err := m.composeManager.ListProjects()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list projects: %w", err)
+	if err := m.composeManager.ImportProjectArchive(projectName, archive); err != nil {
+		return nil, fmt.Errorf("failed to import project: %w", err)
 	}
 
 	return map[string]interface{}{
-		"projects":  projects,
-		"count":     len(projects),
-		"base_path": m.config.ComposeBasePath,
+		"status":  "created",
+		"project": projectName,
+		"path":    m.composeManager.GetProjectPath(projectName),
 	}, nil
 }
 
-// executeComposeRemove removes a compose project and its files
-func (m *Manager) executeComposeRemove(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
-	// Extract project name from payload
+// executeComposeExportArchive archives a project as .tar.gz. The archive is
+// written to output_path when given (a directory gets the default file name),
+// otherwise it is returned base64-encoded up to maxInlineSize. .env files are
+// included unless include_env is false.
+func (m *Manager) executeComposeExportArchive(payload map[string]interface{}) (interface{}, error) {
 	projectName, ok := payload["project_name"].(string)
 	if !ok || projectName == "" {
 		return nil, fmt.Errorf("project_name is required")
 	}
 
-	// Check if the project exists before trying to remove it
-	if !m.composeManager.ProjectExists(projectName) {
-		return nil, fmt.Errorf("project %s does not exist", projectName)
+	includeEnv := true
+	if include, ok := payload["include_env"].(bool); ok {
+		includeEnv = include
+	}
+	// Archives carry the real values, so only full env access may include them
+	if m.envAccess() != config.StackEnvFull {
+		includeEnv = false
 	}
 
-	// Get project path for logging
-	projectPath := m.composeManager.GetProjectPath(projectName)
+	filename := compose.ProjectArchiveName(projectName)
+	result := map[string]interface{}{
+		"project":  projectName,
+		"filename": filename,
+	}
 
-	// First, try to bring down the compose project if it's running
-	composePath := m.composeManager.GetComposePath(projectName, "docker-compose.yml")
-	if _, err := os.Stat(composePath); err == nil {
-		// The compose file exists, try to bring it down
-		_, _ = m.dockerClient.ComposeDown(ctx, composePath)
-		// We ignore errors from ComposeDown since we want to proceed with deletion regardless
+	outputPath, _ := payload["output_path"].(string)
+	if outputPath == "" {
+		var buf bytes.Buffer
+		err := m.composeManager.ExportProjectArchive(projectName, &limitedWriter{w: &buf, limit: maxInlineSize}, includeEnv)
+		if errors.Is(err, errTooLarge) {
+			return nil, fmt.Errorf("%w: the archive of project %s exceeds %d MiB, pass output_path to write it to disk", errdefs.ErrInvalidInput, projectName, maxInlineSize>>20)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result["archive"] = base64.StdEncoding.EncodeToString(buf.Bytes())
+		return result, nil
 	}
 
-	// Now delete the project files and directory
-	if err := m.composeManager.DeleteProject(projectName); err != nil {
-		return nil, fmt.Errorf("failed to delete project %s: %w", projectName, err)
+	if !filepath.IsAbs(outputPath) {
+		return nil, fmt.Errorf("output_path must be an absolute path")
+	}
+	if info, err := os.Stat(outputPath); err == nil && info.IsDir() {
+		outputPath = filepath.Join(outputPath, filename)
 	}
 
-	return map[string]interface{}{
-		"status":  "removed",
-		"message": fmt.Sprintf("Successfully removed project %s at %s", projectName, projectPath),
-		"project": map[string]interface{}{
-			"id":   projectName,
-			"name": projectName,
-			"path": projectPath,
-		},
-	}, nil
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", outputPath, err)
+	}
+	defer file.Close()
+
+	if err := m.composeManager.ExportProjectArchive(projectName, file, includeEnv); err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+
+	result["path"] = outputPath
+	return result, nil
 }
 
 // Helper method to parse project configuration from payload
@@ -422,6 +2055,30 @@ func (m *Manager) parseProjectConfig(payload map[string]interface{}) (compose.Pr
 		config.Override = override
 	}
 
+	// Optional auto-update flag
+	if autoUpdate, ok := payload["auto_update"].(bool); ok {
+		config.AutoUpdate = &autoUpdate
+	}
+
+	// Optional override layer and explicit compose file order
+	if overrideContent, ok := payload["override_content"].(string); ok {
+		config.OverrideContent = overrideContent
+	}
+	config.ComposeFiles = getStringSlice(payload, "compose_files")
+	config.ComposeProjectName, _ = payload["compose_project_name"].(string)
+
+	// Optional extra files, e.g. file-backed secrets
+	if files, ok := payload["files"].(map[string]interface{}); ok {
+		config.Files = make(map[string]string, len(files))
+		for path, content := range files {
+			contentStr, ok := content.(string)
+			if !ok {
+				return config, fmt.Errorf("files: content of %s must be a string", path)
+			}
+			config.Files[path] = contentStr
+		}
+	}
+
 	return config, nil
 }
 
@@ -433,19 +2090,69 @@ func (m *Manager) getComposeProjectPath(payload map[string]interface{}) (string,
 		return "", "", fmt.Errorf("project_name is required")
 	}
 
-	// Allow custom compose file name, default to docker-compose.yml
-	composeFile := "docker-compose.yml"
-	if file, ok := payload["compose_file"].(string); ok && file != "" {
-		composeFile = file
+	if err := compose.ValidateProjectName(projectName); err != nil {
+		return "", "", err
+	}
+
+	// An explicit compose file wins over the files recorded for the project,
+	// but must stay inside the stack directory
+	composeFile, _ := payload["compose_file"].(string)
+	if composeFile != "" && !filepath.IsLocal(composeFile) {
+		return "", "", fmt.Errorf("%w: compose file %q must be a path inside the stack directory", errdefs.ErrInvalidInput, composeFile)
 	}
 
-	// Use compose manager to get the path
-	composePath := m.composeManager.GetComposePath(projectName, composeFile)
+	// Use compose manager to resolve the (possibly layered) compose files
+	composePath := docker.JoinComposeFiles(m.composeManager.GetComposeFiles(projectName, composeFile))
 
 	return projectName, composePath, nil
 }
 
-func (m *Manager) executeStackList(ctx context.Context) (interface{}, error) {
+// getSelectedComposePath is getComposeProjectPath for tasks that start a
+// stack: compose_files (an ordered list) or compose_file pick which of the
+// stack's files are passed as -f, and each must exist in the stack directory
+func (m *Manager) getSelectedComposePath(payload map[string]interface{}) (string, string, error) {
+	files := getStringSlice(payload, "compose_files")
+	if file, ok := payload["compose_file"].(string); ok && file != "" && len(files) == 0 {
+		files = []string{file}
+	}
+	if len(files) == 0 {
+		return m.getComposeProjectPath(payload)
+	}
+
+	projectName, ok := payload["project_name"].(string)
+	if !ok || projectName == "" {
+		return "", "", fmt.Errorf("project_name is required")
+	}
+
+	paths, err := m.composeManager.SelectComposeFiles(projectName, files)
+	if err != nil {
+		return "", "", err
+	}
+	return projectName, docker.JoinComposeFiles(paths), nil
+}
+
+func (m *Manager) executeStackList(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	refresh, _ := payload["refresh"].(bool)
+	if !refresh {
+		if cached, ok := m.statusCache.snapshot(m.config.StackStatusInterval); ok {
+			return cached, nil
+		}
+	}
+
+	collectedAt := time.Now()
+	stacks, err := m.collectStacks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.statusCache.store(stacks, collectedAt)
+
+	return map[string]interface{}{
+		"stacks": stacks,
+	}, nil
+}
+
+// collectStacks builds the stack list, querying compose for each project's status
+func (m *Manager) collectStacks(ctx context.Context) ([]map[string]interface{}, error) {
 	// Get all compose projects from the compose manager
 	projects, err := m.composeManager.ListProjects()
 	if err != nil {
@@ -466,7 +2173,7 @@ func (m *Manager) executeStackList(ctx context.Context) (interface{}, error) {
 			"createdAt":      project["createdAt"],
 			"updatedAt":      project["updatedAt"],
 			"composeContent": project["composeContent"],
-			"envContent":     project["envContent"],
+			"envContent":     m.stackEnvContent(project["envContent"]),
 			"isLegacy":       false,
 			"isExternal":     false,
 			"isRemote":       false,
@@ -482,37 +2189,19 @@ func (m *Manager) executeStackList(ctx context.Context) (interface{}, error) {
 			"project_name": projectName,
 		})
 
-		serviceResult, err := m.dockerClient.ComposePs(ctx, composePath, projectName)
+		serviceResult, err := m.dockerClient.ComposePs(m.composeContext(ctx, projectName, nil), composePath, projectName)
 		if err == nil {
 			// Parse the services output
 			if resultMap, ok := serviceResult.(map[string]interface{}); ok {
 				if servicesOutput, ok := resultMap["services"].(string); ok && servicesOutput != "" {
 					services := m.parseComposeServicesOutput(servicesOutput)
 
-					serviceCount := len(services)
-					runningCount := 0
-					for _, svc := range services {
-						if state, ok := svc["state"].(map[string]interface{}); ok {
-							if running, ok := state["Running"].(bool); ok && running {
-								runningCount++
-							}
-						}
-					}
+					status, serviceCount, runningCount := computeStackStatus(services)
 
 					stack["serviceCount"] = serviceCount
 					stack["runningCount"] = runningCount
 					stack["services"] = services
-
-					// Determine status based on service counts
-					if serviceCount == 0 {
-						stack["status"] = "unknown"
-					} else if runningCount == 0 {
-						stack["status"] = "stopped"
-					} else if runningCount == serviceCount {
-						stack["status"] = "running"
-					} else {
-						stack["status"] = "partially running"
-					}
+					stack["status"] = status
 				}
 			}
 		}
@@ -520,11 +2209,150 @@ func (m *Manager) executeStackList(ctx context.Context) (interface{}, error) {
 		stacks = append(stacks, stack)
 	}
 
+	return stacks, nil
+}
+
+// stackEnvContent applies STACK_ENV_ACCESS to a listed project's env content
+func (m *Manager) stackEnvContent(value interface{}) string {
+	content, _ := value.(string)
+	content, _ = m.redactEnv(content)
+	return content
+}
+
+// maxBatchConcurrency bounds how many objects a batch operation touches at once
+const maxBatchConcurrency = 4
+
+// executeStackBatch applies one action to several stacks, collecting a result
+// per stack instead of aborting on the first failure
+func (m *Manager) executeStackBatch(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	action, ok := payload["action"].(string)
+	if !ok || action == "" {
+		return nil, fmt.Errorf("action is required")
+	}
+
+	switch action {
+	case "start", "stop", "restart", "pull", "down", "pause", "unpause":
+	default:
+		return nil, fmt.Errorf("unsupported batch action: %s", action)
+	}
+
+	ids := getStringSlice(payload, "ids")
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("ids is required")
+	}
+
+	results, failed := runBatch(ids, func(id string) error {
+		return m.runStackAction(ctx, action, id)
+	})
+
 	return map[string]interface{}{
-		"stacks": stacks,
+		"action":    action,
+		"results":   results,
+		"total":     len(results),
+		"succeeded": len(results) - failed,
+		"failed":    failed,
+	}, nil
+}
+
+// executeImageDelete removes several images, reporting a result per image. An
+// image that cannot be removed, e.g. because a container still uses it, does
+// not stop the others.
+func (m *Manager) executeImageDelete(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	ids := getStringSlice(payload, "ids")
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("ids is required")
+	}
+	force, _ := payload["force"].(bool)
+
+	results, failed := runBatch(ids, func(id string) error {
+		_, err := m.dockerClient.RemoveImage(ctx, id, force)
+		return err
+	})
+
+	return map[string]interface{}{
+		"results":   results,
+		"total":     len(results),
+		"succeeded": len(results) - failed,
+		"failed":    failed,
 	}, nil
 }
 
+// runBatch calls fn for every id, at most maxBatchConcurrency at a time, and
+// returns a result per id in input order along with the number that failed
+func runBatch(ids []string, fn func(id string) error) ([]map[string]interface{}, int) {
+	results := make([]map[string]interface{}, len(ids))
+	sem := make(chan struct{}, maxBatchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := map[string]interface{}{
+				"id":      id,
+				"success": true,
+			}
+			if err := fn(id); err != nil {
+				result["success"] = false
+				result["error"] = err.Error()
+				result["error_code"] = errdefs.Code(err)
+			}
+			results[i] = result
+		}(i, id)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result["success"] != true {
+			failed++
+		}
+	}
+	return results, failed
+}
+
+func (m *Manager) runStackAction(ctx context.Context, action, projectName string) error {
+	if !m.composeManager.ProjectExists(projectName) {
+		return fmt.Errorf("%w: project %s does not exist", errdefs.ErrNotFound, projectName)
+	}
+
+	release, err := m.locks.acquire(ctx, projectName, stackLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+	defer m.statusCache.invalidate()
+
+	_, composePath, err := m.getComposeProjectPath(map[string]interface{}{
+		"project_name": projectName,
+	})
+	if err != nil {
+		return err
+	}
+	ctx = m.composeContext(ctx, projectName, nil)
+
+	switch action {
+	case "start":
+		_, err = m.dockerClient.ComposeStart(ctx, composePath, projectName)
+	case "stop":
+		_, err = m.dockerClient.ComposeStop(ctx, composePath, projectName)
+	case "restart":
+		_, err = m.dockerClient.ComposeRestart(ctx, composePath, projectName)
+	case "pull":
+		_, err = m.dockerClient.ComposePull(ctx, composePath, projectName, nil)
+	case "down":
+		_, err = m.dockerClient.ComposeDownWithProject(ctx, composePath, projectName)
+	case "pause":
+		_, err = m.dockerClient.ComposePause(ctx, composePath, projectName)
+	case "unpause":
+		_, err = m.dockerClient.ComposeUnpause(ctx, composePath, projectName)
+	}
+	return err
+}
+
 func (m *Manager) executeStackServices(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
 	projectName, ok := payload["stack_name"].(string)
 	if !ok || projectName == "" {
@@ -537,6 +2365,7 @@ func (m *Manager) executeStackServices(ctx context.Context, payload map[string]i
 	if err != nil {
 		return nil, err
 	}
+	ctx = m.composeContext(ctx, projectName, nil)
 
 	serviceResult, err := m.dockerClient.ComposePs(ctx, composePath, projectName)
 	if err != nil {
@@ -557,34 +2386,113 @@ func (m *Manager) executeStackServices(ctx context.Context, payload map[string]i
 	}, nil
 }
 
-// Helper method to parse compose ps output into service objects
-func (m *Manager) parseComposeServicesOutput(output string) []map[string]interface{} {
-	services := []map[string]interface{}{}
+// decodeComposePs turns compose ps output into one record per container. It
+// accepts JSON lines, the JSON array printed by some v2 releases, and the
+// table older releases print when they ignore --format json.
+func decodeComposePs(output string) []map[string]interface{} {
+	trimmed := strings.TrimSpace(output)
+	if trimmed == "" {
+		return nil
+	}
 
-	// Split output by lines
-	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if strings.HasPrefix(trimmed, "[") {
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &records); err == nil {
+			return records
+		}
+	}
 
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
+	if strings.HasPrefix(trimmed, "{") {
+		records := []map[string]interface{}{}
+		for _, line := range strings.Split(trimmed, "\n") {
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &record); err == nil {
+				records = append(records, record)
+			}
 		}
+		return records
+	}
+
+	return parseComposePsTable(trimmed)
+}
+
+// composePsColumns maps table headers onto the keys of the JSON format
+var composePsColumns = map[string]string{
+	"NAME":    "Name",
+	"IMAGE":   "Image",
+	"COMMAND": "Command",
+	"SERVICE": "Service",
+	"CREATED": "CreatedAt",
+	"STATE":   "State",
+	"STATUS":  "Status",
+	"PORTS":   "Ports",
+}
+
+var composePsHeaderPattern = regexp.MustCompile(`\S+`)
+
+// parseComposePsTable parses the column-aligned table of `compose ps`, e.g.
+// NAME, COMMAND, SERVICE, STATUS, PORTS. Each value is cut at the offset of
+// its header, since values such as commands and statuses contain spaces.
+func parseComposePsTable(output string) []map[string]interface{} {
+	lines := strings.Split(output, "\n")
 
-		var serviceInfo map[string]interface{}
-		if err := json.Unmarshal([]byte(line), &serviceInfo); err != nil {
+	// Every header marks a column boundary, known or not
+	header := lines[0]
+	type column struct {
+		key   string
+		start int
+	}
+	columns := []column{}
+	known := false
+	for _, match := range composePsHeaderPattern.FindAllStringIndex(header, -1) {
+		key := composePsColumns[strings.ToUpper(header[match[0]:match[1]])]
+		known = known || key != ""
+		// Offsets are in runes: truncated commands end in a multi-byte "…"
+		columns = append(columns, column{key: key, start: utf8.RuneCountInString(header[:match[0]])})
+	}
+	if !known {
+		return nil
+	}
+
+	records := []map[string]interface{}{}
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(strings.Trim(line, "-")) == "" {
 			continue
 		}
 
-		// Extract service name
-		serviceName := ""
-		if name, ok := serviceInfo["Name"].(string); ok {
-			parts := strings.Split(name, "-")
-			if len(parts) > 1 {
-				serviceName = parts[len(parts)-1]
-			} else {
-				serviceName = name
+		runes := []rune(line)
+		record := map[string]interface{}{}
+		for i, col := range columns {
+			if col.key == "" || col.start >= len(runes) {
+				continue
+			}
+			end := len(runes)
+			if i+1 < len(columns) && columns[i+1].start < end {
+				end = columns[i+1].start
 			}
-		} else if service, ok := serviceInfo["Service"].(string); ok {
+			if value := strings.TrimSpace(string(runes[col.start:end])); value != "" {
+				record[col.key] = value
+			}
+		}
+		if len(record) > 0 {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// Helper method to parse compose ps output into service objects
+func (m *Manager) parseComposeServicesOutput(output string) []map[string]interface{} {
+	services := []map[string]interface{}{}
+
+	for _, serviceInfo := range decodeComposePs(output) {
+		// Extract service name, preferring the compose service label over the
+		// container name so scaled replicas (project-web-1, project-web-2) group together
+		serviceName := ""
+		if service, ok := serviceInfo["Service"].(string); ok && service != "" {
 			serviceName = service
+		} else if name, ok := serviceInfo["Name"].(string); ok {
+			serviceName = serviceNameFromContainer(name)
 		} else {
 			continue // Skip if no service name
 		}
@@ -613,9 +2521,13 @@ func (m *Manager) parseComposeServicesOutput(output string) []map[string]interfa
 		}
 
 		// Update state
-		if state, ok := serviceInfo["State"].(string); ok {
-			isRunning := strings.Contains(strings.ToLower(state), "running")
-			service["state"].(map[string]interface{})["Running"] = isRunning
+		// Older compose releases only report the human readable Status
+		state, ok := serviceInfo["State"].(string)
+		if !ok || state == "" {
+			state, ok = serviceInfo["Status"].(string)
+		}
+		if ok && state != "" {
+			service["state"].(map[string]interface{})["Running"] = isRunningState(state)
 			service["state"].(map[string]interface{})["Status"] = state
 		}
 
@@ -694,6 +2606,144 @@ func (m *Manager) parseComposeServicesOutput(output string) []map[string]interfa
 	return services
 }
 
+// getStringSlice reads a list of strings from a task payload
+func getStringSlice(payload map[string]interface{}, key string) []string {
+	var values []string
+	if list, ok := payload[key].([]interface{}); ok {
+		for _, item := range list {
+			if str, ok := item.(string); ok && str != "" {
+				values = append(values, str)
+			}
+		}
+	}
+	return values
+}
+
+// getStringValues reads a payload field that may be a single string or a list
+func getStringValues(payload map[string]interface{}, key string) []string {
+	if value, ok := payload[key].(string); ok {
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	}
+	return getStringSlice(payload, key)
+}
+
+// getStringMap reads a string-to-string map from a task payload
+func getStringMap(payload map[string]interface{}, key string) map[string]string {
+	values := map[string]string{}
+	if raw, ok := payload[key].(map[string]interface{}); ok {
+		for k, v := range raw {
+			if str, ok := v.(string); ok {
+				values[k] = str
+			}
+		}
+	}
+	return values
+}
+
+// parseComposeUpOptions reads force_recreate, profiles and env_overrides from a payload
+func parseComposeUpOptions(payload map[string]interface{}) docker.ComposeUpOptions {
+	forceRecreate, _ := payload["force_recreate"].(bool)
+	return docker.ComposeUpOptions{
+		ForceRecreate: forceRecreate,
+		Profiles:      getStringSlice(payload, "profiles"),
+		EnvOverrides:  getStringMap(payload, "env_overrides"),
+	}
+}
+
+// getFilters reads a docker filter map from the payload's "filters" key. Values
+// may be a single string or a list of strings.
+func getFilters(payload map[string]interface{}) map[string][]string {
+	filters := map[string][]string{}
+	raw, ok := payload["filters"].(map[string]interface{})
+	if !ok {
+		return filters
+	}
+
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			filters[key] = append(filters[key], v)
+		case []interface{}:
+			filters[key] = append(filters[key], getStringSlice(map[string]interface{}{key: v}, key)...)
+		}
+	}
+	return filters
+}
+
+// serviceNameFromContainer derives a service name from a compose container name
+// such as "project-web-1" or "project_web_2"
+func serviceNameFromContainer(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	if len(parts) == 0 {
+		return name
+	}
+
+	// Drop the trailing replica number
+	if _, err := strconv.Atoi(parts[len(parts)-1]); err == nil && len(parts) > 1 {
+		parts = parts[:len(parts)-1]
+	}
+	return parts[len(parts)-1]
+}
+
+// summarizeServices groups service containers by name and counts a service as
+// running only when every one of its replicas is running
+func summarizeServices(services []map[string]interface{}) (serviceCount, runningCount int) {
+	replicas := map[string]int{}
+	running := map[string]int{}
+
+	for _, svc := range services {
+		name, _ := svc["name"].(string)
+		replicas[name]++
+		if state, ok := svc["state"].(map[string]interface{}); ok {
+			if isRunning, ok := state["Running"].(bool); ok && isRunning {
+				running[name]++
+			}
+		}
+	}
+
+	for name, count := range replicas {
+		if running[name] == count {
+			runningCount++
+		}
+	}
+
+	return len(replicas), runningCount
+}
+
+// isRunningState reports whether a compose/docker state string describes a
+// running container. Both the machine state ("running") and the human status
+// ("Up 5 minutes (healthy)") are accepted; restarting and paused containers
+// do not count as running.
+func isRunningState(state string) bool {
+	state = strings.ToLower(strings.TrimSpace(state))
+	if strings.Contains(state, "paused") || strings.Contains(state, "restarting") {
+		return false
+	}
+	return state == "running" || state == "up" || strings.HasPrefix(state, "up ")
+}
+
+// computeStackStatus derives the stack status from its service containers
+func computeStackStatus(services []map[string]interface{}) (status string, serviceCount, runningCount int) {
+	serviceCount, runningCount = summarizeServices(services)
+
+	switch {
+	case serviceCount == 0:
+		status = "unknown"
+	case runningCount == 0:
+		status = "stopped"
+	case runningCount == serviceCount:
+		status = "running"
+	default:
+		status = "partially running"
+	}
+	return status, serviceCount, runningCount
+}
+
 // Helper function to get hostname
 func getHostname() string {
 	hostname, err := os.Hostname()
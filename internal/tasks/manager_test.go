@@ -1,10 +1,20 @@
 package tasks
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ofkm/arcane-agent/internal/compose"
 	"github.com/ofkm/arcane-agent/internal/config"
 	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/errdefs"
 )
 
 func TestNewManager(t *testing.T) {
@@ -83,6 +93,351 @@ func TestExecuteTask(t *testing.T) {
 			payload:  map[string]interface{}{},
 			wantErr:  true,
 		},
+		{
+			name:     "container_pause missing container_id",
+			taskType: "container_pause",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "container_unpause missing container_id",
+			taskType: "container_unpause",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "container_rename missing container_id",
+			taskType: "container_rename",
+			payload:  map[string]interface{}{"new_name": "web"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_rename missing new_name",
+			taskType: "container_rename",
+			payload:  map[string]interface{}{"container_id": "abc123"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_rename invalid new_name",
+			taskType: "container_rename",
+			payload:  map[string]interface{}{"container_id": "abc123", "new_name": "bad name!"},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_pause missing project_name",
+			taskType: "compose_pause",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_unpause missing project_name",
+			taskType: "compose_unpause",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_pull missing project_name",
+			taskType: "compose_pull",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "container_update missing container_id",
+			taskType: "container_update",
+			payload:  map[string]interface{}{"resources": map[string]interface{}{"memory": "1g"}},
+			wantErr:  true,
+		},
+		{
+			name:     "container_update invalid memory",
+			taskType: "container_update",
+			payload: map[string]interface{}{
+				"container_id": "abc",
+				"resources":    map[string]interface{}{"memory": "lots"},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "container_inspect missing container_id",
+			taskType: "container_inspect",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "image_inspect missing image",
+			taskType: "image_inspect",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "container_logs invalid grep",
+			taskType: "container_logs",
+			payload:  map[string]interface{}{"container_id": "web", "grep": "[a-"},
+			wantErr:  true,
+		},
+		{
+			name:     "stack_prune without confirm",
+			taskType: "stack_prune",
+			payload:  map[string]interface{}{"project_name": "web"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_top missing container_id",
+			taskType: "container_top",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "run_job missing image",
+			taskType: "run_job",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "run_job image is a flag",
+			taskType: "run_job",
+			payload:  map[string]interface{}{"image": "--volume=/:/host", "command": []interface{}{"alpine", "cat", "/host/etc/shadow"}},
+			wantErr:  true,
+		},
+		{
+			name:     "run_job timeout above maximum",
+			taskType: "run_job",
+			payload:  map[string]interface{}{"image": "alpine", "timeout": float64(3600)},
+			wantErr:  true,
+		},
+		{
+			name:     "volume_create unsupported local driver option",
+			taskType: "volume_create",
+			payload:  map[string]interface{}{"name": "data", "driver_opts": map[string]interface{}{"size": "1g"}},
+			wantErr:  true,
+		},
+		{
+			name:     "task_get missing task_id",
+			taskType: "task_get",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "task_cancel missing task_id",
+			taskType: "task_cancel",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "stack_volumes missing project_name",
+			taskType: "stack_volumes",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "image_tags missing repository",
+			taskType: "image_tags",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "container_commit missing repository",
+			taskType: "container_commit",
+			payload:  map[string]interface{}{"container_id": "web"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_commit invalid repository",
+			taskType: "container_commit",
+			payload:  map[string]interface{}{"container_id": "web", "repository": "Web App"},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_effective_env missing project",
+			taskType: "compose_effective_env",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "image_scan missing image",
+			taskType: "image_scan",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "image_history missing image",
+			taskType: "image_history",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "image_save missing image",
+			taskType: "image_save",
+			payload:  map[string]interface{}{"output_path": "/tmp"},
+			wantErr:  true,
+		},
+		{
+			name:     "image_save relative output_path",
+			taskType: "image_save",
+			payload:  map[string]interface{}{"image": "nginx", "output_path": "nginx.tar"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_diff missing container_id",
+			taskType: "container_diff",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "container_export missing container_id",
+			taskType: "container_export",
+			payload:  map[string]interface{}{"output_path": "/tmp"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_export relative output_path",
+			taskType: "container_export",
+			payload:  map[string]interface{}{"container_id": "abc", "output_path": "abc.tar"},
+			wantErr:  true,
+		},
+		{
+			name:     "volume_browse missing volume_name",
+			taskType: "volume_browse",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "volume_browse path traversal",
+			taskType: "volume_browse",
+			payload:  map[string]interface{}{"volume_name": "data", "path": "../etc"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_copy_to missing path",
+			taskType: "container_copy_to",
+			payload:  map[string]interface{}{"container_id": "abc", "archive": "dGFy"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_copy_to missing archive",
+			taskType: "container_copy_to",
+			payload:  map[string]interface{}{"container_id": "abc", "path": "/tmp"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_copy_to relative container path",
+			taskType: "container_copy_to",
+			payload:  map[string]interface{}{"container_id": "abc", "path": "tmp", "archive": "dGFy"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_copy_from missing container_id",
+			taskType: "container_copy_from",
+			payload:  map[string]interface{}{"path": "/etc/hosts"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_copy_from relative output_path",
+			taskType: "container_copy_from",
+			payload:  map[string]interface{}{"container_id": "abc", "path": "/etc/hosts", "output_path": "hosts.tar"},
+			wantErr:  true,
+		},
+		{
+			name:     "system_prune without confirmation",
+			taskType: "system_prune",
+			payload:  map[string]interface{}{"all": true, "volumes": true},
+			wantErr:  true,
+		},
+		{
+			name:     "container_logs_download missing container_id",
+			taskType: "container_logs_download",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "container_logs_download relative output_path",
+			taskType: "container_logs_download",
+			payload:  map[string]interface{}{"container_id": "web", "output_path": "web.log"},
+			wantErr:  true,
+		},
+		{
+			name:     "container_logs_download invalid until",
+			taskType: "container_logs_download",
+			payload:  map[string]interface{}{"container_id": "web", "until": "yesterday"},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_delete_project path traversal",
+			taskType: "compose_delete_project",
+			payload:  map[string]interface{}{"project_name": "../../etc"},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_create_project absolute name",
+			taskType: "compose_create_project",
+			payload:  map[string]interface{}{"project_name": "/etc", "compose_content": "services: {}"},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_topology missing project_name",
+			taskType: "compose_topology",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_create_project non-string file content",
+			taskType: "compose_create_project",
+			payload:  map[string]interface{}{"project_name": "web", "compose_content": "services: {}", "files": map[string]interface{}{"secrets/key.txt": 42.0}},
+			wantErr:  true,
+		},
+		{
+			name:     "stack_audit missing project_name",
+			taskType: "stack_audit",
+			payload:  map[string]interface{}{"repair": true},
+			wantErr:  true,
+		},
+		{
+			name:     "image_load missing input_path",
+			taskType: "image_load",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "image_load nonexistent file",
+			taskType: "image_load",
+			payload:  map[string]interface{}{"input_path": "/nonexistent/image.tar"},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_import_archive missing archive",
+			taskType: "compose_import_archive",
+			payload:  map[string]interface{}{"project_name": "imported"},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_import_archive invalid base64",
+			taskType: "compose_import_archive",
+			payload:  map[string]interface{}{"project_name": "imported", "archive": "!!!"},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_export_archive missing project_name",
+			taskType: "compose_export_archive",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_export_archive relative output_path",
+			taskType: "compose_export_archive",
+			payload:  map[string]interface{}{"project_name": "web", "output_path": "web.tar.gz"},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_restart_service missing service",
+			taskType: "compose_restart_service",
+			payload:  map[string]interface{}{"project_name": "web"},
+			wantErr:  true,
+		},
+		{
+			name:     "compose_check_updates missing project_name",
+			taskType: "compose_check_updates",
+			payload:  map[string]interface{}{},
+			wantErr:  true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -197,7 +552,7 @@ func TestExecuteDockerCommand(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := manager.executeDockerCommand(tt.payload)
+			result, err := manager.executeDockerCommand(context.Background(), tt.payload)
 
 			if tt.wantErr && err == nil {
 				t.Error("Expected error but got none")
@@ -464,8 +819,1099 @@ func TestExecuteTaskSignature(t *testing.T) {
 	}
 
 	// Verify the error message
-	expectedErrorMsg := "unknown task type: unknown_task"
+	expectedErrorMsg := "invalid input: unknown task type: unknown_task"
 	if err.Error() != expectedErrorMsg {
 		t.Errorf("Expected error message '%s', got '%s'", expectedErrorMsg, err.Error())
 	}
+	if !errors.Is(err, errdefs.ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestValidateCommandBindMounts(t *testing.T) {
+	allowed := []string{"/srv/data"}
+
+	tests := []struct {
+		name    string
+		args    []string
+		allowed []string
+		wantErr bool
+	}{
+		{
+			name:    "no allowlist permits everything",
+			args:    []string{"-v", "/etc:/etc", "alpine"},
+			allowed: nil,
+			wantErr: false,
+		},
+		{
+			name:    "allowed bind mount",
+			args:    []string{"-v", "/srv/data/app:/app", "alpine"},
+			allowed: allowed,
+			wantErr: false,
+		},
+		{
+			name:    "named volume is not a bind mount",
+			args:    []string{"--volume", "appdata:/app", "alpine"},
+			allowed: allowed,
+			wantErr: false,
+		},
+		{
+			name:    "disallowed bind mount",
+			args:    []string{"-v", "/etc:/host-etc", "alpine"},
+			allowed: allowed,
+			wantErr: true,
+		},
+		{
+			name:    "disallowed bind mount with equals syntax",
+			args:    []string{"--volume=/var/run/docker.sock:/var/run/docker.sock", "alpine"},
+			allowed: allowed,
+			wantErr: true,
+		},
+		{
+			name:    "disallowed --mount bind",
+			args:    []string{"--mount", "type=bind,source=/root,target=/root", "alpine"},
+			allowed: allowed,
+			wantErr: true,
+		},
+		{
+			name:    "--mount volume is ignored",
+			args:    []string{"--mount", "type=volume,source=appdata,target=/app", "alpine"},
+			allowed: allowed,
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCommandBindMounts(tt.args, tt.allowed)
+			if tt.wantErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateDockerCommand(t *testing.T) {
+	allowed := []string{"/srv/data"}
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		allowed []string
+		wantErr bool
+	}{
+		{"no allowlist", "container", []string{"run", "-v", "/:/host", "alpine"}, nil, false},
+		{"run allowed mount", "run", []string{"-v", "/srv/data:/data", "alpine"}, allowed, false},
+		{"container run disallowed mount", "container", []string{"run", "-v", "/:/host", "alpine"}, allowed, true},
+		{"container create disallowed mount", "container", []string{"create", "--mount=type=bind,src=/etc,dst=/etc", "alpine"}, allowed, true},
+		{"volume create", "volume", []string{"create", "-o", "o=bind", "-o", "device=/", "host"}, allowed, true},
+		{"service create", "service", []string{"create", "--mount", "type=bind,src=/,dst=/host", "alpine"}, allowed, true},
+		{"compose", "compose", []string{"up", "-d"}, allowed, true},
+		{"global flag", "--host", []string{"tcp://other:2375", "run", "-v", "/:/host", "alpine"}, allowed, true},
+		{"volume ls", "volume", []string{"ls"}, allowed, false},
+		{"ps", "ps", []string{"-a"}, allowed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDockerCommand(tt.command, tt.args, tt.allowed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateDockerCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecuteVolumeCreateBindDevice(t *testing.T) {
+	manager := NewManager(docker.NewClient(), &config.Config{
+		ComposeBasePath:  t.TempDir(),
+		AllowedBindPaths: []string{"/srv/data"},
+	})
+
+	_, err := manager.ExecuteTask("volume_create", map[string]interface{}{
+		"name":        "host-etc",
+		"driver_opts": map[string]interface{}{"type": "none", "o": "bind", "device": "/etc"},
+	})
+	if !errors.Is(err, errdefs.ErrInvalidInput) || !strings.Contains(err.Error(), "/etc") {
+		t.Errorf("Expected invalid input naming /etc, got %v", err)
+	}
+}
+
+func TestStackPathsStayInsideStackDirectory(t *testing.T) {
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+
+	tests := []struct {
+		name     string
+		taskType string
+		payload  map[string]interface{}
+	}{
+		{"stack_name traversal", "stack_services", map[string]interface{}{"stack_name": "../../x"}},
+		{"compose_file traversal", "compose_ps", map[string]interface{}{"project_name": "web", "compose_file": "../../other/docker-compose.yml"}},
+		{"absolute compose_file", "compose_ps", map[string]interface{}{"project_name": "web", "compose_file": "/etc/compose.yml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.ExecuteTask(tt.taskType, tt.payload); !errors.Is(err, errdefs.ErrInvalidInput) {
+				t.Errorf("Expected invalid input, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReadLimited(t *testing.T) {
+	data, err := readLimited(strings.NewReader("12345"), 5)
+	if err != nil || string(data) != "12345" {
+		t.Errorf("readLimited() at the limit = %q, %v", data, err)
+	}
+
+	if _, err := readLimited(strings.NewReader("123456"), 5); !errors.Is(err, errTooLarge) {
+		t.Errorf("readLimited() past the limit = %v, expected errTooLarge", err)
+	}
+}
+
+func TestLimitedWriter(t *testing.T) {
+	var buf strings.Builder
+	w := &limitedWriter{w: &buf, limit: 5}
+	if _, err := w.Write([]byte("123")); err != nil {
+		t.Fatalf("Write() under the limit error = %v", err)
+	}
+	if _, err := w.Write([]byte("45")); err != nil {
+		t.Fatalf("Write() at the limit error = %v", err)
+	}
+	if _, err := w.Write([]byte("6")); !errors.Is(err, errTooLarge) {
+		t.Errorf("Write() past the limit = %v, expected errTooLarge", err)
+	}
+	if buf.String() != "12345" {
+		t.Errorf("written = %q, expected 12345", buf.String())
+	}
+}
+
+func TestGetStringSlice(t *testing.T) {
+	payload := map[string]interface{}{
+		"services": []interface{}{"web", "", 42, "db"},
+		"invalid":  "web",
+	}
+
+	services := getStringSlice(payload, "services")
+	if len(services) != 2 || services[0] != "web" || services[1] != "db" {
+		t.Errorf("Expected [web db], got %v", services)
+	}
+
+	if values := getStringSlice(payload, "invalid"); values != nil {
+		t.Errorf("Expected nil for non-list value, got %v", values)
+	}
+
+	if values := getStringSlice(payload, "missing"); values != nil {
+		t.Errorf("Expected nil for missing key, got %v", values)
+	}
+}
+
+func TestGetFilters(t *testing.T) {
+	filters := getFilters(map[string]interface{}{
+		"filters": map[string]interface{}{
+			"until": "24h",
+			"label": []interface{}{"env=dev", "tier=web"},
+		},
+	})
+
+	if len(filters["until"]) != 1 || filters["until"][0] != "24h" {
+		t.Errorf("Expected until filter [24h], got %v", filters["until"])
+	}
+
+	if len(filters["label"]) != 2 {
+		t.Errorf("Expected 2 label filters, got %v", filters["label"])
+	}
+
+	if filters := getFilters(map[string]interface{}{}); len(filters) != 0 {
+		t.Errorf("Expected empty filters, got %v", filters)
+	}
+}
+
+func TestContainerListFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		want    map[string][]string
+	}{
+		{
+			name:    "status",
+			payload: map[string]interface{}{"status": "running"},
+			want:    map[string][]string{"status": {"running"}},
+		},
+		{
+			name:    "labels",
+			payload: map[string]interface{}{"label": []interface{}{"env=prod", "tier=web"}},
+			want:    map[string][]string{"label": {"env=prod", "tier=web"}},
+		},
+		{
+			name:    "name merged with raw filters",
+			payload: map[string]interface{}{"name": "web", "filters": map[string]interface{}{"name": "db"}},
+			want:    map[string][]string{"name": {"db", "web"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFilters(t, containerListFilters(tt.payload), tt.want)
+		})
+	}
+}
+
+func TestImageListFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		want    map[string][]string
+	}{
+		{
+			name:    "dangling",
+			payload: map[string]interface{}{"dangling": true},
+			want:    map[string][]string{"dangling": {"true"}},
+		},
+		{
+			name:    "not dangling",
+			payload: map[string]interface{}{"dangling": false},
+			want:    map[string][]string{"dangling": {"false"}},
+		},
+		{
+			name:    "reference",
+			payload: map[string]interface{}{"reference": "nginx:*"},
+			want:    map[string][]string{"reference": {"nginx:*"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFilters(t, imageListFilters(tt.payload), tt.want)
+		})
+	}
+}
+
+func assertFilters(t *testing.T, got, want map[string][]string) {
+	t.Helper()
+	for key, values := range got {
+		if len(values) > 0 && want[key] == nil {
+			t.Errorf("unexpected filter %s=%v", key, values)
+		}
+	}
+	for key, values := range want {
+		if strings.Join(got[key], ",") != strings.Join(values, ",") {
+			t.Errorf("filter %s = %v, want %v", key, got[key], values)
+		}
+	}
+}
+
+func TestPaginateResult(t *testing.T) {
+	newResult := func() map[string]interface{} {
+		return map[string]interface{}{
+			"containers": []interface{}{"a", "b", "c", "d", "e"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		want    string
+	}{
+		{"no paging", map[string]interface{}{}, "a,b,c,d,e"},
+		{"limit", map[string]interface{}{"limit": float64(2)}, "a,b"},
+		{"offset and limit", map[string]interface{}{"offset": float64(1), "limit": float64(3)}, "b,c,d"},
+		{"limit past end", map[string]interface{}{"offset": float64(3), "limit": float64(10)}, "d,e"},
+		{"offset past end", map[string]interface{}{"offset": float64(9)}, ""},
+		{"huge limit", map[string]interface{}{"offset": float64(1), "limit": 1e20}, "b,c,d,e"},
+		{"huge offset", map[string]interface{}{"offset": 1e20, "limit": float64(2)}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := paginateResult(newResult(), "containers", tt.payload).(map[string]interface{})
+
+			var page []string
+			for _, item := range result["containers"].([]interface{}) {
+				page = append(page, item.(string))
+			}
+			if strings.Join(page, ",") != tt.want {
+				t.Errorf("page = %v, want %s", page, tt.want)
+			}
+			if result["total"] != 5 {
+				t.Errorf("total = %v, want 5", result["total"])
+			}
+		})
+	}
+}
+
+func TestParseResourceLimits(t *testing.T) {
+	resources, err := parseResourceLimits(map[string]interface{}{
+		"resources": map[string]interface{}{
+			"memory":  "1g",
+			"cpus":    float64(0.5),
+			"restart": "always",
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseResourceLimits() error = %v", err)
+	}
+	if resources.Memory != "1g" || resources.CPUs != 0.5 || resources.Restart != "always" {
+		t.Errorf("unexpected resources: %+v", resources)
+	}
+
+	invalid := []map[string]interface{}{
+		{},
+		{"resources": "1g"},
+		{"resources": map[string]interface{}{"memroy": "1g"}},
+		{"resources": map[string]interface{}{"cpus": "lots"}},
+	}
+	for _, payload := range invalid {
+		if _, err := parseResourceLimits(payload); err == nil {
+			t.Errorf("parseResourceLimits(%v) expected error", payload)
+		}
+	}
+}
+
+func TestParseStopTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		want    *int
+		wantErr bool
+	}{
+		{name: "omitted", payload: map[string]interface{}{}},
+		{name: "null", payload: map[string]interface{}{"timeout": nil}},
+		{name: "zero", payload: map[string]interface{}{"timeout": float64(0)}, want: intPtr(0)},
+		{name: "seconds", payload: map[string]interface{}{"timeout": float64(60)}, want: intPtr(60)},
+		{name: "negative", payload: map[string]interface{}{"timeout": float64(-5)}, wantErr: true},
+		{name: "fractional", payload: map[string]interface{}{"timeout": 1.5}, wantErr: true},
+		{name: "string", payload: map[string]interface{}{"timeout": "30"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStopTimeout(tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStopTimeout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("parseStopTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLogTimeRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   map[string]interface{}
+		wantSince string
+		wantUntil string
+		wantErr   bool
+	}{
+		{name: "omitted", payload: map[string]interface{}{}},
+		{name: "durations", payload: map[string]interface{}{"since": "1h30m", "until": "10m"}, wantSince: "1h30m", wantUntil: "10m"},
+		{name: "rfc3339", payload: map[string]interface{}{"since": "2024-01-02T15:04:05Z", "until": "2024-01-02T16:04:05.5+02:00"}, wantSince: "2024-01-02T15:04:05Z", wantUntil: "2024-01-02T16:04:05.5+02:00"},
+		{name: "invalid since", payload: map[string]interface{}{"since": "yesterday"}, wantErr: true},
+		{name: "negative until", payload: map[string]interface{}{"until": "-5m"}, wantErr: true},
+		{name: "date only", payload: map[string]interface{}{"until": "2024-01-02"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since, until, err := parseLogTimeRange(tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLogTimeRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if since != tt.wantSince || until != tt.wantUntil {
+				t.Errorf("parseLogTimeRange() = %q, %q, want %q, %q", since, until, tt.wantSince, tt.wantUntil)
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestRequireService(t *testing.T) {
+	spec := &compose.ProjectSpec{
+		Services: map[string]compose.ServiceSpec{
+			"web": {Image: "nginx"},
+			"db":  {Image: "postgres"},
+		},
+	}
+
+	if err := requireService(spec, "web"); err != nil {
+		t.Errorf("requireService(web) error = %v", err)
+	}
+
+	err := requireService(spec, "cache")
+	if err == nil {
+		t.Fatal("expected error for unknown service")
+	}
+	if !strings.Contains(err.Error(), "db, web") {
+		t.Errorf("expected available services in error, got %v", err)
+	}
+}
+
+func TestExecuteStackBatch(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := &config.Config{
+		ComposeBasePath: tempDir,
+	}
+	manager := NewManager(docker.NewClient(), cfg)
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "existing"), 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+
+	t.Run("validates payload", func(t *testing.T) {
+		if _, err := manager.ExecuteTask("stack_batch", map[string]interface{}{"ids": []interface{}{"a"}}); err == nil {
+			t.Error("Expected error for missing action")
+		}
+		if _, err := manager.ExecuteTask("stack_batch", map[string]interface{}{"action": "explode", "ids": []interface{}{"a"}}); err == nil {
+			t.Error("Expected error for unsupported action")
+		}
+		if _, err := manager.ExecuteTask("stack_batch", map[string]interface{}{"action": "restart"}); err == nil {
+			t.Error("Expected error for missing ids")
+		}
+	})
+
+	t.Run("reports per-stack results", func(t *testing.T) {
+		result, err := manager.ExecuteTask("stack_batch", map[string]interface{}{
+			"action": "restart",
+			"ids":    []interface{}{"missing-one", "existing", "missing-two"},
+		})
+		if err != nil {
+			t.Fatalf("Expected aggregated results, got error: %v", err)
+		}
+
+		resultMap := result.(map[string]interface{})
+		results := resultMap["results"].([]map[string]interface{})
+		if len(results) != 3 {
+			t.Fatalf("Expected 3 results, got %d", len(results))
+		}
+
+		for i, id := range []string{"missing-one", "existing", "missing-two"} {
+			if results[i]["id"] != id {
+				t.Errorf("Expected result %d to be for %s, got %v", i, id, results[i]["id"])
+			}
+		}
+
+		for _, i := range []int{0, 2} {
+			if results[i]["success"] != false {
+				t.Errorf("Expected missing stack %v to fail", results[i]["id"])
+			}
+			if errMsg, _ := results[i]["error"].(string); !strings.Contains(errMsg, "does not exist") {
+				t.Errorf("Expected 'does not exist' error, got %q", errMsg)
+			}
+		}
+
+		// The existing stack has no compose file or Docker here, so only the
+		// aggregate shape is asserted for it
+		if resultMap["total"] != 3 {
+			t.Errorf("Expected total 3, got %v", resultMap["total"])
+		}
+	})
+}
+
+func TestExecuteImageDelete(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the docker binary")
+	}
+
+	// A fake docker binary: "in-use" is held by a container, "missing" does not
+	// exist and anything else is removed
+	bin := filepath.Join(t.TempDir(), "fake-docker")
+	script := `#!/bin/sh
+for last; do :; done
+case "$last" in
+in-use) echo "Error response from daemon: conflict: unable to delete in-use (must be forced) - image is being used by stopped container 1a2b3c" >&2; exit 1 ;;
+missing) echo "Error response from daemon: No such image: missing" >&2; exit 1 ;;
+esac
+echo "Untagged: $last:latest"
+echo "Deleted: sha256:0123abcd"
+`
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker: %v", err)
+	}
+
+	cfg := &config.Config{ComposeBasePath: t.TempDir()}
+	manager := NewManager(docker.NewClientWithOptions(docker.ClientOptions{Binary: bin}), cfg)
+
+	if _, err := manager.ExecuteTask("image_delete", map[string]interface{}{}); err == nil {
+		t.Error("Expected error for missing ids")
+	}
+
+	result, err := manager.ExecuteTask("image_delete", map[string]interface{}{
+		"ids": []interface{}{"nginx", "in-use", "missing", "redis"},
+	})
+	if err != nil {
+		t.Fatalf("Expected aggregated results, got error: %v", err)
+	}
+
+	resultMap := result.(map[string]interface{})
+	if resultMap["succeeded"] != 2 || resultMap["failed"] != 2 {
+		t.Errorf("Expected 2 succeeded and 2 failed, got %v", resultMap)
+	}
+
+	want := []struct {
+		id      string
+		success bool
+		code    string
+	}{
+		{"nginx", true, ""},
+		{"in-use", false, errdefs.CodeConflict},
+		{"missing", false, errdefs.CodeNotFound},
+		{"redis", true, ""},
+	}
+	results := resultMap["results"].([]map[string]interface{})
+	for i, w := range want {
+		if results[i]["id"] != w.id || results[i]["success"] != w.success {
+			t.Errorf("result %d = %v, want id %s success %v", i, results[i], w.id, w.success)
+		}
+		if code, _ := results[i]["error_code"].(string); code != w.code {
+			t.Errorf("result %d error_code = %q, want %q", i, code, w.code)
+		}
+	}
+}
+
+func TestExecuteSystemFacts(t *testing.T) {
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+	manager.startedAt = time.Now().Add(-90 * time.Second)
+
+	result, err := manager.ExecuteTask("system_facts", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+	facts := result.(map[string]interface{})
+
+	// Docker and Compose facts may be empty here, but every key is reported
+	for _, key := range []string{
+		"platform", "architecture", "num_cpu", "go_version", "agent_version",
+		"memory_total", "memory_available", "kernel_version",
+		"docker_version", "compose_version", "started_at", "uptime_seconds",
+	} {
+		if _, ok := facts[key]; !ok {
+			t.Errorf("missing key %q in %v", key, facts)
+		}
+	}
+
+	if facts["platform"] != runtime.GOOS || facts["architecture"] != runtime.GOARCH {
+		t.Errorf("platform = %v/%v, want %s/%s", facts["platform"], facts["architecture"], runtime.GOOS, runtime.GOARCH)
+	}
+	if uptime, _ := facts["uptime_seconds"].(int64); uptime < 90 {
+		t.Errorf("uptime_seconds = %v, want at least 90", facts["uptime_seconds"])
+	}
+}
+
+func TestServiceNameFromContainer(t *testing.T) {
+	tests := map[string]string{
+		"myapp-web-1":   "web",
+		"myapp-web-12":  "web",
+		"myapp_db_1":    "db",
+		"myapp-worker":  "worker",
+		"standalone":    "standalone",
+		"myapp-redis-a": "a",
+	}
+
+	for input, expected := range tests {
+		if got := serviceNameFromContainer(input); got != expected {
+			t.Errorf("serviceNameFromContainer(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestParseComposeServicesOutputScaled(t *testing.T) {
+	cfg := &config.Config{
+		ComposeBasePath: "/opt/compose-projects",
+	}
+	manager := NewManager(docker.NewClient(), cfg)
+
+	output := `{"ID":"a1","Name":"myapp-web-1","Service":"web","State":"running"}
+{"ID":"a2","Name":"myapp-web-2","Service":"web","State":"running"}
+{"ID":"a3","Name":"myapp-web-3","Service":"web","State":"running"}
+{"ID":"b1","Name":"myapp-db-1","Service":"db","State":"running"}`
+
+	services := manager.parseComposeServicesOutput(output)
+	if len(services) != 4 {
+		t.Fatalf("Expected 4 service containers, got %d", len(services))
+	}
+
+	for _, svc := range services[:3] {
+		if svc["name"] != "web" {
+			t.Errorf("Expected replica to be named 'web', got %v", svc["name"])
+		}
+	}
+
+	serviceCount, runningCount := summarizeServices(services)
+	if serviceCount != 2 || runningCount != 2 {
+		t.Errorf("Expected 2/2 running services, got %d/%d", runningCount, serviceCount)
+	}
+
+	// One stopped replica means the service is not fully running
+	output = strings.Replace(output, `"myapp-web-3","Service":"web","State":"running"`, `"myapp-web-3","Service":"web","State":"exited"`, 1)
+	serviceCount, runningCount = summarizeServices(manager.parseComposeServicesOutput(output))
+	if serviceCount != 2 || runningCount != 1 {
+		t.Errorf("Expected 1/2 running services, got %d/%d", runningCount, serviceCount)
+	}
+}
+
+func TestParseComposeServicesOutputFormats(t *testing.T) {
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{
+			name: "json lines",
+			output: `{"ID":"a1","Name":"shop-web-1","Service":"web","State":"running","Ports":"0.0.0.0:8080->80/tcp"}
+{"ID":"b1","Name":"shop-db-1","Service":"db","State":"exited"}`,
+		},
+		{
+			name:   "json array",
+			output: `[{"ID":"a1","Name":"shop-web-1","Service":"web","State":"running","Ports":"0.0.0.0:8080->80/tcp"},{"ID":"b1","Name":"shop-db-1","Service":"db","State":"exited"}]`,
+		},
+		{
+			name: "legacy table",
+			output: `NAME                COMMAND                  SERVICE             STATUS                     PORTS
+shop-web-1          "/docker-entrypoint.…"   web                 running                    0.0.0.0:8080->80/tcp
+shop-db-1           "docker-entrypoint.s…"   db                  exited (0)
+`,
+		},
+		{
+			name: "table with image and created columns",
+			output: `NAME         IMAGE          COMMAND                  SERVICE   CREATED         STATUS                   PORTS
+shop-web-1   nginx:1.27     "/docker-entrypoint.…"   web       2 minutes ago   Up 2 minutes             0.0.0.0:8080->80/tcp
+shop-db-1    postgres:16    "docker-entrypoint.s…"   db        2 minutes ago   Exited (0) 1 minute ago
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services := manager.parseComposeServicesOutput(tt.output)
+			if len(services) != 2 {
+				t.Fatalf("Expected 2 services, got %d: %v", len(services), services)
+			}
+
+			web, db := services[0], services[1]
+			if web["name"] != "web" || db["name"] != "db" {
+				t.Errorf("names = %v, %v, want web, db", web["name"], db["name"])
+			}
+			if running := web["state"].(map[string]interface{})["Running"]; running != true {
+				t.Errorf("Expected web to be running, got %v", web["state"])
+			}
+			if running := db["state"].(map[string]interface{})["Running"]; running != false {
+				t.Errorf("Expected db to be stopped, got %v", db["state"])
+			}
+			if ports := web["ports"].([]map[string]interface{}); len(ports) != 1 {
+				t.Errorf("Expected one web port, got %v", ports)
+			}
+		})
+	}
+
+	if services := manager.parseComposeServicesOutput("no such service: web"); len(services) != 0 {
+		t.Errorf("Expected no services from unrecognized output, got %v", services)
+	}
+}
+
+func TestComputeStackStatus(t *testing.T) {
+	svc := func(name, state string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":  name,
+			"state": map[string]interface{}{"Running": isRunningState(state), "Status": state},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		services []map[string]interface{}
+		status   string
+		running  int
+		total    int
+	}{
+		{"no services", nil, "unknown", 0, 0},
+		{"running", []map[string]interface{}{svc("web", "running")}, "running", 1, 1},
+		{"up", []map[string]interface{}{svc("web", "Up 5 minutes")}, "running", 1, 1},
+		{"up healthy", []map[string]interface{}{svc("web", "Up 2 hours (healthy)")}, "running", 1, 1},
+		{"exited", []map[string]interface{}{svc("web", "exited")}, "stopped", 0, 1},
+		{"restarting", []map[string]interface{}{svc("web", "restarting")}, "stopped", 0, 1},
+		{"paused", []map[string]interface{}{svc("web", "Up 1 minute (Paused)")}, "stopped", 0, 1},
+		{"mixed", []map[string]interface{}{svc("web", "Up 1 minute"), svc("db", "Exited (1) 3 seconds ago")}, "partially running", 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, total, running := computeStackStatus(tt.services)
+			if status != tt.status || running != tt.running || total != tt.total {
+				t.Errorf("computeStackStatus() = %q %d/%d, want %q %d/%d", status, running, total, tt.status, tt.running, tt.total)
+			}
+		})
+	}
+}
+
+func TestParseComposeUpOptions(t *testing.T) {
+	opts := parseComposeUpOptions(map[string]interface{}{})
+	if opts.ForceRecreate || len(opts.Profiles) != 0 || len(opts.EnvOverrides) != 0 {
+		t.Errorf("Expected zero options for empty payload, got %+v", opts)
+	}
+
+	opts = parseComposeUpOptions(map[string]interface{}{
+		"force_recreate": true,
+		"profiles":       []interface{}{"debug"},
+		"env_overrides":  map[string]interface{}{"TAG": "2.0"},
+	})
+	if !opts.ForceRecreate {
+		t.Error("Expected ForceRecreate to be set")
+	}
+	if len(opts.Profiles) != 1 || opts.Profiles[0] != "debug" {
+		t.Errorf("Expected profiles [debug], got %v", opts.Profiles)
+	}
+	if opts.EnvOverrides["TAG"] != "2.0" {
+		t.Errorf("Expected TAG override '2.0', got %v", opts.EnvOverrides)
+	}
+}
+
+func TestExecuteComposeValidateMissingEnvFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker-compose is a shell script")
+	}
+
+	// The fake fails like compose when the stack's env_file is absent
+	bin := t.TempDir()
+	script := `#!/bin/sh
+while [ $# -gt 0 ]; do
+  [ "$1" = "-f" ] && dir="$(dirname "$2")"
+  shift
+done
+if [ ! -f "$dir/secrets.env" ]; then
+  echo "env file $dir/secrets.env not found: stat $dir/secrets.env: no such file or directory" >&2
+  exit 1
+fi
+echo '{"name":"web","services":{"web":{"image":"nginx"}}}'
+`
+	if err := os.WriteFile(filepath.Join(bin, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker-compose: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+	content := "services:\n  web:\n    image: nginx\n    env_file: secrets.env\n"
+	if err := manager.composeManager.CreateProject(compose.ProjectConfig{Name: "web", Content: content}); err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+
+	_, err := manager.ExecuteTask("compose_validate", map[string]interface{}{"project_name": "web"})
+	if !errors.Is(err, errdefs.ErrInvalidInput) || !strings.Contains(err.Error(), "secrets.env") {
+		t.Fatalf("Expected invalid input naming secrets.env, got %v", err)
+	}
+
+	// Supplying the env file as a project file makes the stack valid
+	err = manager.composeManager.UpdateProject(compose.ProjectConfig{
+		Name:    "web",
+		Content: content,
+		Files:   map[string]string{"secrets.env": "API_KEY=abc\n"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateProject() error = %v", err)
+	}
+	result, err := manager.ExecuteTask("compose_validate", map[string]interface{}{"project_name": "web"})
+	if err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+	if result.(map[string]interface{})["valid"] != true {
+		t.Errorf("Expected valid project, got %v", result)
+	}
+}
+
+func TestComposeUpValidatesBindMountsWithOverrides(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker-compose is a shell script")
+	}
+
+	// The fake interpolates the bind source from DATA like compose would
+	bin := t.TempDir()
+	script := `#!/bin/sh
+for arg in "$@"; do
+  if [ "$arg" = "config" ]; then
+    echo "{\"name\":\"web\",\"services\":{\"web\":{\"image\":\"nginx\",\"volumes\":[{\"type\":\"bind\",\"source\":\"${DATA:-/srv/ok}\",\"target\":\"/data\"}]}}}"
+    exit 0
+  fi
+done
+exit 0
+`
+	if err := os.WriteFile(filepath.Join(bin, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker-compose: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	manager := NewManager(docker.NewClient(), &config.Config{
+		ComposeBasePath:  t.TempDir(),
+		AllowedBindPaths: []string{"/srv/ok"},
+	})
+	if err := manager.composeManager.CreateProject(compose.ProjectConfig{
+		Name:    "web",
+		Content: "services:\n  web:\n    image: nginx\n    volumes:\n      - ${DATA}:/data\n",
+	}); err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+
+	if _, err := manager.ExecuteTask("compose_up", map[string]interface{}{"project_name": "web"}); err != nil {
+		t.Fatalf("compose_up without overrides: unexpected error %v", err)
+	}
+
+	_, err := manager.ExecuteTask("compose_up", map[string]interface{}{
+		"project_name":  "web",
+		"env_overrides": map[string]interface{}{"DATA": "/"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "not in the allowed") {
+		t.Fatalf("compose_up with DATA=/: expected a bind mount error, got %v", err)
+	}
+}
+
+func TestCollectStacksCountsExitedReplicas(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker-compose is a shell script")
+	}
+
+	// The fake only lists the exited replica when asked for all containers
+	bin := t.TempDir()
+	script := `#!/bin/sh
+echo '{"Name":"web-web-1","Service":"web","State":"running"}'
+for arg in "$@"; do
+  [ "$arg" = "--all" ] && echo '{"Name":"web-web-2","Service":"web","State":"exited"}'
+done
+exit 0
+`
+	if err := os.WriteFile(filepath.Join(bin, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker-compose: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+	if err := manager.composeManager.CreateProject(compose.ProjectConfig{Name: "web", Content: "services:\n  web:\n    image: nginx\n"}); err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+
+	stacks, err := manager.collectStacks(context.Background())
+	if err != nil {
+		t.Fatalf("collectStacks() error = %v", err)
+	}
+	if len(stacks) != 1 || stacks[0]["status"] != "stopped" {
+		t.Errorf("expected a service with a dead replica not to count as running, got %v", stacks)
+	}
+}
+
+func TestExecuteComposeDeclared(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker-compose is a shell script")
+	}
+
+	bin := t.TempDir()
+	script := `#!/bin/sh
+cat <<'JSON'
+{
+  "name": "shop",
+  "services": {
+    "web": {"image": "nginx:1.27", "depends_on": {"api": {"condition": "service_started"}}},
+    "api": {"image": "shop/api:2.1", "volumes": [{"type": "volume", "source": "uploads", "target": "/uploads"}]},
+    "worker": {"image": "shop/api:2.1"},
+    "db": {"image": "postgres:16", "volumes": [{"type": "volume", "source": "pgdata", "target": "/var/lib/postgresql/data"}]}
+  },
+  "volumes": {"pgdata": {}, "uploads": {}}
+}
+JSON
+`
+	if err := os.WriteFile(filepath.Join(bin, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker-compose: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+	if err := manager.composeManager.CreateProject(compose.ProjectConfig{Name: "shop", Content: "services:\n  web:\n    image: nginx\n"}); err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+
+	tests := []struct {
+		kind string
+		want []string
+	}{
+		{"services", []string{"api", "db", "web", "worker"}},
+		{"volumes", []string{"pgdata", "uploads"}},
+		{"images", []string{"nginx:1.27", "postgres:16", "shop/api:2.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			result, err := manager.ExecuteTask("compose_declared", map[string]interface{}{"project_name": "shop", "kind": tt.kind})
+			if err != nil {
+				t.Fatalf("ExecuteTask() error = %v", err)
+			}
+			items := result.(map[string]interface{})["items"].([]string)
+			if !reflect.DeepEqual(items, tt.want) {
+				t.Errorf("%s = %v, want %v", tt.kind, items, tt.want)
+			}
+		})
+	}
+
+	if _, err := manager.ExecuteTask("compose_declared", map[string]interface{}{"project_name": "shop", "kind": "networks"}); !errors.Is(err, errdefs.ErrInvalidInput) {
+		t.Errorf("Expected invalid input for an unknown kind, got %v", err)
+	}
+}
+
+func TestExecuteComposeUpSelectedFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker-compose is a shell script")
+	}
+
+	bin := t.TempDir()
+	calls := filepath.Join(bin, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + calls + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker-compose: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	base := t.TempDir()
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: base})
+	err := manager.composeManager.CreateProject(compose.ProjectConfig{
+		Name:    "shop",
+		Content: "services:\n  web:\n    image: nginx\n",
+		Files:   map[string]string{"compose.prod.yaml": "services:\n  web:\n    restart: always\n"},
+	})
+	if err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+
+	upArgs := func(payload map[string]interface{}) string {
+		t.Helper()
+		os.Remove(calls)
+		if _, err := manager.ExecuteTask("compose_up", payload); err != nil {
+			t.Fatalf("compose_up error = %v", err)
+		}
+		data, _ := os.ReadFile(calls)
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.Contains(line, " up ") {
+				return line
+			}
+		}
+		t.Fatalf("compose up was not run, calls: %s", data)
+		return ""
+	}
+
+	dir := filepath.Join(base, "shop")
+	if got := upArgs(map[string]interface{}{"project_name": "shop"}); !strings.Contains(got, "-f "+filepath.Join(dir, "docker-compose.yml")) {
+		t.Errorf("Expected the default compose file, got %q", got)
+	}
+
+	got := upArgs(map[string]interface{}{"project_name": "shop", "compose_files": []interface{}{"compose.prod.yaml"}})
+	if !strings.Contains(got, "-f "+filepath.Join(dir, "compose.prod.yaml")) || strings.Contains(got, "docker-compose.yml") {
+		t.Errorf("Expected only the selected compose file, got %q", got)
+	}
+
+	_, err = manager.ExecuteTask("compose_up", map[string]interface{}{"project_name": "shop", "compose_file": "compose.staging.yaml"})
+	if !errors.Is(err, errdefs.ErrNotFound) {
+		t.Errorf("Expected not found for a compose file missing from the stack, got %v", err)
+	}
+}
+
+func TestExecuteComposeRemoveKeepFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker-compose is a shell script")
+	}
+
+	bin := t.TempDir()
+	calls := filepath.Join(bin, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + calls + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker-compose: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+	if err := manager.composeManager.CreateProject(compose.ProjectConfig{Name: "web", Content: "services:\n  web:\n    image: nginx\n", ComposeProjectName: "shared-infra"}); err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+
+	result, err := manager.ExecuteTask("compose_remove", map[string]interface{}{"project_name": "web", "remove_files": false})
+	if err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+	if status := result.(map[string]interface{})["status"]; status != "stopped" {
+		t.Errorf("Expected status stopped, got %v", status)
+	}
+	if data, _ := os.ReadFile(calls); !strings.Contains(string(data), "-p shared-infra") || !strings.Contains(string(data), "down") {
+		t.Errorf("Expected compose down for project shared-infra, calls: %s", data)
+	}
+	if !manager.composeManager.ProjectExists("web") {
+		t.Fatal("Expected project files to be kept")
+	}
+
+	// The default still removes the files, after taking down the project
+	// under its compose project name override
+	if err := manager.composeManager.UpdateProject(compose.ProjectConfig{Name: "web", Content: "services:\n  web:\n    image: nginx\n", ComposeProjectName: "shared-infra"}); err != nil {
+		t.Fatalf("UpdateProject() error = %v", err)
+	}
+	if err := os.Remove(calls); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.ExecuteTask("compose_remove", map[string]interface{}{"project_name": "web"}); err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+	if manager.composeManager.ProjectExists("web") {
+		t.Error("Expected project files to be removed")
+	}
+	if data, _ := os.ReadFile(calls); !strings.Contains(string(data), "-p shared-infra") || !strings.Contains(string(data), "down") {
+		t.Errorf("Expected compose down for project shared-infra, calls: %s", data)
+	}
+}
+
+func TestExecuteComposeCreateAndStart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker-compose is a shell script")
+	}
+
+	bin := t.TempDir()
+	calls := filepath.Join(bin, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + calls + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker-compose: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	manager := NewManager(docker.NewClient(), &config.Config{ComposeBasePath: t.TempDir()})
+	if err := manager.composeManager.CreateProject(compose.ProjectConfig{Name: "shop", Content: "services:\n  web:\n    image: nginx\n"}); err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+
+	tests := []struct {
+		taskType string
+		payload  map[string]interface{}
+		want     string
+		status   string
+	}{
+		{"compose_create", map[string]interface{}{"project_name": "shop"}, "-p shop create", "created"},
+		{"compose_start", map[string]interface{}{"project_name": "shop", "services": []interface{}{"web", "worker"}}, "-p shop start web worker", "started"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.taskType, func(t *testing.T) {
+			os.Remove(calls)
+			result, err := manager.ExecuteTask(tt.taskType, tt.payload)
+			if err != nil {
+				t.Fatalf("ExecuteTask() error = %v", err)
+			}
+			data, _ := os.ReadFile(calls)
+			if got := strings.TrimSpace(string(data)); !strings.HasSuffix(got, tt.want) || strings.Contains(got, " up ") {
+				t.Errorf("compose args = %q, want suffix %q", got, tt.want)
+			}
+			if status := result.(map[string]interface{})["status"]; status != tt.status {
+				t.Errorf("status = %v, want %s", status, tt.status)
+			}
+		})
+	}
 }
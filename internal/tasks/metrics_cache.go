@@ -0,0 +1,106 @@
+package tasks
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/hostinfo"
+)
+
+// HeartbeatSnapshot is the part of a heartbeat that is expensive to collect.
+// Metrics and DockerInfo are nil when docker could not be queried.
+type HeartbeatSnapshot struct {
+	Metrics    interface{}
+	DockerInfo interface{}
+	Host       hostinfo.Host
+}
+
+// metricsSource collects the resource counts, docker info and host usage sent
+// with each heartbeat
+type metricsSource interface {
+	collect(ctx context.Context) *HeartbeatSnapshot
+}
+
+type dockerMetricsSource struct {
+	client   *docker.Client
+	basePath string
+}
+
+func (d *dockerMetricsSource) collect(ctx context.Context) *HeartbeatSnapshot {
+	snapshot := &HeartbeatSnapshot{Host: hostinfo.Collect(d.basePath)}
+	if metrics, err := d.client.GetMetrics(ctx); err == nil {
+		snapshot.Metrics = metrics
+	} else {
+		slog.Warn("Failed to collect metrics", "error", err)
+	}
+	if info, err := d.client.GetDockerInfo(ctx); err == nil {
+		snapshot.DockerInfo = info
+	}
+	return snapshot
+}
+
+// metricsCache holds the last heartbeat snapshot. Collecting shells out to
+// docker, df and free, so it runs on METRICS_INTERVAL rather than on every
+// heartbeat.
+type metricsCache struct {
+	mu        sync.RWMutex
+	snapshot  *HeartbeatSnapshot
+	updatedAt time.Time
+}
+
+func (c *metricsCache) store(snapshot *HeartbeatSnapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.snapshot = snapshot
+	c.updatedAt = time.Now()
+}
+
+func (c *metricsCache) load() (*HeartbeatSnapshot, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.snapshot, !c.updatedAt.IsZero()
+}
+
+// Heartbeat returns the snapshot to report in a heartbeat: the cached one
+// when it exists, otherwise a fresh collection. With METRICS_INTERVAL zero,
+// every heartbeat collects.
+func (m *Manager) Heartbeat(ctx context.Context) *HeartbeatSnapshot {
+	if m.config.MetricsInterval > 0 {
+		if snapshot, ok := m.metricsCache.load(); ok {
+			return snapshot
+		}
+	}
+
+	snapshot := m.metricsSource.collect(ctx)
+	m.metricsCache.store(snapshot)
+	return snapshot
+}
+
+// RunMetricsCollector refreshes the cached heartbeat snapshot every
+// METRICS_INTERVAL until ctx is cancelled. It returns immediately when the
+// interval is zero.
+func (m *Manager) RunMetricsCollector(ctx context.Context) {
+	interval := m.config.MetricsInterval
+	if interval <= 0 {
+		return
+	}
+
+	m.metricsCache.store(m.metricsSource.collect(ctx))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.metricsCache.store(m.metricsSource.collect(ctx))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package tasks
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+type countingMetricsSource struct {
+	calls atomic.Int32
+}
+
+func (c *countingMetricsSource) collect(ctx context.Context) *HeartbeatSnapshot {
+	n := c.calls.Add(1)
+	return &HeartbeatSnapshot{
+		Metrics:    map[string]interface{}{"collection": int(n)},
+		DockerInfo: map[string]interface{}{"collection": int(n)},
+	}
+}
+
+func TestHeartbeatUsesMetricsInterval(t *testing.T) {
+	cfg := &config.Config{ComposeBasePath: t.TempDir(), MetricsInterval: 50 * time.Millisecond}
+	manager := NewManager(docker.NewClient(), cfg)
+	source := &countingMetricsSource{}
+	manager.metricsSource = source
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		manager.RunMetricsCollector(ctx)
+		close(done)
+	}()
+
+	// Heartbeats far more often than the metrics interval
+	deadline := time.Now().Add(180 * time.Millisecond)
+	heartbeats := 0
+	for time.Now().Before(deadline) {
+		if snapshot := manager.Heartbeat(ctx); snapshot.Metrics == nil || snapshot.DockerInfo == nil {
+			t.Fatalf("Heartbeat() = %+v, expected metrics and docker info", snapshot)
+		}
+		heartbeats++
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	calls := int(source.calls.Load())
+	// One collection at start plus one per elapsed interval, with slack for
+	// a heartbeat racing the first collection
+	if calls < 2 || calls > 6 {
+		t.Errorf("Expected the snapshot collected on the 50ms interval, got %d collections for %d heartbeats", calls, heartbeats)
+	}
+	if calls >= heartbeats {
+		t.Errorf("Expected fewer collections (%d) than heartbeats (%d)", calls, heartbeats)
+	}
+}
+
+func TestHeartbeatWithoutInterval(t *testing.T) {
+	cfg := &config.Config{ComposeBasePath: t.TempDir()}
+	manager := NewManager(docker.NewClient(), cfg)
+	source := &countingMetricsSource{}
+	manager.metricsSource = source
+
+	// The collector is disabled and every heartbeat collects
+	manager.RunMetricsCollector(context.Background())
+	for i := 0; i < 3; i++ {
+		manager.Heartbeat(context.Background())
+	}
+	if calls := source.calls.Load(); calls != 3 {
+		t.Errorf("Expected a collection per heartbeat, got %d", calls)
+	}
+}
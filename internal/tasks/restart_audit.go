@@ -0,0 +1,120 @@
+package tasks
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/compose"
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+// restartPolicyFinding compares one container's restart policy with the
+// policy its service declares
+type restartPolicyFinding struct {
+	Service     string `json:"service"`
+	Container   string `json:"container"`
+	ContainerID string `json:"container_id"`
+	Declared    string `json:"declared"`
+	Actual      string `json:"actual"`
+	Matches     bool   `json:"matches"`
+	Repaired    bool   `json:"repaired,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// executeStackAudit reports containers whose restart policy drifted from the
+// compose file, e.g. after a manual `docker update`. With repair set, each
+// drifted container gets its declared policy back in place.
+func (m *Manager) executeStackAudit(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+	repair, _ := payload["repair"].(bool)
+
+	output, err := m.dockerClient.ComposeConfig(ctx, composePath, projectName)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := compose.ParseProjectSpec([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := m.dockerClient.ProjectRestartPolicies(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := auditRestartPolicies(spec, containers)
+
+	if repair {
+		release, err := m.locks.acquire(ctx, projectName, stackLockTimeout)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	mismatched, repaired := 0, 0
+	for i := range findings {
+		finding := &findings[i]
+		if finding.Matches {
+			continue
+		}
+		mismatched++
+		if !repair {
+			continue
+		}
+		if err := m.dockerClient.UpdateRestartPolicy(ctx, finding.ContainerID, finding.Declared); err != nil {
+			finding.Error = err.Error()
+			continue
+		}
+		finding.Repaired = true
+		repaired++
+	}
+
+	return map[string]interface{}{
+		"project_name": projectName,
+		"containers":   findings,
+		"mismatched":   mismatched,
+		"repaired":     repaired,
+	}, nil
+}
+
+// auditRestartPolicies pairs each container with its service's declared
+// policy. Containers of services no longer declared are skipped.
+func auditRestartPolicies(spec *compose.ProjectSpec, containers []docker.ContainerRestartPolicy) []restartPolicyFinding {
+	findings := []restartPolicyFinding{}
+	for _, container := range containers {
+		service, ok := spec.Services[container.Service]
+		if !ok {
+			continue
+		}
+
+		declared := normalizeRestartPolicy(service.Restart)
+		actual := normalizeRestartPolicy(container.Policy)
+		findings = append(findings, restartPolicyFinding{
+			Service:     container.Service,
+			Container:   container.Name,
+			ContainerID: container.ID,
+			Declared:    declared,
+			Actual:      actual,
+			Matches:     declared == actual,
+		})
+	}
+	return findings
+}
+
+// normalizeRestartPolicy maps equivalent spellings onto one form: an unset
+// policy is "no" and "on-failure:0" is plain "on-failure"
+func normalizeRestartPolicy(policy string) string {
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	switch {
+	case policy == "":
+		return "no"
+	case policy == "on-failure:0":
+		return "on-failure"
+	default:
+		return policy
+	}
+}
@@ -0,0 +1,54 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/compose"
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+func TestAuditRestartPolicies(t *testing.T) {
+	spec := &compose.ProjectSpec{Services: map[string]compose.ServiceSpec{
+		"web":    {Image: "nginx", Restart: "unless-stopped"},
+		"worker": {Image: "app", Restart: "on-failure:0"},
+		"cron":   {Image: "app"},
+	}}
+	containers := []docker.ContainerRestartPolicy{
+		{ID: "a1", Name: "shop-web-1", Service: "web", Policy: "no"},
+		{ID: "b1", Name: "shop-worker-1", Service: "worker", Policy: "on-failure"},
+		{ID: "c1", Name: "shop-cron-1", Service: "cron", Policy: ""},
+		{ID: "d1", Name: "shop-legacy-1", Service: "legacy", Policy: "always"},
+	}
+
+	findings := auditRestartPolicies(spec, containers)
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 findings (orphan skipped), got %d: %+v", len(findings), findings)
+	}
+
+	want := []restartPolicyFinding{
+		{Service: "web", Container: "shop-web-1", ContainerID: "a1", Declared: "unless-stopped", Actual: "no", Matches: false},
+		{Service: "worker", Container: "shop-worker-1", ContainerID: "b1", Declared: "on-failure", Actual: "on-failure", Matches: true},
+		{Service: "cron", Container: "shop-cron-1", ContainerID: "c1", Declared: "no", Actual: "no", Matches: true},
+	}
+	for i := range want {
+		if findings[i] != want[i] {
+			t.Errorf("finding %d = %+v, want %+v", i, findings[i], want[i])
+		}
+	}
+}
+
+func TestNormalizeRestartPolicy(t *testing.T) {
+	tests := map[string]string{
+		"":               "no",
+		"no":             "no",
+		" Always ":       "always",
+		"on-failure:0":   "on-failure",
+		"on-failure:3":   "on-failure:3",
+		"unless-stopped": "unless-stopped",
+	}
+	for input, want := range tests {
+		if got := normalizeRestartPolicy(input); got != want {
+			t.Errorf("normalizeRestartPolicy(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
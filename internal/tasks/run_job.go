@@ -0,0 +1,48 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+const (
+	// defaultRunJobTimeout applies when a run_job task sets no timeout
+	defaultRunJobTimeout = 60 * time.Second
+	// defaultRunJobMaxTimeout is used when RUN_JOB_MAX_TIMEOUT is unset
+	defaultRunJobMaxTimeout = 10 * time.Minute
+)
+
+// executeRunJob runs a one-shot container and returns its output and exit
+// code. timeout is in seconds and may not exceed RUN_JOB_MAX_TIMEOUT.
+func (m *Manager) executeRunJob(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	image, ok := payload["image"].(string)
+	if !ok || image == "" {
+		return nil, fmt.Errorf("missing image")
+	}
+
+	maxTimeout := m.config.RunJobMaxTimeout
+	if maxTimeout <= 0 {
+		maxTimeout = defaultRunJobMaxTimeout
+	}
+	timeout := min(defaultRunJobTimeout, maxTimeout)
+	if seconds, ok := payload["timeout"].(float64); ok {
+		if seconds <= 0 {
+			return nil, fmt.Errorf("%w: timeout must be positive", errdefs.ErrInvalidInput)
+		}
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	if timeout > maxTimeout {
+		return nil, fmt.Errorf("%w: timeout %v exceeds the maximum of %v", errdefs.ErrInvalidInput, timeout, maxTimeout)
+	}
+
+	return m.dockerClient.RunJob(ctx, docker.RunJobOptions{
+		Image:   image,
+		Command: getStringSlice(payload, "command"),
+		Env:     getStringMap(payload, "env"),
+		Timeout: timeout,
+	})
+}
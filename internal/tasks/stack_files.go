@@ -0,0 +1,135 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ofkm/arcane-agent/internal/compose"
+	"github.com/ofkm/arcane-agent/internal/config"
+)
+
+// ErrEnvAccessDisabled is returned when STACK_ENV_ACCESS is "none"
+var ErrEnvAccessDisabled = errors.New("stack env file access is disabled")
+
+// maskedEnvValue replaces env values when STACK_ENV_ACCESS is "masked"
+const maskedEnvValue = "********"
+
+// executeComposeGetFile returns a project's main compose file on its own
+func (m *Manager) executeComposeGetFile(payload map[string]interface{}) (interface{}, error) {
+	projectName, ok := payload["project_name"].(string)
+	if !ok || projectName == "" {
+		return nil, fmt.Errorf("project_name is required")
+	}
+	composeFile, _ := payload["compose_file"].(string)
+
+	path := m.composeManager.GetComposeFiles(projectName, composeFile)[0]
+	content, err := readStackFile(projectName, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"project_name": projectName,
+		"filename":     filepath.Base(path),
+		"content_type": "text/yaml",
+		"content":      content,
+	}, nil
+}
+
+// executeComposeGetEnv returns a project's .env file on its own, subject to
+// STACK_ENV_ACCESS
+func (m *Manager) executeComposeGetEnv(payload map[string]interface{}) (interface{}, error) {
+	projectName, ok := payload["project_name"].(string)
+	if !ok || projectName == "" {
+		return nil, fmt.Errorf("project_name is required")
+	}
+
+	if m.envAccess() == config.StackEnvNone {
+		return nil, ErrEnvAccessDisabled
+	}
+
+	path := filepath.Join(m.composeManager.GetComposeDir(projectName), ".env")
+	content, err := readStackFile(projectName, path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, masked := m.redactEnv(content)
+	return map[string]interface{}{
+		"project_name": projectName,
+		"filename":     ".env",
+		"content_type": "text/plain",
+		"content":      content,
+		"masked":       masked,
+	}, nil
+}
+
+// readStackFile reads a project file, keeping os.ErrNotExist in the chain so
+// callers can tell a missing file from other failures
+func readStackFile(projectName, path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%s not found for project %s: %w", filepath.Base(path), projectName, os.ErrNotExist)
+		}
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(content), nil
+}
+
+func (m *Manager) envAccess() string {
+	if m.config.StackEnvAccess == "" {
+		return config.StackEnvFull
+	}
+	return m.config.StackEnvAccess
+}
+
+// redactEnv applies STACK_ENV_ACCESS to .env content. It reports whether the
+// content was altered.
+func (m *Manager) redactEnv(content string) (string, bool) {
+	switch m.envAccess() {
+	case config.StackEnvNone:
+		return "", content != ""
+	case config.StackEnvMasked:
+		return maskEnvValues(content), content != ""
+	default:
+		return content, false
+	}
+}
+
+// maskEnvValues hides the values of KEY=VALUE lines, keeping keys, comments
+// and blank lines so the file structure stays readable
+func maskEnvValues(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if key, _, ok := strings.Cut(line, "="); ok {
+			lines[i] = key + "=" + maskedEnvValue
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// redactTopologyEnv applies STACK_ENV_ACCESS to declared service environments
+func (m *Manager) redactTopologyEnv(topology *compose.Topology) {
+	access := m.envAccess()
+	if access == config.StackEnvFull {
+		return
+	}
+	for i := range topology.Services {
+		service := &topology.Services[i]
+		if access == config.StackEnvNone {
+			service.Environment = map[string]string{}
+			continue
+		}
+		for key := range service.Environment {
+			service.Environment[key] = maskedEnvValue
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// stackLockTimeout bounds how long a mutating task waits for another
+// operation on the same stack before giving up
+const stackLockTimeout = 10 * time.Second
+
+// ErrStackBusy is returned when another operation holds a stack's lock
+var ErrStackBusy = fmt.Errorf("%w: stack is busy", errdefs.ErrConflict)
+
+// mutatingStackTasks lists the task types that change a stack's files or
+// containers and so must not run concurrently on the same stack. Every new
+// task that writes to a stack belongs here.
+var mutatingStackTasks = map[string]bool{
+	"compose_up":              true,
+	"compose_create":          true,
+	"compose_start":           true,
+	"compose_down":            true,
+	"compose_deploy":          true,
+	"compose_remove":          true,
+	"compose_pull":            true,
+	"compose_pause":           true,
+	"compose_unpause":         true,
+	"compose_restart_service": true,
+	"compose_create_project":  true,
+	"compose_update_project":  true,
+	"compose_delete_project":  true,
+	"compose_create_from_git": true,
+	"compose_git_pull":        true,
+	"compose_import_archive":  true,
+	"stack_prune":             true,
+}
+
+// stackLocks serializes operations per stack while letting different stacks
+// proceed in parallel. Each stack gets a one-slot channel so waiting can time out.
+type stackLocks struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+}
+
+func newStackLocks() *stackLocks {
+	return &stackLocks{locks: map[string]chan struct{}{}}
+}
+
+func (l *stackLocks) get(name string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, ok := l.locks[name]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		l.locks[name] = lock
+	}
+	return lock
+}
+
+// acquire locks the named stack, waiting at most timeout. The returned
+// function releases the lock.
+func (l *stackLocks) acquire(ctx context.Context, name string, timeout time.Duration) (func(), error) {
+	lock := l.get(name)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: %s has another operation in progress", ErrStackBusy, name)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
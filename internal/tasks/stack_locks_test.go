@@ -0,0 +1,124 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+func TestStackLocksSerializeSameStack(t *testing.T) {
+	locks := newStackLocks()
+
+	var active, maxActive int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := locks.acquire(context.Background(), "web", time.Second)
+			if err != nil {
+				t.Errorf("acquire() error = %v", err)
+				return
+			}
+			defer release()
+
+			n := atomic.AddInt32(&active, 1)
+			for {
+				prev := atomic.LoadInt32(&maxActive)
+				if n <= prev || atomic.CompareAndSwapInt32(&maxActive, prev, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+		}()
+	}
+	wg.Wait()
+
+	if maxActive != 1 {
+		t.Errorf("expected at most one holder per stack, got %d", maxActive)
+	}
+}
+
+func TestStackLocksAllowDifferentStacks(t *testing.T) {
+	locks := newStackLocks()
+
+	release, err := locks.acquire(context.Background(), "web", time.Second)
+	if err != nil {
+		t.Fatalf("acquire(web) error = %v", err)
+	}
+	defer release()
+
+	other, err := locks.acquire(context.Background(), "db", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected a different stack to lock independently, got %v", err)
+	}
+	other()
+}
+
+func TestStackLocksTimeout(t *testing.T) {
+	locks := newStackLocks()
+
+	release, err := locks.acquire(context.Background(), "web", time.Second)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	if _, err := locks.acquire(context.Background(), "web", 10*time.Millisecond); !errors.Is(err, ErrStackBusy) {
+		t.Errorf("expected ErrStackBusy while locked, got %v", err)
+	} else if errdefs.Code(err) != errdefs.CodeConflict {
+		t.Errorf("expected ErrStackBusy to be a conflict, got %s", errdefs.Code(err))
+	}
+
+	release()
+
+	again, err := locks.acquire(context.Background(), "web", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected lock to be free after release, got %v", err)
+	}
+	again()
+}
+
+func TestExecuteTaskWaitsForStackLock(t *testing.T) {
+	for _, taskType := range []string{"compose_down", "compose_restart_service", "compose_import_archive"} {
+		t.Run(taskType, func(t *testing.T) {
+			cfg := &config.Config{ComposeBasePath: t.TempDir()}
+			manager := NewManager(docker.NewClient(), cfg)
+
+			release, err := manager.locks.acquire(context.Background(), "busy", time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				manager.ExecuteTask(taskType, map[string]interface{}{"project_name": "busy"})
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				t.Fatalf("expected %s to wait for the stack lock", taskType)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			release()
+			<-done
+		})
+	}
+}
+
+func TestMutatingStackTasksAreNotReadOnly(t *testing.T) {
+	for taskType := range mutatingStackTasks {
+		if readOnlyTasks[taskType] {
+			t.Errorf("%s is both a mutating stack task and read-only", taskType)
+		}
+	}
+}
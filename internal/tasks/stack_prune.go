@@ -0,0 +1,86 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ofkm/arcane-agent/internal/compose"
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// executeStackPrune clears out what a stack leaves behind after services were
+// removed or images changed: it takes the stack down with --remove-orphans and
+// then removes the images the stack used that no other container needs.
+// Like system_prune it is destructive, so the payload must set confirm.
+func (m *Manager) executeStackPrune(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, composePath, err := m.getComposeProjectPath(payload)
+	if err != nil {
+		return nil, err
+	}
+	if confirm, _ := payload["confirm"].(bool); !confirm {
+		return nil, fmt.Errorf("stack_prune requires confirm: true")
+	}
+
+	// Collect images before down, while the containers still reference them
+	images, err := m.dockerClient.ProjectImages(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	if output, err := m.dockerClient.ComposeConfig(ctx, composePath, projectName); err == nil {
+		if spec, err := compose.ParseProjectSpec([]byte(output)); err == nil {
+			for _, service := range spec.Services {
+				if service.Image != "" {
+					images = append(images, service.Image)
+				}
+			}
+		}
+	}
+
+	removed, err := m.dockerClient.ComposeDownRemoveOrphans(ctx, composePath, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	removedImages, keptImages := []string{}, []string{}
+	imageErrors := map[string]string{}
+	for _, image := range uniqueSorted(images) {
+		_, err := m.dockerClient.RemoveImage(ctx, image, false)
+		switch {
+		case err == nil:
+			removedImages = append(removedImages, image)
+		case errors.Is(err, errdefs.ErrConflict):
+			// Still used by a container outside this stack
+			keptImages = append(keptImages, image)
+		case errors.Is(err, errdefs.ErrNotFound):
+		default:
+			imageErrors[image] = err.Error()
+		}
+	}
+
+	result := map[string]interface{}{
+		"project_name": projectName,
+		"containers":   removed.Containers,
+		"networks":     removed.Networks,
+		"images":       removedImages,
+		"kept_images":  keptImages,
+	}
+	if len(imageErrors) > 0 {
+		result["image_errors"] = imageErrors
+	}
+	return result, nil
+}
+
+func uniqueSorted(values []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,97 @@
+package tasks
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// stackStatusCache holds the most recent stack list so stack_list does not
+// shell out to docker-compose for every project on each request
+type stackStatusCache struct {
+	mu            sync.RWMutex
+	stacks        []map[string]interface{}
+	updatedAt     time.Time
+	invalidatedAt time.Time
+}
+
+// store caches stacks collected starting at collectedAt. A collection that
+// began before the last invalidation may predate the change that caused it
+// and is dropped.
+func (c *stackStatusCache) store(stacks []map[string]interface{}, collectedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if collectedAt.Before(c.invalidatedAt) {
+		return
+	}
+	c.stacks = stacks
+	c.updatedAt = time.Now()
+}
+
+// invalidate empties the cache so the next stack_list collects fresh
+// statuses, e.g. after a task changed a stack
+func (c *stackStatusCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stacks = nil
+	c.updatedAt = time.Time{}
+	c.invalidatedAt = time.Now()
+}
+
+// snapshot returns the cached stack list with its age. Stacks are reported
+// stale once they are older than two refresh intervals.
+func (c *stackStatusCache) snapshot(interval time.Duration) (map[string]interface{}, bool) {
+	if interval <= 0 {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.updatedAt.IsZero() {
+		return nil, false
+	}
+
+	return map[string]interface{}{
+		"stacks":    c.stacks,
+		"cached":    true,
+		"cached_at": c.updatedAt.UTC().Format(time.RFC3339),
+		"stale":     time.Since(c.updatedAt) > 2*interval,
+	}, true
+}
+
+// RunStatusRefresher periodically refreshes the stack status cache until ctx
+// is cancelled. It returns immediately when STACK_STATUS_INTERVAL is zero.
+func (m *Manager) RunStatusRefresher(ctx context.Context) {
+	interval := m.config.StackStatusInterval
+	if interval <= 0 {
+		return
+	}
+
+	m.refreshStackStatuses(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.refreshStackStatuses(ctx)
+		}
+	}
+}
+
+func (m *Manager) refreshStackStatuses(ctx context.Context) {
+	collectedAt := time.Now()
+	stacks, err := m.collectStacks(ctx)
+	if err != nil {
+		slog.Warn("Failed to refresh stack statuses", "error", err)
+		return
+	}
+	m.statusCache.store(stacks, collectedAt)
+}
@@ -0,0 +1,128 @@
+package tasks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+func TestStackStatusCacheSnapshot(t *testing.T) {
+	cache := &stackStatusCache{}
+
+	if _, ok := cache.snapshot(30 * time.Second); ok {
+		t.Error("Expected empty cache to miss")
+	}
+
+	cache.store([]map[string]interface{}{{"name": "web"}}, time.Now())
+
+	if _, ok := cache.snapshot(0); ok {
+		t.Error("Expected cache to be bypassed when interval is zero")
+	}
+
+	snapshot, ok := cache.snapshot(30 * time.Second)
+	if !ok {
+		t.Fatal("Expected cache hit")
+	}
+	if snapshot["stale"] != false {
+		t.Errorf("Expected fresh snapshot, got stale=%v", snapshot["stale"])
+	}
+	if snapshot["cached_at"] == "" {
+		t.Error("Expected cached_at to be set")
+	}
+
+	cache.updatedAt = time.Now().Add(-2 * time.Minute)
+	snapshot, _ = cache.snapshot(30 * time.Second)
+	if snapshot["stale"] != true {
+		t.Errorf("Expected stale snapshot, got stale=%v", snapshot["stale"])
+	}
+}
+
+func TestStackStatusCacheInvalidate(t *testing.T) {
+	cache := &stackStatusCache{}
+	collectedAt := time.Now()
+
+	cache.store([]map[string]interface{}{{"name": "web"}}, collectedAt)
+	cache.invalidate()
+	if _, ok := cache.snapshot(30 * time.Second); ok {
+		t.Fatal("Expected invalidated cache to miss")
+	}
+
+	// A refresh that started before the invalidation must not repopulate it
+	cache.store([]map[string]interface{}{{"name": "web"}}, collectedAt)
+	if _, ok := cache.snapshot(30 * time.Second); ok {
+		t.Error("Expected a collection predating the invalidation to be dropped")
+	}
+
+	cache.store([]map[string]interface{}{}, time.Now())
+	if _, ok := cache.snapshot(30 * time.Second); !ok {
+		t.Error("Expected a fresh collection to be cached")
+	}
+}
+
+func TestMutatingTaskInvalidatesStackList(t *testing.T) {
+	cfg := &config.Config{
+		ComposeBasePath:     t.TempDir(),
+		StackStatusInterval: 30 * time.Second,
+	}
+	manager := NewManager(docker.NewClient(), cfg)
+	manager.statusCache.store([]map[string]interface{}{{"name": "gone"}}, time.Now())
+
+	// Fails since the project does not exist, but still drops the cache
+	manager.ExecuteTask("compose_remove", map[string]interface{}{"project_name": "gone"})
+
+	if _, ok := manager.statusCache.snapshot(cfg.StackStatusInterval); ok {
+		t.Error("Expected compose_remove to invalidate the stack status cache")
+	}
+}
+
+func TestStackListServedFromCache(t *testing.T) {
+	cfg := &config.Config{
+		ComposeBasePath:     t.TempDir(),
+		StackStatusInterval: 30 * time.Second,
+	}
+	manager := NewManager(docker.NewClient(), cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		manager.RunStatusRefresher(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := manager.statusCache.snapshot(cfg.StackStatusInterval); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected refresher to populate the cache")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	result, err := manager.ExecuteTask("stack_list", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("stack_list failed: %v", err)
+	}
+	if result.(map[string]interface{})["cached"] != true {
+		t.Error("Expected stack_list to be served from cache")
+	}
+
+	result, err = manager.ExecuteTask("stack_list", map[string]interface{}{"refresh": true})
+	if err != nil {
+		t.Fatalf("stack_list refresh failed: %v", err)
+	}
+	if _, cached := result.(map[string]interface{})["cached"]; cached {
+		t.Error("Expected refresh to bypass the cache")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Expected refresher to stop when context is cancelled")
+	}
+}
@@ -4,6 +4,11 @@ import (
 	"context"
 	"os/exec"
 	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/hostinfo"
+	"github.com/ofkm/arcane-agent/internal/version"
 )
 
 // SystemTaskExecutor handles system-level tasks
@@ -81,3 +86,47 @@ func (s *SystemTaskExecutor) GetMemoryUsage(ctx context.Context) (interface{}, e
 		"platform":     runtime.GOOS,
 	}, nil
 }
+
+// executeSystemFacts describes the agent and its host in one result: platform,
+// CPUs, memory, kernel, Docker and Compose versions and agent uptime. Facts
+// that cannot be determined, e.g. while Docker is down, are left empty rather
+// than failing the task.
+func (m *Manager) executeSystemFacts(ctx context.Context) (interface{}, error) {
+	facts, err := NewSystemTaskExecutor().GetSystemInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := facts.(map[string]interface{})
+
+	result["agent_version"] = version.GetVersion()
+	result["started_at"] = m.startedAt.UTC().Format(time.RFC3339)
+	result["uptime_seconds"] = int64(time.Since(m.startedAt).Seconds())
+
+	var memoryTotal, memoryAvailable int64
+	if memory := hostinfo.Collect(m.config.ComposeBasePath).Memory; memory != nil {
+		memoryTotal, memoryAvailable = memory.Total, memory.Free
+	}
+
+	dockerVersion, kernelVersion := "", ""
+	if info, err := m.dockerClient.GetDockerInfo(ctx); err == nil {
+		dockerVersion, _ = info["version"].(string)
+		kernelVersion, _ = info["kernel_version"].(string)
+		if total, ok := info["memory_total"].(int64); ok && memoryTotal == 0 {
+			memoryTotal = total
+		}
+	}
+	if kernelVersion == "" && runtime.GOOS != "windows" {
+		if output, err := exec.CommandContext(ctx, "uname", "-r").Output(); err == nil {
+			kernelVersion = strings.TrimSpace(string(output))
+		}
+	}
+
+	composeVersion, _ := m.dockerClient.ComposeVersion(ctx)
+
+	result["memory_total"] = memoryTotal
+	result["memory_available"] = memoryAvailable
+	result["kernel_version"] = kernelVersion
+	result["docker_version"] = dockerVersion
+	result["compose_version"] = composeVersion
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// runningTasks maps the IDs of in-flight tracked tasks to the functions that
+// cancel their contexts
+type runningTasks struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelCauseFunc
+}
+
+func newRunningTasks() *runningTasks {
+	return &runningTasks{cancels: map[string]context.CancelCauseFunc{}}
+}
+
+func (r *runningTasks) add(id string, cancel context.CancelCauseFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancels[id] = cancel
+}
+
+func (r *runningTasks) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cancels, id)
+}
+
+// cancel cancels the task's context, which kills any docker or compose
+// process it is waiting on
+func (r *runningTasks) cancel(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cancel, ok := r.cancels[id]
+	if ok {
+		cancel(errdefs.ErrCancelled)
+	}
+	return ok
+}
+
+// executeTaskCancel cancels an in-flight task by ID
+func (m *Manager) executeTaskCancel(payload map[string]interface{}) (interface{}, error) {
+	id, ok := payload["task_id"].(string)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("missing task_id")
+	}
+
+	if !m.running.cancel(id) {
+		return nil, fmt.Errorf("%w: task %s is not running", errdefs.ErrNotFound, id)
+	}
+	return map[string]interface{}{"task_id": id, "cancelled": true}, nil
+}
@@ -0,0 +1,78 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+func TestTaskCancelStopsRunningTask(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker is a shell script")
+	}
+
+	bin := filepath.Join(t.TempDir(), "docker")
+	script := "#!/bin/sh\ncase \"$1\" in run|pull) exec sleep 30;; esac\nexit 0\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker: %v", err)
+	}
+
+	cfg := &config.Config{ComposeBasePath: t.TempDir(), TaskHistorySize: 10}
+	manager := NewManager(docker.NewClientWithOptions(docker.ClientOptions{Binary: bin}), cfg)
+	ctx := context.Background()
+
+	tests := []struct {
+		taskType string
+		payload  map[string]interface{}
+	}{
+		{"run_job", map[string]interface{}{"image": "alpine"}},
+		{"docker_command", map[string]interface{}{"command": "pull", "args": []interface{}{"alpine"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.taskType, func(t *testing.T) {
+			id := "slow-" + tt.taskType
+			done := make(chan error, 1)
+			go func() {
+				_, err := manager.ExecuteTrackedTask(ctx, id, tt.taskType, tt.payload)
+				done <- err
+			}()
+
+			// Wait for the task to register before cancelling it
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				if _, err := manager.ExecuteTaskContext(ctx, "task_cancel", map[string]interface{}{"task_id": id}); err == nil {
+					break
+				} else if !errors.Is(err, errdefs.ErrNotFound) || time.Now().After(deadline) {
+					t.Fatalf("task_cancel error = %v", err)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, errdefs.ErrCancelled) {
+					t.Errorf("Expected cancelled error, got %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Cancelled task did not stop")
+			}
+
+			record, ok := manager.history.get(id)
+			if !ok || record.Status != TaskCancelled {
+				t.Errorf("Expected cancelled history record, got %+v", record)
+			}
+
+			if _, err := manager.ExecuteTaskContext(ctx, "task_cancel", map[string]interface{}{"task_id": id}); !errors.Is(err, errdefs.ErrNotFound) {
+				t.Errorf("Expected not found cancelling a finished task, got %v", err)
+			}
+		})
+	}
+}
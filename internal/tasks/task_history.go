@@ -0,0 +1,164 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+// maxHistoryOutput bounds how much of a task's result or error is kept
+const maxHistoryOutput = 4096
+
+// Task history statuses
+const (
+	TaskRunning   = "running"
+	TaskCompleted = "completed"
+	TaskFailed    = "failed"
+	TaskCancelled = "cancelled"
+)
+
+// TaskRecord is the local record of one executed task, kept so a result the
+// server never received can still be looked up on the agent
+type TaskRecord struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Output     string     `json:"output,omitempty"`
+	Truncated  bool       `json:"truncated,omitempty"`
+}
+
+// taskHistory is a ring buffer of the most recent tasks. Once full, starting
+// a task evicts the oldest record.
+type taskHistory struct {
+	mu      sync.Mutex
+	records []TaskRecord
+	next    int
+	count   int
+	byID    map[string]int
+}
+
+func newTaskHistory(capacity int) *taskHistory {
+	return &taskHistory{records: make([]TaskRecord, capacity), byID: map[string]int{}}
+}
+
+func (h *taskHistory) start(id, taskType string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.records) == 0 {
+		return
+	}
+	if h.count == len(h.records) {
+		// A re-sent task ID may already point at a newer slot
+		if oldID := h.records[h.next].ID; h.byID[oldID] == h.next {
+			delete(h.byID, oldID)
+		}
+	} else {
+		h.count++
+	}
+
+	h.records[h.next] = TaskRecord{ID: id, Type: taskType, Status: TaskRunning, StartedAt: time.Now()}
+	h.byID[id] = h.next
+	h.next = (h.next + 1) % len(h.records)
+}
+
+func (h *taskHistory) finish(id, status, output string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	idx, ok := h.byID[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	record := &h.records[idx]
+	record.Status = status
+	record.FinishedAt = &now
+	record.Output, record.Truncated = truncateOutput(output, maxHistoryOutput)
+}
+
+func (h *taskHistory) get(id string) (TaskRecord, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	idx, ok := h.byID[id]
+	if !ok {
+		return TaskRecord{}, false
+	}
+	return h.records[idx], true
+}
+
+// list returns the recorded tasks, newest first
+func (h *taskHistory) list() []TaskRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := make([]TaskRecord, 0, h.count)
+	for i := 1; i <= h.count; i++ {
+		records = append(records, h.records[(h.next-i+len(h.records))%len(h.records)])
+	}
+	return records
+}
+
+// truncateOutput cuts s to at most limit bytes without splitting a rune
+func truncateOutput(s string, limit int) (string, bool) {
+	if len(s) <= limit {
+		return s, false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut], true
+}
+
+// ExecuteTrackedTask runs a task like ExecuteTaskContext, records it in the
+// task history under id and lets task_cancel cancel it while it runs
+func (m *Manager) ExecuteTrackedTask(ctx context.Context, id, taskType string, payload map[string]interface{}) (interface{}, error) {
+	m.history.start(id, taskType)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	m.running.add(id, cancel)
+	defer m.running.remove(id)
+
+	result, err := m.ExecuteTaskContext(ctx, taskType, payload)
+	if err != nil {
+		if errors.Is(context.Cause(ctx), errdefs.ErrCancelled) {
+			err = fmt.Errorf("%w: task %s was cancelled: %v", errdefs.ErrCancelled, id, err)
+			m.history.finish(id, TaskCancelled, err.Error())
+			return nil, err
+		}
+		m.history.finish(id, TaskFailed, err.Error())
+		return result, err
+	}
+
+	output, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		output = []byte(fmt.Sprintf("%v", result))
+	}
+	m.history.finish(id, TaskCompleted, string(output))
+	return result, nil
+}
+
+// executeTaskGet returns the history record of one task
+func (m *Manager) executeTaskGet(payload map[string]interface{}) (interface{}, error) {
+	id, ok := payload["task_id"].(string)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("missing task_id")
+	}
+
+	record, ok := m.history.get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: task %s is not in the history", errdefs.ErrNotFound, id)
+	}
+	return record, nil
+}
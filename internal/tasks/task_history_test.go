@@ -0,0 +1,129 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+	"github.com/ofkm/arcane-agent/internal/errdefs"
+)
+
+func historyIDs(records []TaskRecord) string {
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestTaskHistoryRecordsTasks(t *testing.T) {
+	history := newTaskHistory(3)
+	history.start("t1", "container_list")
+	history.start("t2", "image_pull")
+
+	record, ok := history.get("t1")
+	if !ok || record.Status != TaskRunning || record.FinishedAt != nil {
+		t.Fatalf("Expected running record for t1, got %+v", record)
+	}
+
+	history.finish("t1", TaskCompleted, `{"containers":[]}`)
+	history.finish("t2", TaskFailed, "pull access denied")
+
+	record, _ = history.get("t1")
+	if record.Status != TaskCompleted || record.FinishedAt == nil || record.Output != `{"containers":[]}` {
+		t.Errorf("Unexpected record for t1: %+v", record)
+	}
+	record, _ = history.get("t2")
+	if record.Type != "image_pull" || record.Status != TaskFailed || record.Output != "pull access denied" {
+		t.Errorf("Unexpected record for t2: %+v", record)
+	}
+
+	if got := historyIDs(history.list()); got != "t2,t1" {
+		t.Errorf("Expected newest first, got %s", got)
+	}
+}
+
+func TestTaskHistoryEvictsOldestAtCapacity(t *testing.T) {
+	history := newTaskHistory(3)
+	for _, id := range []string{"t1", "t2", "t3", "t4", "t5"} {
+		history.start(id, "container_list")
+	}
+
+	if got := historyIDs(history.list()); got != "t5,t4,t3" {
+		t.Errorf("Expected the three newest tasks, got %s", got)
+	}
+	for _, id := range []string{"t1", "t2"} {
+		if _, ok := history.get(id); ok {
+			t.Errorf("Expected %s to be evicted", id)
+		}
+	}
+
+	// Finishing an evicted task is a no-op
+	history.finish("t1", TaskCompleted, "late")
+	if got := historyIDs(history.list()); got != "t5,t4,t3" {
+		t.Errorf("Expected history unchanged, got %s", got)
+	}
+}
+
+func TestTaskHistoryKeepsResentIDAfterEviction(t *testing.T) {
+	history := newTaskHistory(3)
+	for _, id := range []string{"t1", "t2", "t1", "t3"} {
+		history.start(id, "container_list")
+	}
+
+	// Evicting the first t1 slot must not drop the newer t1
+	record, ok := history.get("t1")
+	if !ok || record.Status != TaskRunning {
+		t.Fatalf("Expected the re-sent t1 to stay known, got %+v, %v", record, ok)
+	}
+	history.finish("t1", TaskCompleted, "done")
+	if record, _ := history.get("t1"); record.Status != TaskCompleted {
+		t.Errorf("Expected t1 completed, got %+v", record)
+	}
+}
+
+func TestTaskHistoryDisabled(t *testing.T) {
+	history := newTaskHistory(0)
+	history.start("t1", "container_list")
+	history.finish("t1", TaskCompleted, "")
+
+	if records := history.list(); len(records) != 0 {
+		t.Errorf("Expected no records with history disabled, got %v", records)
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	if got, truncated := truncateOutput("short", 10); got != "short" || truncated {
+		t.Errorf("truncateOutput() = %q, %v", got, truncated)
+	}
+	// "é" is two bytes; cutting inside it must drop the whole rune
+	if got, truncated := truncateOutput("abé", 3); got != "ab" || !truncated {
+		t.Errorf("truncateOutput() = %q, %v", got, truncated)
+	}
+}
+
+func TestExecuteTrackedTask(t *testing.T) {
+	cfg := &config.Config{ComposeBasePath: t.TempDir(), TaskHistorySize: 10}
+	manager := NewManager(docker.NewClient(), cfg)
+	ctx := context.Background()
+
+	if _, err := manager.ExecuteTrackedTask(ctx, "t1", "no_such_task", map[string]interface{}{}); err == nil {
+		t.Fatal("Expected unknown task type to fail")
+	}
+
+	result, err := manager.ExecuteTaskContext(ctx, "task_get", map[string]interface{}{"task_id": "t1"})
+	if err != nil {
+		t.Fatalf("task_get error = %v", err)
+	}
+	record := result.(TaskRecord)
+	if record.Type != "no_such_task" || record.Status != TaskFailed || !strings.Contains(record.Output, "unknown task type") {
+		t.Errorf("Unexpected record %+v", record)
+	}
+
+	if _, err := manager.ExecuteTaskContext(ctx, "task_get", map[string]interface{}{"task_id": "missing"}); !errors.Is(err, errdefs.ErrNotFound) {
+		t.Errorf("Expected not found for an unknown task, got %v", err)
+	}
+}
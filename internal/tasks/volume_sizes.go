@@ -0,0 +1,106 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+// volumeSizeTTL is how long computed volume sizes are reused. Docker walks
+// every volume to size it, which is expensive on large volumes.
+const volumeSizeTTL = time.Minute
+
+// volumeSizeCache holds the last `docker system df -v` volume listing
+type volumeSizeCache struct {
+	mu        sync.Mutex
+	volumes   []docker.VolumeUsage
+	updatedAt time.Time
+}
+
+// get returns cached sizes while they are fresh, otherwise it reloads them.
+// The lock is held across the reload so concurrent callers share one df run.
+func (c *volumeSizeCache) get(refresh bool, load func() ([]docker.VolumeUsage, error)) ([]docker.VolumeUsage, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !refresh && !c.updatedAt.IsZero() && time.Since(c.updatedAt) < volumeSizeTTL {
+		return c.volumes, c.updatedAt, nil
+	}
+
+	volumes, err := load()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	c.volumes = volumes
+	c.updatedAt = time.Now()
+	return c.volumes, c.updatedAt, nil
+}
+
+// executeVolumeSize reports the bytes used by one volume, or by every volume
+// when volume_name is omitted. Pass refresh to bypass the cache.
+func (m *Manager) executeVolumeSize(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	refresh, _ := payload["refresh"].(bool)
+	volumes, measuredAt, err := m.volumeSizes.get(refresh, func() ([]docker.VolumeUsage, error) {
+		return m.dockerClient.VolumeSizes(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"measuredAt": measuredAt.UTC().Format(time.RFC3339),
+	}
+
+	volumeName, _ := payload["volume_name"].(string)
+	if volumeName == "" {
+		result["volumes"] = volumes
+		return result, nil
+	}
+
+	for _, volume := range volumes {
+		if volume.Name == volumeName {
+			result["volume"] = volume
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("volume %s not found", volumeName)
+}
+
+// executeStackVolumes lists the volumes compose created for a stack with their
+// sizes, which come from the same cache as volume_size
+func (m *Manager) executeStackVolumes(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	projectName, ok := payload["project_name"].(string)
+	if !ok || projectName == "" {
+		return nil, fmt.Errorf("project_name is required")
+	}
+
+	volumes, err := m.dockerClient.ProjectVolumes(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, _ := payload["refresh"].(bool)
+	sizes, measuredAt, err := m.volumeSizes.get(refresh, func() ([]docker.VolumeUsage, error) {
+		return m.dockerClient.VolumeSizes(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bySize := make(map[string]int64, len(sizes))
+	for _, usage := range sizes {
+		bySize[usage.Name] = usage.Size
+	}
+	for i := range volumes {
+		volumes[i].Size = bySize[volumes[i].Name]
+	}
+
+	return map[string]interface{}{
+		"project_name": projectName,
+		"volumes":      volumes,
+		"measuredAt":   measuredAt.UTC().Format(time.RFC3339),
+	}, nil
+}
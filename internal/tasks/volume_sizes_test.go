@@ -0,0 +1,93 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/ofkm/arcane-agent/internal/config"
+	"github.com/ofkm/arcane-agent/internal/docker"
+)
+
+func TestVolumeSizeCache(t *testing.T) {
+	cache := &volumeSizeCache{}
+	loads := 0
+	load := func() ([]docker.VolumeUsage, error) {
+		loads++
+		return []docker.VolumeUsage{{Name: "pgdata", Links: 1, Size: int64(loads)}}, nil
+	}
+
+	first, _, err := cache.get(false, load)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	second, _, _ := cache.get(false, load)
+	if loads != 1 || second[0].Size != first[0].Size {
+		t.Errorf("expected cached sizes to be reused, loads = %d", loads)
+	}
+
+	refreshed, _, _ := cache.get(true, load)
+	if loads != 2 || refreshed[0].Size != 2 {
+		t.Errorf("expected refresh to reload sizes, loads = %d", loads)
+	}
+
+	// Expired entries are reloaded
+	cache.updatedAt = cache.updatedAt.Add(-2 * volumeSizeTTL)
+	if _, _, _ = cache.get(false, load); loads != 3 {
+		t.Errorf("expected expired sizes to be reloaded, loads = %d", loads)
+	}
+
+	// Failures are not cached
+	failing := func() ([]docker.VolumeUsage, error) { return nil, errors.New("daemon unavailable") }
+	if _, _, err := cache.get(true, failing); err == nil {
+		t.Error("expected load error to be returned")
+	}
+	if volumes, _, _ := cache.get(false, load); loads != 3 || volumes[0].Size != 3 {
+		t.Errorf("expected previous sizes to survive a failed refresh, loads = %d", loads)
+	}
+}
+
+func TestExecuteStackVolumes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker is a shell script")
+	}
+
+	// The fake only lists volumes for the shop project's label filter
+	bin := filepath.Join(t.TempDir(), "docker")
+	script := `#!/bin/sh
+if [ "$1" = "volume" ]; then
+  [ "$4" = "label=com.docker.compose.project=shop" ] || exit 0
+  printf 'shop_pgdata\tlocal\t/var/lib/docker/volumes/shop_pgdata/_data\tpgdata\n'
+  printf 'shop_uploads\tlocal\t/var/lib/docker/volumes/shop_uploads/_data\tuploads\n'
+  exit 0
+fi
+cat <<'EOF_DF'
+Local Volumes space usage:
+
+VOLUME NAME    LINKS     SIZE
+shop_pgdata    1         1.5GB
+other_cache    0         10MB
+EOF_DF
+`
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker: %v", err)
+	}
+
+	manager := NewManager(docker.NewClientWithOptions(docker.ClientOptions{Binary: bin}), &config.Config{ComposeBasePath: t.TempDir()})
+	result, err := manager.ExecuteTask("stack_volumes", map[string]interface{}{"project_name": "shop"})
+	if err != nil {
+		t.Fatalf("ExecuteTask() error = %v", err)
+	}
+
+	volumes := result.(map[string]interface{})["volumes"].([]docker.StackVolume)
+	want := []docker.StackVolume{
+		{Name: "shop_pgdata", Declared: "pgdata", Driver: "local", Mountpoint: "/var/lib/docker/volumes/shop_pgdata/_data", Size: 1500000000},
+		{Name: "shop_uploads", Declared: "uploads", Driver: "local", Mountpoint: "/var/lib/docker/volumes/shop_uploads/_data"},
+	}
+	if !reflect.DeepEqual(volumes, want) {
+		t.Errorf("volumes = %+v, want %+v", volumes, want)
+	}
+}
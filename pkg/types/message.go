@@ -20,6 +20,11 @@ type TaskResult struct {
 	Status string      `json:"status"`
 	Result interface{} `json:"result,omitempty"`
 	Error  string      `json:"error,omitempty"`
+	// ErrorCode categorizes a failure, e.g. "not_found" or "conflict"
+	ErrorCode string `json:"error_code,omitempty"`
+	// ErrorDetails carries structured failure information when available,
+	// such as the failing service of a compose command
+	ErrorDetails interface{} `json:"error_details,omitempty"`
 }
 
 type AgentMetrics struct {